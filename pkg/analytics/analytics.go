@@ -18,6 +18,9 @@ type EventData struct {
 
 func TrackEvent(data EventData) error {
 	conf := config.NewConstants()
+	if conf.GetAnalyticsOptOut() {
+		return nil
+	}
 
 	url := conf.GetBrevAPIURl() + "/api/brevent"
 
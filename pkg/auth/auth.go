@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -382,6 +383,21 @@ func GetEmailFromToken(token string) string {
 	return email
 }
 
+// GetExpiryFromToken reads the exp claim, false when the token has none
+func GetExpiryFromToken(token string) (time.Time, bool) {
+	parser := jwt.Parser{}
+	claims := jwt.MapClaims{}
+	_, _, err := parser.ParseUnverified(token, &claims)
+	if err != nil {
+		return time.Time{}, false
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
 func AuthProviderFlagToCredentialProvider(authProviderFlag string) entity.CredentialProvider {
 	if authProviderFlag == "" {
 		return ""
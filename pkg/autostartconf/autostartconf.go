@@ -115,3 +115,48 @@ User=` + store.GetOSUser() + `
 	}
 	return nil
 }
+
+func NewIdleStopConfigurer(store AutoStartStore) DaemonConfigurer {
+	return LinuxSystemdConfigurer{
+		Store: store,
+		ValueConfigFile: `
+[Install]
+WantedBy=multi-user.target
+
+[Unit]
+Description=Brev idle instance autostop daemon
+After=systemd-user-sessions.service
+
+[Service]
+Type=simple
+ExecStart=brev tasks run autostop --user ` + store.GetOSUser() + `
+Restart=always
+User=` + store.GetOSUser() + `
+`,
+		ServiceName: "brevautostop.service",
+		ServiceType: "user",
+		TargetBin:   targetBin,
+	}
+}
+
+// NewStartExecsConfigurer runs the instance's start stage execs now and on every boot
+func NewStartExecsConfigurer(store AutoStartStore) DaemonConfigurer {
+	return LinuxSystemdConfigurer{
+		Store: store,
+		ValueConfigFile: `
+[Install]
+WantedBy=multi-user.target
+
+[Unit]
+Description=Brev instance start execs
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=brev setupworkspace --start-execs
+`,
+		ServiceName: "brevstartexecs.service",
+		ServiceType: "system",
+		TargetBin:   targetBin,
+	}
+}
@@ -0,0 +1,270 @@
+package autostop
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	DefaultProcRoot            = "/proc"
+	DefaultConnectionEventPath = "/etc/meta/connection_event"
+
+	// uids below this are system accounts, their processes don't count as someone working
+	minUserUID = 1000
+)
+
+// processes that are always around on an instance and say nothing about whether it is in use
+var ignoredProcesses = map[string]bool{
+	"sshd":         true,
+	"brev":         true,
+	"bash":         true,
+	"sh":           true,
+	"zsh":          true,
+	"tmux: server": true,
+	"systemd":      true,
+	"(sd-pam)":     true,
+}
+
+// Activity is one look at what is happening on the instance
+type Activity struct {
+	SSHSessions     int
+	CPUBusy         float64  // fraction of all cores busy since the last sample
+	ActiveProcesses []string // user processes that used cpu since the last sample
+	LastConnection  time.Time
+}
+
+// Probe samples activity from /proc, it keeps cpu counters between samples
+// so the first sample reports no cpu activity
+type Probe struct {
+	ProcRoot            string
+	ConnectionEventPath string
+	// ProcessCPUTicks is how many clock ticks a user process has to use between
+	// samples to count as active
+	ProcessCPUTicks uint64
+
+	lastCPU       *cpuTimes
+	lastProcesses map[int]uint64
+}
+
+func NewProbe() *Probe {
+	return &Probe{
+		ProcRoot:            DefaultProcRoot,
+		ConnectionEventPath: DefaultConnectionEventPath,
+		ProcessCPUTicks:     100, // a second of cpu at the usual 100 ticks per second
+	}
+}
+
+func (p *Probe) Sample() (Activity, error) {
+	var activity Activity
+	var err error
+	activity.SSHSessions, err = countSSHSessions(p.ProcRoot)
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+
+	cpu, err := readCPUTimes(p.ProcRoot)
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	if p.lastCPU != nil {
+		activity.CPUBusy = cpu.busySince(*p.lastCPU)
+	}
+	p.lastCPU = cpu
+
+	processes, err := readUserProcessTicks(p.ProcRoot)
+	if err != nil {
+		return Activity{}, breverrors.WrapAndTrace(err)
+	}
+	if p.lastProcesses != nil {
+		activity.ActiveProcesses = activeProcesses(p.ProcRoot, p.lastProcesses, processes, p.ProcessCPUTicks)
+	}
+	p.lastProcesses = processes
+
+	activity.LastConnection = readConnectionEvent(p.ConnectionEventPath)
+	return activity, nil
+}
+
+func listPIDs(procRoot string) ([]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	pids := []int{}
+	for _, e := range entries {
+		pid, convErr := strconv.Atoi(e.Name())
+		if convErr == nil && e.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// countSSHSessions counts sshd's per connection processes, they are named
+// sshd: user@pts/0 for shells and sshd: user@notty for editors, scp and tunnels
+func countSSHSessions(procRoot string) (int, error) {
+	pids, err := listPIDs(procRoot)
+	if err != nil {
+		return 0, breverrors.WrapAndTrace(err)
+	}
+	sessions := 0
+	for _, pid := range pids {
+		cmdline, readErr := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+		if readErr != nil {
+			continue // the process exited
+		}
+		cmd := string(bytes.TrimRight(bytes.ReplaceAll(cmdline, []byte{0}, []byte(" ")), " "))
+		if strings.HasPrefix(cmd, "sshd: ") && strings.Contains(cmd, "@") {
+			sessions++
+		}
+	}
+	return sessions, nil
+}
+
+type cpuTimes struct {
+	total uint64
+	idle  uint64
+}
+
+func (c cpuTimes) busySince(prev cpuTimes) float64 {
+	total := c.total - prev.total
+	if c.total <= prev.total || total == 0 {
+		return 0
+	}
+	idle := c.idle - prev.idle
+	if idle > total {
+		return 0
+	}
+	return float64(total-idle) / float64(total)
+}
+
+func readCPUTimes(procRoot string) (*cpuTimes, error) {
+	b, err := os.ReadFile(filepath.Join(procRoot, "stat")) //nolint:gosec // proc root is a constant outside of tests
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	line, _, _ := strings.Cut(string(b), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return nil, breverrors.New("unexpected /proc/stat format")
+	}
+	var times cpuTimes
+	for i, f := range fields[1:] {
+		v, convErr := strconv.ParseUint(f, 10, 64)
+		if convErr != nil {
+			return nil, breverrors.WrapAndTrace(convErr)
+		}
+		// guest time is already counted in user and nice
+		if i >= 8 {
+			break
+		}
+		times.total += v
+		// idle and iowait
+		if i == 3 || i == 4 {
+			times.idle += v
+		}
+	}
+	return &times, nil
+}
+
+// readUserProcessTicks returns the cpu ticks used by each process owned by a
+// regular user
+func readUserProcessTicks(procRoot string) (map[int]uint64, error) {
+	pids, err := listPIDs(procRoot)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	ticks := map[int]uint64{}
+	for _, pid := range pids {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		uid, ok := readUID(dir)
+		if !ok || uid < minUserUID {
+			continue
+		}
+		comm, used, ok := readStat(dir)
+		if !ok || ignoredProcesses[comm] {
+			continue
+		}
+		ticks[pid] = used
+	}
+	return ticks, nil
+}
+
+func activeProcesses(procRoot string, before, now map[int]uint64, threshold uint64) []string {
+	active := []string{}
+	for pid, used := range now {
+		prev := before[pid] // new processes count everything they used so far
+		if used < prev || used-prev < threshold {
+			continue
+		}
+		comm, _, _ := readStat(filepath.Join(procRoot, strconv.Itoa(pid)))
+		active = append(active, comm)
+	}
+	return active
+}
+
+func readUID(dir string) (int, bool) {
+	b, err := os.ReadFile(filepath.Join(dir, "status")) //nolint:gosec // reading /proc
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, false
+		}
+		uid, convErr := strconv.Atoi(fields[1])
+		return uid, convErr == nil
+	}
+	return 0, false
+}
+
+// readStat returns the command name and utime+stime from /proc/<pid>/stat,
+// the name is in parens and can contain spaces so fields are counted after it
+func readStat(dir string) (string, uint64, bool) {
+	b, err := os.ReadFile(filepath.Join(dir, "stat")) //nolint:gosec // reading /proc
+	if err != nil {
+		return "", 0, false
+	}
+	s := string(b)
+	open := strings.Index(s, "(")
+	end := strings.LastIndex(s, ")")
+	if open < 0 || end < open {
+		return "", 0, false
+	}
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 13 {
+		return "", 0, false
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return s[open+1 : end], utime + stime, true
+}
+
+// readConnectionEvent reads the time brev write-connection-event last
+// recorded an editor or shell connecting, zero if there isn't one
+func readConnectionEvent(path string) time.Time {
+	b, err := os.ReadFile(path) //nolint:gosec // fixed path outside of tests
+	if err != nil {
+		return time.Time{}
+	}
+	ts, err := time.Parse(time.RFC3339, strings.TrimSpace(string(b)))
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
@@ -0,0 +1,127 @@
+package autostop
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func writeProc(t *testing.T, root string, pid string, cmdline string, uid int, stat string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	assert.Nil(t, os.MkdirAll(dir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "status"), []byte("Name:\tx\nUid:\t"+strconv.Itoa(uid)+"\t0\t0\t0\n"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644))
+}
+
+func procStat(pid string, comm string, ticks string) string {
+	return pid + " (" + comm + ") S 1 1 1 0 -1 4194560 100 0 0 0 " + ticks + " 0 0 0 20 0 1 0 100 0 0"
+}
+
+func TestProbe(t *testing.T) {
+	root := t.TempDir()
+	stat := filepath.Join(root, "stat")
+	assert.Nil(t, os.WriteFile(stat, []byte("cpu  100 0 100 800 0 0 0 0 0 0\ncpu0 1 2 3\n"), 0o644))
+	writeProc(t, root, "10", "sshd: ubuntu@pts/0\x00\x00", 1000, procStat("10", "sshd", "5"))
+	writeProc(t, root, "11", "sshd: /usr/sbin/sshd -D\x00", 0, procStat("11", "sshd", "5"))
+	writeProc(t, root, "12", "python\x00train.py\x00", 1000, procStat("12", "my python", "50"))
+	writeProc(t, root, "13", "/usr/bin/dockerd\x00", 0, procStat("13", "dockerd", "900"))
+	event := filepath.Join(root, "connection_event")
+	assert.Nil(t, os.WriteFile(event, []byte("2024-01-02T03:04:05Z"), 0o644))
+
+	probe := &Probe{ProcRoot: root, ConnectionEventPath: event, ProcessCPUTicks: 100}
+	a, err := probe.Sample()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, a.SSHSessions)
+	assert.Equal(t, 0.0, a.CPUBusy)
+	assert.Empty(t, a.ActiveProcesses)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), a.LastConnection.UTC())
+
+	// half of the new ticks were busy, the user process used 150 ticks, root's are ignored
+	assert.Nil(t, os.WriteFile(stat, []byte("cpu  150 0 150 900 0 0 0 0 0 0\n"), 0o644))
+	writeProc(t, root, "12", "python\x00train.py\x00", 1000, procStat("12", "my python", "200"))
+	writeProc(t, root, "13", "/usr/bin/dockerd\x00", 0, procStat("13", "dockerd", "9000"))
+	a, err = probe.Sample()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, a.CPUBusy)
+	assert.Equal(t, []string{"my python"}, a.ActiveProcesses)
+}
+
+func TestTracker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewTracker(time.Hour, start)
+	assert.Equal(t, 10*time.Minute, tracker.WarnBefore)
+
+	assert.Equal(t, Keep, tracker.Observe(start.Add(30*time.Minute), Activity{SSHSessions: 1}))
+	assert.Equal(t, Keep, tracker.Observe(start.Add(60*time.Minute), Activity{CPUBusy: 0.01}))
+	assert.Equal(t, Warn, tracker.Observe(start.Add(80*time.Minute), Activity{}))
+	// only warn once per idle stretch
+	assert.Equal(t, Keep, tracker.Observe(start.Add(81*time.Minute), Activity{}))
+	// a connection event resets the clock and the warning
+	assert.Equal(t, Keep, tracker.Observe(start.Add(82*time.Minute), Activity{LastConnection: start.Add(81 * time.Minute)}))
+	assert.Equal(t, "connection event", tracker.LastReason())
+	assert.Equal(t, Warn, tracker.Observe(start.Add(131*time.Minute), Activity{}))
+	assert.Equal(t, Stop, tracker.Observe(start.Add(141*time.Minute), Activity{}))
+
+	tracker = NewTracker(8*time.Minute, start)
+	assert.Equal(t, 2*time.Minute, tracker.WarnBefore)
+	assert.Equal(t, Keep, tracker.Observe(start.Add(5*time.Minute), Activity{ActiveProcesses: []string{"python"}}))
+	assert.Contains(t, tracker.LastReason(), "python")
+}
+
+type fakeStore struct {
+	autostartconf.AutoStartStore
+	workspace entity.Workspace
+}
+
+func (f *fakeStore) GetCurrentWorkspaceID() (string, error) {
+	return "ws1", nil
+}
+
+func (f *fakeStore) GetWorkspace(_ string) (*entity.Workspace, error) {
+	w := f.workspace
+	return &w, nil
+}
+
+func TestIdleMonitor(t *testing.T) {
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "stat"), []byte("cpu  0 0 0 100 0 0 0 0 0 0\n"), 0o644))
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeStore{workspace: entity.Workspace{IsStoppable: false, StopTimeout: 20 * time.Minute}}
+	messages := []string{}
+	stopped := 0
+	m := &IdleMonitor{
+		Store:     store,
+		probe:     &Probe{ProcRoot: root, ConnectionEventPath: filepath.Join(root, "none")},
+		now:       func() time.Time { return now },
+		broadcast: func(message string) error { messages = append(messages, message); return nil },
+		stopSelf:  func() error { stopped++; return nil },
+	}
+
+	// autostop off, idle time doesn't count
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, m.Run())
+		now = now.Add(5 * time.Minute)
+	}
+	assert.Equal(t, 0, stopped)
+
+	store.workspace.IsStoppable = true
+	m.refreshedAt = time.Time{}
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, m.Run())
+		now = now.Add(5 * time.Minute)
+	}
+	assert.Equal(t, 0, stopped)
+	assert.Len(t, messages, 1)
+	assert.Nil(t, m.Run())
+	assert.Equal(t, 1, stopped)
+}
@@ -0,0 +1,186 @@
+// Package autostop stops an instance that nobody is using, it runs on the
+// instance as a brev task and watches ssh sessions, cpu, user processes and
+// editor connections
+package autostop
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/tasks"
+)
+
+const (
+	// the instance settings only change from the console or brev apply so
+	// there's no need to ask the api every sample
+	settingsRefresh = 15 * time.Minute
+)
+
+type IdleStopStore interface {
+	autostartconf.AutoStartStore
+	GetCurrentWorkspaceID() (string, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+// IdleStopTask runs the idle monitor until the task daemon is stopped
+type IdleStopTask struct {
+	Store IdleStopStore
+}
+
+var _ tasks.Task = IdleStopTask{}
+
+func NewIdleStopTask(store IdleStopStore) IdleStopTask {
+	return IdleStopTask{Store: store}
+}
+
+func (ist IdleStopTask) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{}
+}
+
+func (ist IdleStopTask) Run() error {
+	err := tasks.RunTasks([]tasks.Task{NewIdleMonitor(ist.Store)})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (ist IdleStopTask) Configure() error {
+	err := autostartconf.NewIdleStopConfigurer(ist.Store).Install()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// IdleMonitor samples activity every minute and stops the instance once it
+// has been idle for the instance's stop timeout, warning logged in shells first
+type IdleMonitor struct {
+	Store IdleStopStore
+
+	probe       *Probe
+	tracker     *Tracker
+	enabled     bool
+	refreshedAt time.Time
+
+	now       func() time.Time
+	broadcast func(message string) error
+	stopSelf  func() error
+}
+
+var _ tasks.Task = &IdleMonitor{}
+
+func NewIdleMonitor(store IdleStopStore) *IdleMonitor {
+	return &IdleMonitor{
+		Store:     store,
+		probe:     NewProbe(),
+		now:       time.Now,
+		broadcast: wall,
+		stopSelf:  brevStopSelf,
+	}
+}
+
+func (m *IdleMonitor) GetTaskSpec() tasks.TaskSpec {
+	return tasks.TaskSpec{RunCronImmediately: true, Cron: "@every 1m"}
+}
+
+func (m *IdleMonitor) Configure() error {
+	return nil
+}
+
+func (m *IdleMonitor) Run() error {
+	now := m.now()
+	err := m.refreshSettings(now)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	activity, err := m.probe.Sample()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !m.enabled {
+		return nil
+	}
+
+	switch m.tracker.Observe(now, activity) {
+	case Warn:
+		left := m.tracker.Window - m.tracker.IdleFor(now)
+		message := fmt.Sprintf("brev: this instance has been idle for %s and will stop in %s unless it is used, turn off autostop in the console to keep it up",
+			m.tracker.IdleFor(now).Round(time.Minute), left.Round(time.Minute))
+		log.Print(message)
+		err = m.broadcast(message)
+		if err != nil {
+			log.Printf("could not warn logged in users: %v", err)
+		}
+	case Stop:
+		log.Printf("idle for %s (last activity: %s), stopping", m.tracker.IdleFor(now).Round(time.Minute), m.tracker.LastReason())
+		_ = m.broadcast("brev: stopping this idle instance now")
+		err = m.stopSelf()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	case Keep:
+	}
+	return nil
+}
+
+// refreshSettings picks up whether the instance may be stopped and its
+// timeout, the idle clock starts when monitoring does
+func (m *IdleMonitor) refreshSettings(now time.Time) error {
+	if m.tracker != nil && now.Sub(m.refreshedAt) < settingsRefresh {
+		return nil
+	}
+	id, err := m.Store.GetCurrentWorkspaceID()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if id == "" {
+		return breverrors.NewValidationError("autostop only runs on a brev instance")
+	}
+	workspace, err := m.Store.GetWorkspace(id)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	m.refreshedAt = now
+
+	window := workspace.GetStopTimeout()
+	if window <= 0 {
+		window = DefaultIdleWindow
+	}
+	enabled := workspace.GetIsStoppable()
+	// time spent with autostop off doesn't count towards the window
+	if m.tracker == nil || (enabled && !m.enabled) {
+		m.tracker = NewTracker(window, now)
+	} else {
+		m.tracker.SetWindow(window)
+	}
+	if enabled != m.enabled {
+		log.Printf("autostop enabled=%t window=%s", enabled, window)
+	}
+	m.enabled = enabled
+	return nil
+}
+
+func wall(message string) error {
+	cmd := exec.Command("wall")
+	cmd.Stdin = strings.NewReader(message + "\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return breverrors.Errorf("wall: %w: %s", err, out)
+	}
+	return nil
+}
+
+func brevStopSelf() error {
+	out, err := exec.Command("brev", "stop", "self").CombinedOutput()
+	if err != nil {
+		return breverrors.Errorf("brev stop self: %w: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package autostop
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultIdleWindow   = 2 * time.Hour
+	DefaultWarnBefore   = 10 * time.Minute
+	DefaultCPUThreshold = 0.05
+)
+
+type Decision int
+
+const (
+	Keep Decision = iota
+	Warn
+	Stop
+)
+
+// Tracker turns activity samples into a decision, the instance is idle once
+// nothing has happened for Window
+type Tracker struct {
+	Window       time.Duration
+	WarnBefore   time.Duration
+	CPUThreshold float64
+
+	lastActive time.Time
+	reason     string
+	warned     bool
+}
+
+func NewTracker(window time.Duration, now time.Time) *Tracker {
+	return &Tracker{
+		Window:       window,
+		WarnBefore:   warnBefore(window),
+		CPUThreshold: DefaultCPUThreshold,
+		lastActive:   now,
+		reason:       "started",
+	}
+}
+
+// warnBefore leaves time to react without warning for most of a short window
+func warnBefore(window time.Duration) time.Duration {
+	if window/4 < DefaultWarnBefore {
+		return window / 4
+	}
+	return DefaultWarnBefore
+}
+
+// SetWindow changes the idle window, ex: when the instance's stop timeout is edited
+func (t *Tracker) SetWindow(window time.Duration) {
+	if window == t.Window {
+		return
+	}
+	t.Window = window
+	t.WarnBefore = warnBefore(window)
+	t.warned = false
+}
+
+// Busy describes why activity keeps the instance up, empty if it doesn't
+func (t *Tracker) Busy(a Activity) string {
+	reasons := []string{}
+	if a.SSHSessions > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d ssh sessions", a.SSHSessions))
+	}
+	if a.CPUBusy >= t.CPUThreshold {
+		reasons = append(reasons, fmt.Sprintf("cpu %.0f%% busy", a.CPUBusy*100))
+	}
+	if len(a.ActiveProcesses) > 0 {
+		reasons = append(reasons, "processes running: "+strings.Join(a.ActiveProcesses, ", "))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func (t *Tracker) Observe(now time.Time, a Activity) Decision {
+	if reason := t.Busy(a); reason != "" {
+		t.lastActive = now
+		t.reason = reason
+		t.warned = false
+	}
+	// editors and brev shell record when they connect, even when the session is already gone
+	if a.LastConnection.After(t.lastActive) {
+		t.lastActive = a.LastConnection
+		t.reason = "connection event"
+		t.warned = false
+	}
+
+	idle := now.Sub(t.lastActive)
+	switch {
+	case idle >= t.Window:
+		return Stop
+	case idle >= t.Window-t.WarnBefore && !t.warned:
+		t.warned = true
+		return Warn
+	}
+	return Keep
+}
+
+// IdleFor is how long the instance has been idle as of now
+func (t *Tracker) IdleFor(now time.Time) time.Duration {
+	return now.Sub(t.lastActive)
+}
+
+// LastReason is the last activity that kept the instance up
+func (t *Tracker) LastReason() string {
+	return t.reason
+}
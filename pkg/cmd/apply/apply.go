@@ -0,0 +1,286 @@
+// Package apply creates or updates an instance from a declarative spec file
+package apply
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
+)
+
+var (
+	applyLong = `Create or update an instance from a spec file so environments can live in git.
+
+The spec is yaml or json:
+
+  version: v1
+  name: my-instance
+  workspaceClass: 4x16          # or instanceType: n1-highmem-4:nvidia-tesla-t4:1
+  reposV1:
+    app:
+      type: git
+      repository: github.com:brevdev/brev-cli.git
+  execsV1:
+    setup:
+      type: string
+      execStr: make install
+  portMappings:
+    jupyter: "8888"
+  labels:
+    team: ml
+  diskStorage: 120Gi
+  stopTimeout: 2h
+
+Fields that the api can't change on an existing instance (portMappings,
+diskStorage) are only applied on create.`
+	applyExample = `
+  brev apply -f brev.yaml
+  brev apply -f brev.yaml --dry-run
+  cat brev.json | brev apply -f -
+	`
+)
+
+type ApplyStore interface {
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetOrganizations(options *store.GetOrganizationsOptions) ([]entity.Organization, error)
+	GetCurrentUser() (*entity.User, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
+	ModifyWorkspace(workspaceID string, options *store.ModifyWorkspaceRequest) (*entity.Workspace, error)
+}
+
+type ApplyOptions struct {
+	File     string
+	OrgName  string
+	DryRun   bool
+	Detached bool
+}
+
+func NewCmdApply(t *terminal.Terminal, applyStore ApplyStore) *cobra.Command {
+	var opts ApplyOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "apply",
+		DisableFlagsInUseLine: true,
+		Short:                 "Create or update an instance from a spec file",
+		Long:                  applyLong,
+		Example:               applyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunApply(t, opts, applyStore)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "path to the spec file, - reads from stdin")
+	cmd.Flags().StringVarP(&opts.OrgName, "org", "o", "", "organization (will override active org)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "print the plan without changing anything")
+	cmd.Flags().BoolVarP(&opts.Detached, "detached", "d", false, "don't wait for the instance to be running")
+	err := cmd.MarkFlagRequired("file")
+	if err != nil {
+		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
+		fmt.Print(breverrors.WrapAndTrace(err))
+	}
+	return cmd
+}
+
+func RunApply(t *terminal.Terminal, opts ApplyOptions, applyStore ApplyStore) error {
+	contents, err := readSpecFile(opts.File)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	spec, err := ParseSpec(contents)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	org, err := getOrg(applyStore, opts.OrgName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	user, err := applyStore.GetCurrentUser()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	live, err := getLiveWorkspace(applyStore, org.ID, user.ID, spec.Name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	plan, err := MakePlan(*spec, live)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	displayPlan(t, *spec, plan)
+
+	if opts.DryRun || plan.Action == PlanNoChange {
+		return nil
+	}
+
+	var workspace *entity.Workspace
+	if plan.Action == PlanCreate {
+		workspace, err = createFromSpec(t, *spec, org.ID, user, applyStore)
+	} else {
+		workspace, err = applyStore.ModifyWorkspace(plan.Workspace.ID, plan.Modify)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	// a stopped instance stays stopped after an update, so there is nothing to wait for
+	if opts.Detached || (plan.Action == PlanUpdate && plan.Workspace.Status != entity.Running) {
+		t.Vprintf(t.Green("Applied %s, run 'brev ls' to check status\n", workspace.Name))
+		return nil
+	}
+	err = pollUntil(t, workspace.ID, entity.Running, applyStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("\nInstance %s is ready!\n", workspace.Name))
+	return nil
+}
+
+func readSpecFile(path string) ([]byte, error) {
+	if path == "-" {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return contents, nil
+	}
+	contents, err := os.ReadFile(path) //nolint:gosec // user provided spec path
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return contents, nil
+}
+
+func getOrg(applyStore ApplyStore, orgName string) (*entity.Organization, error) {
+	if orgName == "" {
+		org, err := applyStore.GetActiveOrganizationOrDefault()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		if org == nil {
+			return nil, breverrors.NewValidationError("no org exist")
+		}
+		return org, nil
+	}
+	orgs, err := applyStore.GetOrganizations(&store.GetOrganizationsOptions{Name: orgName})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(orgs) == 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no org with name %s", orgName))
+	} else if len(orgs) > 1 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("more than one org with name %s", orgName))
+	}
+	return &orgs[0], nil
+}
+
+func getLiveWorkspace(applyStore ApplyStore, orgID string, userID string, name string) (*entity.Workspace, error) {
+	workspaces, err := applyStore.GetWorkspaces(orgID, &store.GetWorkspacesOptions{UserID: userID, Name: name})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	workspaces = store.FilterNonFailedWorkspaces(workspaces)
+	if len(workspaces) == 0 {
+		return nil, nil
+	}
+	if len(workspaces) > 1 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("multiple instances found with name %s", name))
+	}
+	return &workspaces[0], nil
+}
+
+func createFromSpec(t *terminal.Terminal, spec WorkspaceSpec, orgID string, user *entity.User, applyStore ApplyStore) (*entity.Workspace, error) {
+	clusterID := config.GlobalConfig.GetDefaultClusterID()
+	options, err := spec.ToCreateWorkspacesOptions(clusterID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	options = resolveWorkspaceUserOptions(options, user)
+
+	s := t.NewSpinner()
+	s.Suffix = " Creating your instance. Hang tight 🤙"
+	s.Start()
+	w, err := applyStore.CreateWorkspace(orgID, options)
+	s.Stop()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return w, nil
+}
+
+func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *entity.User) *store.CreateWorkspacesOptions {
+	if options.WorkspaceTemplateID == "" {
+		if featureflag.IsAdmin(user.GlobalUserType) {
+			options.WorkspaceTemplateID = store.DevWorkspaceTemplateID
+		} else {
+			options.WorkspaceTemplateID = store.UserWorkspaceTemplateID
+		}
+	}
+	if options.WorkspaceClassID == "" && options.InstanceType == "" {
+		if featureflag.IsAdmin(user.GlobalUserType) {
+			options.WorkspaceClassID = store.DevWorkspaceClassID
+		} else {
+			options.WorkspaceClassID = store.UserWorkspaceClassID
+		}
+	}
+	return options
+}
+
+func displayPlan(t *terminal.Terminal, spec WorkspaceSpec, plan *Plan) {
+	switch plan.Action {
+	case PlanCreate:
+		t.Vprintf("%s instance %s\n", t.Green("+ create"), t.Green(spec.Name))
+		for _, c := range plan.Changes {
+			t.Vprintf("\t%s %s: %s\n", t.Green("+"), c.Field, c.To)
+		}
+	case PlanUpdate:
+		t.Vprintf("%s instance %s\n", t.Yellow("~ update"), t.Yellow(spec.Name))
+		for _, c := range plan.Changes {
+			t.Vprintf("\t%s %s: %s -> %s\n", t.Yellow("~"), c.Field, c.From, c.To)
+		}
+	case PlanNoChange:
+		t.Vprintf("instance %s is up to date\n", t.Green(spec.Name))
+	}
+	if len(plan.Ignored) > 0 {
+		t.Vprintf(t.Yellow("\tnote: %s can only be set at create time and will not be changed\n", strings.Join(plan.Ignored, ", ")))
+	}
+}
+
+func pollUntil(t *terminal.Terminal, wsid string, state string, applyStore ApplyStore) error {
+	s := t.NewSpinner()
+	t.Vprintf("You can safely ctrl+c to exit\n")
+	s.Suffix = " hang tight 🤙"
+	s.Start()
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  instance is " + strings.ToLower(status)
+	}
+	_, err := wait.Until(applyStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+	s.Suffix = "Instance is ready!"
+	s.Stop()
+	return nil
+}
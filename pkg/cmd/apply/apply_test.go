@@ -0,0 +1,100 @@
+package apply
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+const testSpec = `
+version: v1
+name: my-instance
+workspaceClass: 4x16
+reposV1:
+  app:
+    type: git
+    repository: github.com:brevdev/brev-cli.git
+execsV1:
+  setup:
+    type: string
+    execStr: make install
+labels:
+  team: ml
+stopTimeout: 2h
+`
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "my-instance", spec.Name)
+	assert.Equal(t, "4x16", spec.WorkspaceClass)
+	assert.Equal(t, "github.com:brevdev/brev-cli.git", (*spec.ReposV1)["app"].Repository)
+	assert.Equal(t, "make install", (*spec.ExecsV1)["setup"].ExecStr)
+	assert.Equal(t, map[string]string{"team": "ml"}, spec.Labels)
+
+	_, err = ParseSpec([]byte(`{"version": "v1", "name": "json-instance"}`))
+	assert.Nil(t, err)
+
+	_, err = ParseSpec([]byte("version: v2\nname: foo\n"))
+	assert.NotNil(t, err)
+	_, err = ParseSpec([]byte("version: v1\n"))
+	assert.NotNil(t, err)
+	_, err = ParseSpec([]byte("version: v1\nname: foo\ninstanceTyp: typo\n"))
+	assert.NotNil(t, err)
+	_, err = ParseSpec([]byte("version: v1\nname: foo\nstopTimeout: forever\n"))
+	assert.NotNil(t, err)
+	_, err = ParseSpec([]byte("version: v1\nname: foo\nexecsV1:\n  setup:\n    execStr: make\n    timeout: 10m\n    retryBackoff: 30s\n"))
+	assert.Nil(t, err)
+	_, err = ParseSpec([]byte("version: v1\nname: foo\nexecsV1:\n  setup:\n    execStr: make\n    timeout: forever\n"))
+	assert.NotNil(t, err)
+}
+
+func TestMakePlanCreate(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	if !assert.Nil(t, err) {
+		return
+	}
+	plan, err := MakePlan(*spec, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, PlanCreate, plan.Action)
+
+	options, err := spec.ToCreateWorkspacesOptions("")
+	assert.Nil(t, err)
+	assert.Equal(t, "4x16", options.WorkspaceClassID)
+	assert.Equal(t, 2*time.Hour, *options.StopTimeout)
+}
+
+func TestMakePlanUpdate(t *testing.T) {
+	spec, err := ParseSpec([]byte(testSpec))
+	if !assert.Nil(t, err) {
+		return
+	}
+	live := &entity.Workspace{
+		ID:               "ws1",
+		Name:             "my-instance",
+		WorkspaceClassID: "4x16",
+		ReposV1:          spec.ReposV1,
+		StopTimeout:      2 * time.Hour,
+	}
+	plan, err := MakePlan(*spec, live)
+	assert.Nil(t, err)
+	assert.Equal(t, PlanUpdate, plan.Action)
+	if assert.Len(t, plan.Changes, 2) {
+		assert.Equal(t, "execsV1", plan.Changes[0].Field)
+		assert.Equal(t, "labels", plan.Changes[1].Field)
+	}
+	assert.Equal(t, spec.ExecsV1, plan.Modify.ExecsV1)
+	assert.Equal(t, map[string]string{"team": "ml"}, *plan.Modify.Labels)
+	assert.Empty(t, plan.Ignored)
+
+	live.ExecsV1 = spec.ExecsV1
+	live.Labels = map[string]string{"team": "ml"}
+	plan, err = MakePlan(*spec, live)
+	assert.Nil(t, err)
+	assert.Equal(t, PlanNoChange, plan.Action)
+}
@@ -0,0 +1,257 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+const SpecVersionV1 = "v1"
+
+// WorkspaceSpec is the declarative, versioned description of an instance
+// that lives in a repo as brev.yaml (or json)
+type WorkspaceSpec struct {
+	Version        string            `json:"version"`
+	Name           string            `json:"name"`
+	InstanceType   string            `json:"instanceType,omitempty"`
+	WorkspaceClass string            `json:"workspaceClass,omitempty"`
+	ReposV1        *entity.ReposV1   `json:"reposV1,omitempty"`
+	ExecsV1        *entity.ExecsV1   `json:"execsV1,omitempty"`
+	IDEConfig      *entity.IDEConfig `json:"ideConfig,omitempty"`
+	PortMappings   map[string]string `json:"portMappings,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	DiskStorage    string            `json:"diskStorage,omitempty"`
+	IsStoppable    *bool             `json:"isStoppable,omitempty"`
+	StopTimeout    string            `json:"stopTimeout,omitempty"` // go duration, ex: 2h30m
+}
+
+// ParseSpec reads a yaml or json spec, unknown fields are rejected so typos don't silently do nothing
+func ParseSpec(contents []byte) (*WorkspaceSpec, error) {
+	var spec WorkspaceSpec
+	err := yaml.UnmarshalStrict(contents, &spec)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid spec: %v", err))
+	}
+	err = spec.Validate()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return &spec, nil
+}
+
+func (s WorkspaceSpec) Validate() error {
+	if s.Version != SpecVersionV1 {
+		return breverrors.NewValidationError(fmt.Sprintf("unsupported spec version %q, expected %q", s.Version, SpecVersionV1))
+	}
+	if s.Name == "" {
+		return breverrors.NewValidationError("spec requires a name")
+	}
+	if s.InstanceType != "" && s.WorkspaceClass != "" {
+		return breverrors.NewValidationError("spec can set instanceType or workspaceClass, not both")
+	}
+	_, err := s.GetStopTimeout()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if s.ExecsV1 != nil {
+		for name, exec := range *s.ExecsV1 {
+			err = exec.ExecPolicy.Validate()
+			if err != nil {
+				return breverrors.Wrap(err, fmt.Sprintf("exec %s", name))
+			}
+		}
+	}
+	err = labels.Validate(s.Labels)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (s WorkspaceSpec) GetStopTimeout() (*time.Duration, error) {
+	if s.StopTimeout == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(s.StopTimeout)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid stopTimeout %q: %v", s.StopTimeout, err))
+	}
+	return &d, nil
+}
+
+func (s WorkspaceSpec) ToCreateWorkspacesOptions(clusterID string) (*store.CreateWorkspacesOptions, error) {
+	options := store.NewCreateWorkspacesOptions(clusterID, s.Name)
+	if s.InstanceType != "" {
+		options.WithInstanceType(s.InstanceType)
+	}
+	if s.WorkspaceClass != "" {
+		options.WithWorkspaceClassID(s.WorkspaceClass)
+	}
+	if s.ReposV1 != nil {
+		options.ReposV1 = s.ReposV1
+	}
+	if s.ExecsV1 != nil {
+		options.ExecsV1 = s.ExecsV1
+	}
+	if s.IDEConfig != nil {
+		options.IDEConfig = s.IDEConfig
+	}
+	if len(s.PortMappings) > 0 {
+		options.PortMappings = s.PortMappings
+	}
+	if len(s.Labels) > 0 {
+		options.Labels = s.Labels
+	}
+	if s.DiskStorage != "" {
+		options.DiskStorage = s.DiskStorage
+	}
+	options.IsStoppable = s.IsStoppable
+	stopTimeout, err := s.GetStopTimeout()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	options.StopTimeout = stopTimeout
+	return options, nil
+}
+
+type PlanAction string
+
+const (
+	PlanCreate   PlanAction = "create"
+	PlanUpdate   PlanAction = "update"
+	PlanNoChange PlanAction = "no-change"
+)
+
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+type Plan struct {
+	Action    PlanAction
+	Workspace *entity.Workspace // nil when creating
+	Changes   []FieldChange
+	// fields that differ but can only be set when an instance is created
+	Ignored []string
+	Modify  *store.ModifyWorkspaceRequest
+}
+
+// MakePlan diffs the spec against the live instance, live is nil if it doesn't exist yet
+func MakePlan(spec WorkspaceSpec, live *entity.Workspace) (*Plan, error) {
+	if live == nil {
+		return &Plan{Action: PlanCreate, Changes: describeCreate(spec)}, nil
+	}
+
+	plan := &Plan{Workspace: live, Modify: &store.ModifyWorkspaceRequest{}}
+	if spec.InstanceType != "" && spec.InstanceType != live.InstanceType {
+		plan.Changes = append(plan.Changes, FieldChange{"instanceType", live.InstanceType, spec.InstanceType})
+		plan.Modify.InstanceType = spec.InstanceType
+	}
+	if spec.WorkspaceClass != "" && spec.WorkspaceClass != live.WorkspaceClassID {
+		plan.Changes = append(plan.Changes, FieldChange{"workspaceClass", live.WorkspaceClassID, spec.WorkspaceClass})
+		plan.Modify.WorkspaceClassID = spec.WorkspaceClass
+	}
+	if spec.ReposV1 != nil && !jsonEqual(spec.ReposV1, live.ReposV1) {
+		plan.Changes = append(plan.Changes, FieldChange{"reposV1", toJSON(live.ReposV1), toJSON(spec.ReposV1)})
+		plan.Modify.ReposV1 = spec.ReposV1
+	}
+	if spec.ExecsV1 != nil && !jsonEqual(spec.ExecsV1, live.ExecsV1) {
+		plan.Changes = append(plan.Changes, FieldChange{"execsV1", toJSON(live.ExecsV1), toJSON(spec.ExecsV1)})
+		plan.Modify.ExecsV1 = spec.ExecsV1
+	}
+	if spec.IDEConfig != nil && !jsonEqual(*spec.IDEConfig, live.IDEConfig) {
+		plan.Changes = append(plan.Changes, FieldChange{"ideConfig", toJSON(live.IDEConfig), toJSON(spec.IDEConfig)})
+		plan.Modify.IDEConfig = spec.IDEConfig
+	}
+	if spec.IsStoppable != nil && *spec.IsStoppable != live.IsStoppable {
+		plan.Changes = append(plan.Changes, FieldChange{"isStoppable", fmt.Sprint(live.IsStoppable), fmt.Sprint(*spec.IsStoppable)})
+		plan.Modify.IsStoppable = spec.IsStoppable
+	}
+	stopTimeout, err := spec.GetStopTimeout()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if stopTimeout != nil && *stopTimeout != live.StopTimeout {
+		plan.Changes = append(plan.Changes, FieldChange{"stopTimeout", live.StopTimeout.String(), stopTimeout.String()})
+		plan.Modify.StopTimeout = stopTimeout
+	}
+	if len(spec.Labels) > 0 && labels.Format(spec.Labels) != labels.Format(live.Labels) {
+		plan.Changes = append(plan.Changes, FieldChange{"labels", labels.Format(live.Labels), labels.Format(spec.Labels)})
+		plan.Modify.Labels = &spec.Labels
+	}
+	// the api doesn't return these for a live instance so we can't diff them
+	if len(spec.PortMappings) > 0 {
+		plan.Ignored = append(plan.Ignored, "portMappings")
+	}
+	if spec.DiskStorage != "" {
+		plan.Ignored = append(plan.Ignored, "diskStorage")
+	}
+
+	if len(plan.Changes) == 0 {
+		plan.Action = PlanNoChange
+		plan.Modify = nil
+	} else {
+		plan.Action = PlanUpdate
+	}
+	return plan, nil
+}
+
+func describeCreate(spec WorkspaceSpec) []FieldChange {
+	changes := []FieldChange{{Field: "name", To: spec.Name}}
+	if spec.InstanceType != "" {
+		changes = append(changes, FieldChange{Field: "instanceType", To: spec.InstanceType})
+	}
+	if spec.WorkspaceClass != "" {
+		changes = append(changes, FieldChange{Field: "workspaceClass", To: spec.WorkspaceClass})
+	}
+	if spec.ReposV1 != nil {
+		changes = append(changes, FieldChange{Field: "reposV1", To: toJSON(spec.ReposV1)})
+	}
+	if spec.ExecsV1 != nil {
+		changes = append(changes, FieldChange{Field: "execsV1", To: toJSON(spec.ExecsV1)})
+	}
+	if spec.IDEConfig != nil {
+		changes = append(changes, FieldChange{Field: "ideConfig", To: toJSON(spec.IDEConfig)})
+	}
+	if len(spec.PortMappings) > 0 {
+		changes = append(changes, FieldChange{Field: "portMappings", To: toJSON(spec.PortMappings)})
+	}
+	if len(spec.Labels) > 0 {
+		changes = append(changes, FieldChange{Field: "labels", To: labels.Format(spec.Labels)})
+	}
+	if spec.DiskStorage != "" {
+		changes = append(changes, FieldChange{Field: "diskStorage", To: spec.DiskStorage})
+	}
+	if spec.IsStoppable != nil {
+		changes = append(changes, FieldChange{Field: "isStoppable", To: fmt.Sprint(*spec.IsStoppable)})
+	}
+	if spec.StopTimeout != "" {
+		changes = append(changes, FieldChange{Field: "stopTimeout", To: spec.StopTimeout})
+	}
+	return changes
+}
+
+// jsonEqual compares by wire representation so nil and empty maps are treated the same
+func jsonEqual(a, b interface{}) bool {
+	return toJSON(a) == toJSON(b)
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := string(b)
+	if s == "null" || s == "{}" {
+		return "{}"
+	}
+	return s
+}
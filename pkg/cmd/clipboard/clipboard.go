@@ -70,7 +70,7 @@ func ForwardPort(t *terminal.Terminal, clipboardStore ClipboardStore) *cobra.Com
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(clipboardStore, t),
 		Run: func(cmd *cobra.Command, args []string) {
 			// Portforward
-			_, sshError := portforward.RunSSHPortForward("-R", "6969", "6969", args[0])
+			_, sshError := portforward.RunSSHPortForward(portforward.ForwardRemote, "6969", "6969", args[0])
 			if sshError != nil {
 				t.Errprint(sshError, "Failed to connect to local")
 				return
@@ -0,0 +1,229 @@
+// Package clone creates a new instance with the same setup as an existing one
+package clone
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/cp"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
+)
+
+var (
+	cloneLong = `Create a new instance with the same repos, execs, IDE config, machine type
+and labels as an existing one.
+
+With --copy-files the project folder of the existing instance is copied into
+the new one once it is up, so work in progress and anything the setup built
+comes along without running the setup again. The existing instance has to be
+running for that.`
+	cloneExample = `
+  brev clone my-instance --name my-experiment
+  brev clone my-instance --name my-experiment --copy-files
+  brev clone my-instance --name bigger --gpu p3.8xlarge
+	`
+)
+
+type CloneStore interface {
+	cp.CpStore
+	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+type CloneOptions struct {
+	Name         string
+	CopyFiles    bool
+	InstanceType string
+	CPU          string
+	Detached     bool
+}
+
+func NewCmdClone(t *terminal.Terminal, cloneStore CloneStore, noLoginCloneStore CloneStore) *cobra.Command {
+	var opts CloneOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "clone <instance> --name <new name>",
+		DisableFlagsInUseLine: true,
+		Short:                 "Create a copy of an instance",
+		Long:                  cloneLong,
+		Example:               cloneExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginCloneStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunClone(t, cloneStore, args[0], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Name, "name", "n", "", "name of the new instance")
+	cmd.Flags().BoolVar(&opts.CopyFiles, "copy-files", false, "copy the project folder into the new instance")
+	cmd.Flags().StringVarP(&opts.InstanceType, "gpu", "g", "", "use a different GPU instance type for the new instance")
+	cmd.Flags().StringVarP(&opts.CPU, "cpu", "c", "", "use a different CPU instance type for the new instance")
+	cmd.Flags().BoolVarP(&opts.Detached, "detached", "d", false, "don't wait for the new instance to be ready")
+	return cmd
+}
+
+func RunClone(t *terminal.Terminal, cloneStore CloneStore, source string, opts CloneOptions) error {
+	if opts.Name == "" {
+		return breverrors.NewValidationError("please name the new instance with --name")
+	}
+	if opts.CopyFiles && opts.Detached {
+		return breverrors.NewValidationError("--copy-files waits for the new instance, it can't be used with --detached")
+	}
+	if opts.InstanceType != "" && opts.CPU != "" {
+		return breverrors.NewValidationError("pass --gpu or --cpu, not both")
+	}
+
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(cloneStore, source)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if opts.CopyFiles && workspace.Status != entity.Running {
+		return breverrors.NewValidationError(fmt.Sprintf("%s is %s, start it to copy its files: brev start %s", workspace.Name, strings.ToLower(workspace.Status), workspace.Name))
+	}
+	org, err := cloneStore.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if org == nil {
+		return breverrors.NewValidationError("no orgs exist")
+	}
+
+	options := MakeCloneOptions(*workspace, opts.Name, config.GlobalConfig.GetDefaultClusterID())
+	if opts.InstanceType != "" {
+		options.InstanceType = opts.InstanceType
+		options.WorkspaceClassID = ""
+	}
+	if opts.CPU != "" {
+		options.WorkspaceClassID = opts.CPU
+		options.InstanceType = ""
+	}
+
+	t.Vprintf("Cloning %s into %s\n", t.Green(workspace.Name), t.Green(opts.Name))
+	clone, err := cloneStore.CreateWorkspace(org.ID, options)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if opts.Detached {
+		t.Vprintf("%s is being created, check on it with: brev ls\n", clone.Name)
+		return nil
+	}
+
+	err = waitForClone(t, cloneStore, clone.ID, opts.CopyFiles)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if opts.CopyFiles {
+		err = copyProjectFolder(t, cloneStore, *workspace, opts.Name)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	t.Vprintf(t.Green("\n%s is ready!\n", clone.Name))
+	t.Vprintf(t.Green("SSH into it:\n\tssh %s\n", clone.GetLocalIdentifier()))
+	return nil
+}
+
+// MakeCloneOptions copies everything that describes how source was set up,
+// but not its name, status or anything else the api assigns
+func MakeCloneOptions(source entity.Workspace, name string, clusterID string) *store.CreateWorkspacesOptions {
+	options := store.NewCreateWorkspacesOptions(clusterID, name)
+	if source.WorkspaceGroupID != "" {
+		options.WorkspaceGroupID = source.WorkspaceGroupID
+	}
+	if source.WorkspaceTemplate.ID != "" {
+		options.WorkspaceTemplateID = source.WorkspaceTemplate.ID
+	}
+	options.WorkspaceClassID = source.WorkspaceClassID
+	options.InstanceType = source.InstanceType
+	options.GitRepo = source.GitRepo
+	options.StartupScriptPath = source.StartupScriptPath
+	options.Repos = source.ReposV0
+	options.Execs = source.ExecsV0
+	options.ReposV1 = source.ReposV1
+	options.ExecsV1 = source.ExecsV1
+	ideConfig := source.IDEConfig
+	options.IDEConfig = &ideConfig
+	isStoppable := source.IsStoppable
+	options.IsStoppable = &isStoppable
+	if source.StopTimeout > 0 {
+		stopTimeout := source.StopTimeout
+		options.StopTimeout = &stopTimeout
+	}
+	if len(source.Labels) > 0 {
+		options.Labels = map[string]string{}
+		for k, v := range source.Labels {
+			options.Labels[k] = v
+		}
+	}
+	return options
+}
+
+func waitForClone(t *terminal.Terminal, cloneStore CloneStore, id string, needSSH bool) error {
+	s := t.NewSpinner()
+	s.Suffix = " hang tight 🤙"
+	s.Start()
+	defer s.Stop()
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  instance is " + strings.ToLower(status)
+	}
+	_, err := wait.Until(cloneStore, id, wait.StatusCondition{Status: entity.Running}, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !needSSH {
+		return nil
+	}
+	// the new alias has to be in the ssh config before it can be probed
+	err = refresh.RunRefresh(cloneStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	opts.InitialDelay = 0
+	_, err = wait.Until(cloneStore, id, wait.SSHCondition{}, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func copyProjectFolder(t *terminal.Terminal, cloneStore CloneStore, source entity.Workspace, cloneName string) error {
+	src, err := cp.GetRemote(cloneStore, source.ID, false)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	dst, err := cp.GetRemote(cloneStore, cloneName, false)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	projectFolder := source.GetProjectFolderPath()
+	s := t.NewSpinner()
+	s.Suffix = fmt.Sprintf(" copying %s", projectFolder)
+	s.Start()
+	err = filesync.CopyTree(*src, projectFolder, *dst, path.Dir(projectFolder))
+	s.Stop()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("copied %s from %s\n", projectFolder, source.Name))
+	return nil
+}
@@ -0,0 +1,48 @@
+package clone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestMakeCloneOptions(t *testing.T) {
+	source := entity.Workspace{
+		ID:                "ws1",
+		Name:              "experiment",
+		Status:            entity.Running,
+		WorkspaceGroupID:  "GCP",
+		InstanceType:      "n1-highmem-4:nvidia-tesla-t4:1",
+		WorkspaceTemplate: entity.WorkspaceTemplate{ID: "tmpl"},
+		ReposV1: &entity.ReposV1{
+			"app": entity.RepoV1{Type: entity.GitRepoType, GitRepo: entity.GitRepo{Repository: "github.com:brevdev/app.git"}},
+		},
+		IDEConfig:   entity.IDEConfig{DefaultWorkingDir: "/home/ubuntu/app"},
+		IsStoppable: true,
+		StopTimeout: 30 * time.Minute,
+		Labels:      map[string]string{"team": "ml"},
+	}
+
+	options := MakeCloneOptions(source, "fork", "cluster")
+	assert.Equal(t, "fork", options.Name)
+	assert.Equal(t, "GCP", options.WorkspaceGroupID)
+	assert.Equal(t, "tmpl", options.WorkspaceTemplateID)
+	assert.Equal(t, source.InstanceType, options.InstanceType)
+	assert.Equal(t, source.ReposV1, options.ReposV1)
+	assert.Equal(t, "/home/ubuntu/app", options.IDEConfig.DefaultWorkingDir)
+	assert.True(t, *options.IsStoppable)
+	assert.Equal(t, 30*time.Minute, *options.StopTimeout)
+	assert.Equal(t, map[string]string{"team": "ml"}, options.Labels)
+
+	// the clone gets its own labels
+	options.Labels["team"] = "infra"
+	assert.Equal(t, "ml", source.Labels["team"])
+
+	options = MakeCloneOptions(entity.Workspace{Name: "bare"}, "fork", "cluster")
+	assert.NotEmpty(t, options.WorkspaceGroupID)
+	assert.Nil(t, options.StopTimeout)
+	assert.Nil(t, options.Labels)
+}
@@ -0,0 +1,114 @@
+// Package cloudflared shows and updates the cloudflared binary brev uses to reach instances
+package cloudflared
+
+import (
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloudflaredLong = `brev downloads a pinned cloudflared to reach instances over cloudflare and
+checks its sha256 before installing it. The checksum comes from the cloudflared
+release notes unless the cloudflared-sha256 setting pins it. cloudflared-mirror
+downloads from <mirror>/<version>/<asset> instead of github, with the checksums
+in sha256sum format at <mirror>/<version>/checksums.txt. See brev config.`
+	cloudflaredExample = `  brev cloudflared status
+  brev cloudflared update`
+)
+
+type CloudflaredStore interface {
+	store.CloudflaredStore
+}
+
+func NewCmdCloudflared(t *terminal.Terminal, cloudflaredStore CloudflaredStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "cloudflared",
+		DisableFlagsInUseLine: true,
+		Short:                 "Show or update the cloudflared binary brev uses",
+		Long:                  cloudflaredLong,
+		Example:               cloudflaredExample,
+	}
+	cmd.AddCommand(newCmdStatus(t, cloudflaredStore))
+	cmd.AddCommand(newCmdUpdate(t, cloudflaredStore))
+
+	return cmd
+}
+
+func newCmdStatus(t *terminal.Terminal, cloudflaredStore CloudflaredStore) *cobra.Command {
+	return &cobra.Command{
+		Use:                   "status",
+		DisableFlagsInUseLine: true,
+		Short:                 "Show the installed and pinned cloudflared versions",
+		Args:                  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunStatus(t, store.NewCloudflare(cloudflaredStore))
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func newCmdUpdate(t *terminal.Terminal, cloudflaredStore CloudflaredStore) *cobra.Command {
+	return &cobra.Command{
+		Use:                   "update",
+		DisableFlagsInUseLine: true,
+		Short:                 "Download and verify the pinned cloudflared",
+		Args:                  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunUpdate(t, store.NewCloudflare(cloudflaredStore))
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+}
+
+func RunStatus(t *terminal.Terminal, cl store.Cloudflared) error {
+	status, err := cl.Status()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	installed := status.InstalledVersion
+	switch {
+	case !status.Installed:
+		installed = t.Yellow("not installed")
+	case installed == "":
+		installed = t.Red("doesn't run")
+	case !status.UpToDate():
+		installed = t.Yellow(installed)
+	}
+	checksum := "from the cloudflared release notes"
+	switch {
+	case status.ChecksumPinned:
+		checksum = "pinned by cloudflared-sha256 in brev config"
+	case status.ChecksumURL != "":
+		checksum = "from " + status.ChecksumURL
+	}
+
+	t.Vprintf("path       %s\n", status.BinaryPath)
+	t.Vprintf("installed  %s\n", installed)
+	t.Vprintf("pinned     %s\n", status.PinnedVersion)
+	t.Vprintf("source     %s\n", status.URL)
+	t.Vprintf("checksum   %s\n", checksum)
+	if !status.UpToDate() {
+		t.Vprintf("\nrun %s to install %s\n", t.Green("brev cloudflared update"), status.PinnedVersion)
+	}
+	return nil
+}
+
+func RunUpdate(t *terminal.Terminal, cl store.Cloudflared) error {
+	err := cl.Update()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("%s installed cloudflared %s\n", t.Green("✓"), store.CloudflaredVersion)
+	return nil
+}
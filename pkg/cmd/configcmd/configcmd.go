@@ -0,0 +1,273 @@
+// Package configcmd reads and writes the brev config files
+package configcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	configLong = `Defaults brev reads so you don't repeat the same flags. They live in
+~/.brev/config.yaml, and .brev/config.yaml at the root of a git repo overrides
+them for that repo. A repo can only set org, instance-type, workspace-class and
+output, the other keys pick where brev sends your token or what it runs so only
+your own config sets them. Each key also has an env var, which overrides both
+files, and flags override everything.`
+	configExample = `  brev config list
+  brev config set org my-org
+  brev config set instance-type g5.xlarge --repo
+  brev config get editor
+  brev config unset org`
+)
+
+type ConfigStore interface {
+	GetBrevHomePath() (string, error)
+}
+
+func NewCmdConfig(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "config",
+		DisableFlagsInUseLine: true,
+		Short:                 "Get and set brev defaults",
+		Long:                  configLong,
+		Example:               configExample,
+	}
+	cmd.AddCommand(newCmdList(t, store))
+	cmd.AddCommand(newCmdGet(t, store))
+	cmd.AddCommand(newCmdSet(t, store))
+	cmd.AddCommand(newCmdUnset(t, store))
+	return cmd
+}
+
+func newCmdList(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	var outputFlag string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List every setting with its value and where it came from",
+		Args:  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			layers, err := getLayers(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunList(t, layers, *output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", config.GlobalConfig.GetDefaultOutput(), terminal.OutputFlagUsage)
+	return cmd
+}
+
+func newCmdGet(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	return &cobra.Command{
+		Use:       "get <key>",
+		Short:     "Print the value brev uses for a setting",
+		Args:      cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgs: config.SettingKeys(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layers, err := getLayers(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			value, err := layers.Resolve(args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			t.Vprintf("%s\n", value.Value)
+			return nil
+		},
+	}
+}
+
+func newCmdSet(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	var repo bool
+	cmd := &cobra.Command{
+		Use:       "set <key> <value>",
+		Short:     "Set a setting in your config, or the repo's with --repo",
+		Args:      cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		ValidArgs: config.SettingKeys(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layers, err := getLayers(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunSet(t, layers, args[0], args[1], repo)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&repo, "repo", false, "write .brev/config.yaml in this git repo instead of ~/.brev/config.yaml")
+	return cmd
+}
+
+func newCmdUnset(t *terminal.Terminal, store ConfigStore) *cobra.Command {
+	var repo bool
+	cmd := &cobra.Command{
+		Use:       "unset <key>",
+		Short:     "Remove a setting from your config, or the repo's with --repo",
+		Args:      cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgs: config.SettingKeys(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layers, err := getLayers(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunUnset(t, layers, args[0], repo)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&repo, "repo", false, "edit .brev/config.yaml in this git repo instead of ~/.brev/config.yaml")
+	return cmd
+}
+
+func getLayers(store ConfigStore) (config.Layers, error) {
+	home, err := store.GetBrevHomePath()
+	if err != nil {
+		return config.Layers{}, breverrors.WrapAndTrace(err)
+	}
+	layers := config.DefaultLayers()
+	layers.UserFile = config.UserFilePath(home)
+	return layers, nil
+}
+
+type listEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Origin string `json:"origin,omitempty"`
+}
+
+func RunList(t *terminal.Terminal, layers config.Layers, output terminal.Output) error {
+	entries := []listEntry{}
+	for _, s := range config.Settings {
+		v, err := layers.Resolve(s.Key)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		entries = append(entries, listEntry{Key: v.Key, Value: v.Value, Source: string(v.Source), Origin: v.Origin})
+	}
+	if output.IsStructured() {
+		err := t.PrintStructured(output, entries)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = getBrevTableOptions()
+	ta.AppendHeader(table.Row{"KEY", "VALUE", "FROM"})
+	for _, e := range entries {
+		from := t.Yellow(e.Source)
+		if e.Origin != "" {
+			from = fmt.Sprintf("%s %s", e.Source, e.Origin)
+		}
+		ta.AppendRow(table.Row{e.Key, e.Value, from})
+	}
+	ta.Render()
+
+	for _, path := range []string{layers.RepoFile, layers.UserFile} {
+		if path == "" {
+			continue
+		}
+		unknown, err := config.UnknownKeys(path)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		for _, k := range unknown {
+			t.Vprint(t.Yellow("%s: unknown key %s is ignored\n", path, k))
+		}
+	}
+	for _, w := range layers.Load().Warnings {
+		t.Vprint(t.Yellow("%s\n", w))
+	}
+	return nil
+}
+
+func fileFor(layers config.Layers, repo bool) (string, error) {
+	if !repo {
+		return layers.UserFile, nil
+	}
+	if layers.RepoFile == "" {
+		return "", breverrors.NewValidationError("--repo needs to run inside a git repo")
+	}
+	return layers.RepoFile, nil
+}
+
+func RunSet(t *terminal.Terminal, layers config.Layers, key, value string, repo bool) error {
+	path, err := fileFor(layers, repo)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if repo {
+		setting, lookupErr := config.LookupSetting(key)
+		if lookupErr != nil {
+			return breverrors.WrapAndTrace(lookupErr)
+		}
+		if !setting.Repo {
+			return breverrors.NewValidationError(fmt.Sprintf("a repo can't set %s, only: %s. Set it in your own config without --repo", key, strings.Join(config.RepoSettingKeys(), ", ")))
+		}
+	}
+	err = config.SetInFile(path, key, value)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("set %s in %s\n", key, path)
+	warnIfOverridden(t, layers, key, path)
+	return nil
+}
+
+func RunUnset(t *terminal.Terminal, layers config.Layers, key string, repo bool) error {
+	path, err := fileFor(layers, repo)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = config.UnsetInFile(path, key)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf("unset %s in %s\n", key, path)
+	warnIfOverridden(t, layers, key, path)
+	return nil
+}
+
+// warnIfOverridden says when an env var or the repo file still wins over the file just edited
+func warnIfOverridden(t *terminal.Terminal, layers config.Layers, key, path string) {
+	v, err := layers.Resolve(key)
+	if err != nil || v.Source == config.DefaultSource || v.Origin == path {
+		return
+	}
+	t.Vprint(t.Yellow("%s is still %q from %s %s\n", key, v.Value, v.Source, v.Origin))
+}
+
+func getBrevTableOptions() table.Options {
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	return options
+}
@@ -0,0 +1,34 @@
+package configcmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+func TestRunSetRepo(t *testing.T) {
+	dir := t.TempDir()
+	layers := config.Layers{
+		UserFile: filepath.Join(dir, "home", config.FileName),
+		RepoFile: filepath.Join(dir, "repo", ".brev", config.FileName),
+	}
+
+	err := RunSet(terminal.New(), layers, "api-url", "https://evil.example", true)
+	assert.ErrorContains(t, err, "a repo can't set api-url")
+	assert.NoFileExists(t, layers.RepoFile)
+
+	require.NoError(t, RunSet(terminal.New(), layers, "instance-type", "g5.xlarge", true))
+	values, err := config.ReadFile(layers.RepoFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"instance-type": "g5.xlarge"}, values)
+
+	require.NoError(t, RunSet(terminal.New(), layers, "api-url", "https://api.brev.dev", false))
+	values, err = config.ReadFile(layers.UserFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"api-url": "https://api.brev.dev"}, values)
+}
@@ -0,0 +1,165 @@
+// Package cp copies files between the local machine and instances
+package cp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	cpLong = `Copy a file or directory to or from an instance.
+
+Remote paths are written instance:path and are relative to the home
+directory on the instance. If the destination is an existing directory the
+source is copied into it, otherwise it is copied to that name.`
+	cpExample = `
+  brev cp ./train.py my-instance:app/
+  brev cp ./data my-instance:~/data
+  brev cp my-instance:app/checkpoints ./checkpoints
+	`
+)
+
+type CpStore interface {
+	completions.CompletionStore
+	util.GetWorkspaceByNameOrIDErrStore
+	refresh.RefreshStore
+}
+
+func NewCmdCp(t *terminal.Terminal, store CpStore, noLoginStore CpStore) *cobra.Command {
+	var host bool
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "cp",
+		DisableFlagsInUseLine: true,
+		Short:                 "Copy files to and from an instance",
+		Long:                  cpLong,
+		Example:               cpExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunCp(t, store, args[0], args[1], host)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&host, "host", false, "copy to or from the host machine instead of the container")
+	return cmd
+}
+
+func RunCp(t *terminal.Terminal, store CpStore, src string, dst string, host bool) error {
+	srcInstance, srcPath := filesync.ParseTarget(src)
+	dstInstance, dstPath := filesync.ParseTarget(dst)
+	switch {
+	case srcInstance != "" && dstInstance != "":
+		return breverrors.NewValidationError("copying between two instances isn't supported, copy to your machine first")
+	case srcInstance == "" && dstInstance == "":
+		return breverrors.NewValidationError("one of the paths has to be on an instance, ex: my-instance:path")
+	case dstInstance != "":
+		remote, err := GetRemote(store, dstInstance, host)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = push(t, remote, srcPath, dstPath)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	default:
+		remote, err := GetRemote(store, srcInstance, host)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = pull(t, remote, srcPath, dstPath)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+}
+
+func push(t *terminal.Terminal, remote *filesync.Remote, src string, dst string) error {
+	_, err := os.Stat(src)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	dir, name := path.Split(dst)
+	isDir, err := remote.IsDir(dst)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if isDir || name == "" || name == "~" {
+		dir, name = dst, filepath.Base(src)
+	}
+	err = remote.PushTree(src, dir, name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("copied %s to %s:%s\n", src, remote.Alias, joinRemote(dir, name)))
+	return nil
+}
+
+func pull(t *terminal.Terminal, remote *filesync.Remote, src string, dst string) error {
+	dir, name := filepath.Split(dst)
+	info, err := os.Stat(dst)
+	if (err == nil && info.IsDir()) || name == "" {
+		dir, name = dst, path.Base(strings.TrimSuffix(src, "/"))
+	}
+	if dir == "" {
+		dir = "."
+	}
+	err = remote.PullTree(src, dir, name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	t.Vprintf(t.Green("copied %s:%s to %s\n", remote.Alias, src, filepath.Join(dir, name)))
+	return nil
+}
+
+func joinRemote(dir string, name string) string {
+	if dir == "" {
+		return name
+	}
+	return strings.TrimSuffix(dir, "/") + "/" + name
+}
+
+// GetRemote resolves a running instance to its alias in the brev ssh config,
+// refreshing the config first so new instances are in it
+func GetRemote(store CpStore, workspaceNameOrID string, host bool) (*filesync.Remote, error) {
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(store, workspaceNameOrID)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if workspace.Status != entity.Running {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("%s is %s, start it with: brev start %s", workspace.Name, strings.ToLower(workspace.Status), workspace.Name))
+	}
+	err = refresh.RunRefresh(store)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	configPath, err := store.GetBrevSSHConfigPath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	alias := workspace.GetLocalIdentifier()
+	if host {
+		alias = workspace.GetHostIdentifier()
+	}
+	return &filesync.Remote{SSHConfigPath: configPath, Alias: string(alias)}, nil
+}
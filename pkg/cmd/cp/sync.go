@@ -0,0 +1,145 @@
+package cp
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	syncLong = `Make a directory on an instance match a local one.
+
+Only files whose checksum differs are sent. .git and anything your
+.gitignore files ignore are skipped; --include and --exclude take .gitignore
+style patterns. With --watch brev keeps syncing local changes until you stop
+it, so you can edit locally and run on the instance.`
+	syncExample = `
+  brev sync . my-instance:app
+  brev sync ./src my-instance:app/src --watch
+  brev sync . my-instance:app --exclude 'data/' --exclude '*.ckpt' --delete
+	`
+)
+
+type SyncOptions struct {
+	Include     []string
+	Exclude     []string
+	NoGitIgnore bool
+	Delete      bool
+	DryRun      bool
+	Watch       bool
+	Interval    time.Duration
+	Host        bool
+}
+
+func NewCmdSync(t *terminal.Terminal, store CpStore, noLoginStore CpStore) *cobra.Command {
+	var opts SyncOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "sync",
+		DisableFlagsInUseLine: true,
+		Short:                 "Sync a local directory to an instance",
+		Long:                  syncLong,
+		Example:               syncExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(2)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunSync(t, store, args[0], args[1], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&opts.Include, "include", []string{}, "only sync files matching this pattern (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.Exclude, "exclude", []string{}, "don't sync files matching this pattern (repeatable)")
+	cmd.Flags().BoolVar(&opts.NoGitIgnore, "no-gitignore", false, "sync files even if a .gitignore ignores them")
+	cmd.Flags().BoolVar(&opts.Delete, "delete", false, "delete files on the instance that don't exist locally")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "show what would be synced without syncing")
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "keep syncing local changes until interrupted")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", time.Second, "how often --watch checks for local changes")
+	cmd.Flags().BoolVar(&opts.Host, "host", false, "sync to the host machine instead of the container")
+	return cmd
+}
+
+func RunSync(t *terminal.Terminal, store CpStore, src string, dst string, opts SyncOptions) error {
+	srcInstance, srcPath := filesync.ParseTarget(src)
+	dstInstance, dstPath := filesync.ParseTarget(dst)
+	if srcInstance != "" || dstInstance == "" {
+		return breverrors.NewValidationError("sync goes from a local directory to an instance, ex: brev sync . my-instance:app")
+	}
+	if opts.Watch && opts.DryRun {
+		return breverrors.NewValidationError("--watch and --dry-run can't be used together")
+	}
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if !info.IsDir() {
+		return breverrors.NewValidationError(fmt.Sprintf("%s is not a directory, use brev cp for single files", srcPath))
+	}
+
+	remote, err := GetRemote(store, dstInstance, opts.Host)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	syncer := &filesync.Syncer{
+		Transport: remote,
+		LocalRoot: srcPath,
+		RemoteDir: dstPath,
+		Filter:    filesync.NewFilter(opts.Include, opts.Exclude, !opts.NoGitIgnore),
+		Delete:    opts.Delete,
+		DryRun:    opts.DryRun,
+	}
+	result, err := syncer.Sync()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	printSyncResult(t, result, opts.DryRun)
+	if !opts.Watch {
+		return nil
+	}
+
+	t.Vprintf(t.Yellow("watching %s for changes, ctrl-c to stop\n", srcPath))
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+	err = syncer.Watch(opts.Interval, stop, func(result filesync.SyncResult) {
+		printSyncResult(t, result, false)
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func printSyncResult(t *terminal.Terminal, result filesync.SyncResult, dryRun bool) {
+	if result.Empty() {
+		t.Vprintf("everything is up to date\n")
+		return
+	}
+	verb, deleted := "synced", "deleted"
+	if dryRun {
+		verb, deleted = "would sync", "would delete"
+	}
+	for _, path := range result.Changed {
+		t.Vprintf("%s %s\n", t.Green(verb), path)
+	}
+	for _, path := range result.Deleted {
+		t.Vprintf("%s %s\n", t.Red(deleted), path)
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/labels"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
 	"github.com/spf13/cobra"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -20,6 +22,7 @@ var (
 	createLong    = "Create a new Brev machine"
 	createExample = `
   brev create <name>
+  brev create <name> --label team=ml --label env=dev
 	`
 	// instanceTypes = []string{"p4d.24xlarge", "p3.2xlarge", "p3.8xlarge", "p3.16xlarge", "p3dn.24xlarge", "p2.xlarge", "p2.8xlarge", "p2.16xlarge", "g5.xlarge", "g5.2xlarge", "g5.4xlarge", "g5.8xlarge", "g5.16xlarge", "g5.12xlarge", "g5.24xlarge", "g5.48xlarge", "g5g.xlarge", "g5g.2xlarge", "g5g.4xlarge", "g5g.8xlarge", "g5g.16xlarge", "g5g.metal", "g4dn.xlarge", "g4dn.2xlarge", "g4dn.4xlarge", "g4dn.8xlarge", "g4dn.16xlarge", "g4dn.12xlarge", "g4dn.metal", "g4ad.xlarge", "g4ad.2xlarge", "g4ad.4xlarge", "g4ad.8xlarge", "g4ad.16xlarge", "g3s.xlarge", "g3.4xlarge", "g3.8xlarge", "g3.16xlarge"}
 )
@@ -36,6 +39,7 @@ func NewCmdCreate(t *terminal.Terminal, createStore CreateStore) *cobra.Command
 	var detached bool
 	var gpu string
 	var cpu string
+	var labelFlags []string
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -50,11 +54,17 @@ func NewCmdCreate(t *terminal.Terminal, createStore CreateStore) *cobra.Command
 				name = args[0]
 			}
 
-			err := runCreateWorkspace(t, CreateOptions{
+			workspaceLabels, err := labels.Parse(labelFlags)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+
+			err = runCreateWorkspace(t, CreateOptions{
 				Name:           name,
 				WorkspaceClass: cpu,
 				Detached:       detached,
 				InstanceType:   gpu,
+				Labels:         workspaceLabels,
 			}, createStore)
 			if err != nil {
 				if strings.Contains(err.Error(), "duplicate instance with name") {
@@ -69,7 +79,8 @@ func NewCmdCreate(t *terminal.Terminal, createStore CreateStore) *cobra.Command
 	}
 	cmd.Flags().BoolVarP(&detached, "detached", "d", false, "run the command in the background instead of blocking the shell")
 	cmd.Flags().StringVarP(&cpu, "cpu", "c", "", "CPU instance type. Defaults to 2x8 [2x8, 4x16, 8x32, 16x32]. See docs.brev.dev/cpu for details")
-	cmd.Flags().StringVarP(&gpu, "gpu", "g", "n1-highmem-4:nvidia-tesla-t4:1", "GPU instance type. See https://brev.dev/docs/reference/gpu for details")
+	cmd.Flags().StringArrayVar(&labelFlags, "label", []string{}, "label the instance, ex: team=ml (repeatable)")
+	cmd.Flags().StringVarP(&gpu, "gpu", "g", config.GlobalConfig.GetDefaultInstanceType(), "GPU instance type. See https://brev.dev/docs/reference/gpu for details")
 	return cmd
 }
 
@@ -78,6 +89,7 @@ type CreateOptions struct {
 	WorkspaceClass string
 	Detached       bool
 	InstanceType   string
+	Labels         map[string]string
 }
 
 func runCreateWorkspace(t *terminal.Terminal, options CreateOptions, createStore CreateStore) error {
@@ -122,6 +134,10 @@ func createEmptyWorkspace(user *entity.User, t *terminal.Terminal, options Creat
 		cwOptions.WithInstanceType(options.InstanceType)
 	}
 
+	if len(options.Labels) > 0 {
+		cwOptions.Labels = options.Labels
+	}
+
 	t.Vprintf("Creating instane %s in org %s\n", t.Green(cwOptions.Name), t.Green(orgID))
 	t.Vprintf("\tname %s\n", t.Green(cwOptions.Name))
 	if options.InstanceType != "" {
@@ -129,6 +145,9 @@ func createEmptyWorkspace(user *entity.User, t *terminal.Terminal, options Creat
 	} else {
 		t.Vprintf("\tCPU instance %s\n", t.Green(cwOptions.WorkspaceClassID))
 	}
+	if len(options.Labels) > 0 {
+		t.Vprintf("\tlabels %s\n", t.Green(labels.Format(options.Labels)))
+	}
 	t.Vprintf("\tCloud %s\n\n", t.Green(cwOptions.WorkspaceGroupID))
 
 	s := t.NewSpinner()
@@ -183,24 +202,22 @@ func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace)
 
 func pollUntil(t *terminal.Terminal, wsid string, state string, createStore CreateStore, canSafelyExit bool) error {
 	s := t.NewSpinner()
-	isReady := false
 	if canSafelyExit {
 		t.Vprintf("You can safely ctrl+c to exit\n")
 	}
 	s.Suffix = " hang tight 🤙"
 	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := createStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  instance is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Instance is ready!"
-			s.Stop()
-			isReady = true
-		}
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  instance is " + strings.ToLower(status)
 	}
+	_, err := wait.Until(createStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+	s.Suffix = "Instance is ready!"
+	s.Stop()
 	return nil
 }
@@ -1,14 +1,19 @@
 package delete
 
 import (
+	"bufio"
 	_ "embed"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
+	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
@@ -18,16 +23,21 @@ import (
 var (
 	//go:embed doc.md
 	deleteLong    string
-	deleteExample = "brev delete <ws_name>"
+	deleteExample = "brev delete <ws_name>\nbrev delete --selector team=ml,env=dev --dry-run\nbrev delete --selector team=ml,env=dev"
 )
 
 type DeleteStore interface {
 	completions.CompletionStore
 	DeleteWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetWorkspaceByNameOrID(orgID string, nameOrID string) ([]entity.Workspace, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 }
 
 func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDeleteStore DeleteStore) *cobra.Command {
+	var selectorFlag string
+	var yes bool
+	var dryRun bool
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "delete",
@@ -37,6 +47,21 @@ func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDel
 		Example:               deleteExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginDeleteStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if selectorFlag != "" {
+				if len(args) > 0 {
+					return breverrors.NewValidationError("pass either instance names or --selector")
+				}
+				selector, err := labels.ParseSelector(selectorFlag)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				args, err = getSelectedWorkspaceIDs(t, loginDeleteStore, selector, dryRun, yes, os.Stdin)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+			} else if dryRun {
+				return breverrors.NewValidationError("--dry-run only applies to --selector")
+			}
 			var allError error
 			for _, workspace := range args {
 				err := deleteWorkspace(workspace, t, loginDeleteStore)
@@ -50,10 +75,63 @@ func NewCmdDelete(t *terminal.Terminal, loginDeleteStore DeleteStore, noLoginDel
 			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&selectorFlag, "selector", "l", "", labels.SelectorFlagUsage)
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "delete the instances --selector matches without asking")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the instances --selector matches without deleting them")
 
 	return cmd
 }
 
+// getSelectedWorkspaceIDs lists what the selector matches and asks before
+// deleting it, nothing is returned for a dry run or a no
+func getSelectedWorkspaceIDs(t *terminal.Terminal, deleteStore DeleteStore, selector labels.Selector, dryRun bool, yes bool, in io.Reader) ([]string, error) {
+	workspaces, err := util.GetUserWorkspacesBySelector(deleteStore, selector)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if len(workspaces) == 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no instances match %s", selector))
+	}
+	ok, err := confirmDelete(t, workspaces, selector, dryRun, yes, in)
+	if err != nil || !ok {
+		return nil, err
+	}
+	ids := []string{}
+	for _, w := range workspaces {
+		ids = append(ids, w.ID)
+	}
+	return ids, nil
+}
+
+func confirmDelete(t *terminal.Terminal, workspaces []entity.Workspace, selector labels.Selector, dryRun bool, yes bool, in io.Reader) (bool, error) {
+	t.Vprintf("%d instances match %s:\n", len(workspaces), selector)
+	for _, w := range workspaces {
+		t.Vprintf("  %s (%s)\n", w.Name, w.ID)
+	}
+	if dryRun {
+		t.Vprintf("dry run, nothing deleted\n")
+		return false, nil
+	}
+	if yes {
+		return true, nil
+	}
+	t.Vprintf("Delete these %d instances? This can't be undone [y/N]: ", len(workspaces))
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	case "":
+		if err == io.EOF {
+			return false, breverrors.NewValidationError("can't confirm without a terminal, pass --yes to delete anyway")
+		}
+	}
+	t.Vprintf("nothing deleted\n")
+	return false, nil
+}
+
 func deleteWorkspace(workspaceName string, t *terminal.Terminal, deleteStore DeleteStore) error {
 	workspace, err := util.GetUserWorkspaceByNameOrIDErr(deleteStore, workspaceName)
 	if err != nil {
@@ -1 +1,46 @@
 package delete
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/labels"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmDelete(t *testing.T) {
+	workspaces := []entity.Workspace{{ID: "a1", Name: "ml-train"}, {ID: "b2", Name: "ml-eval"}}
+	selector, err := labels.ParseSelector("team=ml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		dryRun  bool
+		yes     bool
+		input   string
+		want    bool
+		wantErr string
+	}{
+		{name: "dry run", dryRun: true, yes: true, want: false},
+		{name: "yes flag", yes: true, want: true},
+		{name: "answer y", input: "y\n", want: true},
+		{name: "answer yes", input: "YES\n", want: true},
+		{name: "answer n", input: "n\n", want: false},
+		{name: "just enter", input: "\n", want: false},
+		{name: "no terminal", input: "", wantErr: "pass --yes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confirmDelete(terminal.New(), workspaces, selector, tt.dryRun, tt.yes, strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/auth"
+	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/ssh"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+const (
+	slowAPI      = 2 * time.Second
+	clockSkewOK  = 30 * time.Second
+	clockSkewBad = 5 * time.Minute
+)
+
+// runCommand runs a diagnostic command, swapped out in tests
+var runCommand = func(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput() //nolint:gosec // fixed diagnostic commands
+	if err != nil {
+		return string(out), breverrors.WrapAndTrace(err)
+	}
+	return string(out), nil
+}
+
+type apiStore interface {
+	Healthcheck() error
+}
+
+func checkAPI(s apiStore) Result {
+	start := time.Now()
+	err := s.Healthcheck()
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return Result{
+			Status:      Fail,
+			Message:     fmt.Sprintf("%s is unreachable: %v", config.GlobalConfig.GetBrevAPIURl(), err),
+			Remediation: "check that your network, vpn or proxy lets you reach it",
+		}
+	}
+	if latency > slowAPI {
+		return Result{
+			Status:      Warn,
+			Message:     fmt.Sprintf("reachable but slow, %s", latency),
+			Remediation: "commands will be slow, a vpn or proxy may be in the way",
+		}
+	}
+	return Result{Status: Pass, Message: fmt.Sprintf("reachable in %s", latency)}
+}
+
+type clockStore interface {
+	ServerTime() (time.Time, error)
+}
+
+func checkClock(s clockStore, now func() time.Time) Result {
+	serverTime, err := s.ServerTime()
+	if err != nil {
+		return Result{Status: Skip, Message: fmt.Sprintf("couldn't read the api's time: %v", err)}
+	}
+	skew := now().Sub(serverTime).Round(time.Second)
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	remediation := "turn on automatic time sync, ex: sudo timedatectl set-ntp true on linux or Set time automatically on macos"
+	switch {
+	case abs > clockSkewBad:
+		return Result{Status: Fail, Message: fmt.Sprintf("your clock is off by %s, logins will be rejected", skew), Remediation: remediation}
+	case abs > clockSkewOK:
+		return Result{Status: Warn, Message: fmt.Sprintf("your clock is off by %s", skew), Remediation: remediation}
+	}
+	return Result{Status: Pass, Message: "in sync with the api"}
+}
+
+type loginStore interface {
+	GetAuthTokens() (*entity.AuthTokens, error)
+	GetCurrentUser() (*entity.User, error)
+}
+
+func checkLogin(s loginStore, now func() time.Time) Result {
+	tokens, err := s.GetAuthTokens()
+	if err != nil || tokens == nil || tokens.AccessToken == "" {
+		return Result{Status: Fail, Message: "not logged in", Remediation: "run brev login"}
+	}
+	who := auth.GetEmailFromToken(tokens.AccessToken)
+	if who == "" {
+		who = "you"
+	}
+	expiry, ok := auth.GetExpiryFromToken(tokens.AccessToken)
+	if ok && now().After(expiry) {
+		if tokens.RefreshToken == "" {
+			return Result{Status: Fail, Message: "your login expired", Remediation: "run brev login"}
+		}
+		return Result{
+			Status:      Warn,
+			Message:     fmt.Sprintf("the access token for %s expired %s ago", who, now().Sub(expiry).Round(time.Minute)),
+			Remediation: "it is refreshed on the next command that calls the api",
+			Fix: func() error {
+				_, userErr := s.GetCurrentUser()
+				return userErr //nolint:wrapcheck // printed as is
+			},
+		}
+	}
+	_, err = s.GetCurrentUser()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("the api rejected the login for %s: %v", who, err), Remediation: "run brev login"}
+	}
+	message := "logged in as " + who
+	if ok {
+		message += fmt.Sprintf(", token valid for %s", expiry.Sub(now()).Round(time.Minute))
+	}
+	return Result{Status: Pass, Message: message}
+}
+
+type sshIncludeStore interface {
+	GetUserSSHConfig() (string, error)
+	WriteUserSSHConfig(config string) error
+	GetUserSSHConfigPath() (string, error)
+	GetBrevSSHConfigPath() (string, error)
+}
+
+func checkSSHInclude(s sshIncludeStore) Result {
+	userConfigPath, err := s.GetUserSSHConfigPath()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't find your ssh config: %v", err)}
+	}
+	brevConfigPath, err := s.GetBrevSSHConfigPath()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't find the brev ssh config: %v", err)}
+	}
+	conf, err := s.GetUserSSHConfig()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't read %s: %v", userConfigPath, err)}
+	}
+	if !ssh.DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+		return Result{
+			Status:      Fail,
+			Message:     fmt.Sprintf("%s doesn't include %s, ssh and editors can't find your instances", userConfigPath, brevConfigPath),
+			Remediation: fmt.Sprintf("add Include \"%s\" to the top of %s", brevConfigPath, userConfigPath),
+			Fix: func() error {
+				newConf, addErr := ssh.AddIncludeToUserConfig(conf, brevConfigPath)
+				if addErr != nil {
+					return addErr //nolint:wrapcheck // printed as is
+				}
+				return s.WriteUserSSHConfig(newConf) //nolint:wrapcheck // printed as is
+			},
+		}
+	}
+	return Result{Status: Pass, Message: fmt.Sprintf("%s includes the brev config", userConfigPath)}
+}
+
+type privateKeyStore interface {
+	GetPrivateKeyPath() (string, error)
+	Chmod(string, os.FileMode) error
+}
+
+func checkPrivateKey(s privateKeyStore, refreshFix func() error) Result {
+	keyPath, err := s.GetPrivateKeyPath()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't find the brev private key: %v", err)}
+	}
+	info, err := os.Stat(keyPath)
+	if os.IsNotExist(err) {
+		return Result{Status: Fail, Message: fmt.Sprintf("%s is missing", keyPath), Remediation: "run brev refresh", Fix: refreshFix}
+	}
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't read %s: %v", keyPath, err)}
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return Result{
+			Status:      Fail,
+			Message:     fmt.Sprintf("%s is %s, ssh refuses keys others can read", keyPath, info.Mode().Perm()),
+			Remediation: fmt.Sprintf("chmod 600 %s", keyPath),
+			Fix: func() error {
+				return s.Chmod(keyPath, 0o600) //nolint:wrapcheck // printed as is
+			},
+		}
+	}
+	return Result{Status: Pass, Message: fmt.Sprintf("%s is only readable by you", keyPath)}
+}
+
+func checkCloudflared(s store.CloudflaredStore) Result {
+	binaryPath, err := s.GetBrevCloudflaredBinaryPath()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't find cloudflared: %v", err)}
+	}
+	update := func() error {
+		return store.NewCloudflare(s).Update() //nolint:wrapcheck // printed as is
+	}
+	exists, err := s.FileExists(binaryPath)
+	if err != nil || !exists {
+		return Result{
+			Status:      Warn,
+			Message:     fmt.Sprintf("%s is missing, instances reached through cloudflare won't connect", binaryPath),
+			Remediation: "run brev cloudflared update",
+			Fix:         update,
+		}
+	}
+	out, err := runCommand(binaryPath, "--version")
+	version := store.ParseCloudflaredVersion(out)
+	if err != nil || version == "" {
+		return Result{
+			Status:      Fail,
+			Message:     fmt.Sprintf("%s doesn't run: %s", binaryPath, strings.TrimSpace(out)),
+			Remediation: "run brev cloudflared update",
+			Fix:         update,
+		}
+	}
+	if version != store.CloudflaredVersion {
+		return Result{
+			Status:      Warn,
+			Message:     fmt.Sprintf("version %s, brev expects %s", version, store.CloudflaredVersion),
+			Remediation: "run brev cloudflared update",
+			Fix:         update,
+		}
+	}
+	return Result{Status: Pass, Message: "version " + version}
+}
+
+type sshEntriesStore interface {
+	GetBrevSSHConfigPath() (string, error)
+	GetFileAsString(path string) (string, error)
+	FileExists(path string) (bool, error)
+	GetContextWorkspaces() ([]entity.Workspace, error)
+}
+
+func checkSSHEntries(s sshEntriesStore, refreshFix func() error) Result {
+	workspaces, err := s.GetContextWorkspaces()
+	if err != nil {
+		return Result{Status: Skip, Message: fmt.Sprintf("couldn't list your instances: %v", err)}
+	}
+	brevConfigPath, err := s.GetBrevSSHConfigPath()
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("can't find the brev ssh config: %v", err)}
+	}
+	conf := ""
+	exists, err := s.FileExists(brevConfigPath)
+	if err == nil && exists {
+		conf, err = s.GetFileAsString(brevConfigPath)
+		if err != nil {
+			return Result{Status: Fail, Message: fmt.Sprintf("can't read %s: %v", brevConfigPath, err)}
+		}
+	}
+	stale, missing := diffSSHEntries(sshConfigHosts(conf), workspaces)
+	if len(stale) == 0 && len(missing) == 0 {
+		return Result{Status: Pass, Message: fmt.Sprintf("%s matches your running instances", brevConfigPath)}
+	}
+	problems := []string{}
+	if len(stale) > 0 {
+		problems = append(problems, fmt.Sprintf("entries for instances that aren't running: %s", strings.Join(stale, ", ")))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("no entries for running instances: %s", strings.Join(missing, ", ")))
+	}
+	return Result{Status: Warn, Message: strings.Join(problems, "; "), Remediation: "run brev refresh", Fix: refreshFix}
+}
+
+// sshConfigHosts are the Host aliases in an ssh config
+func sshConfigHosts(conf string) []string {
+	hosts := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(conf))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && strings.EqualFold(fields[0], "Host") {
+			hosts = append(hosts, fields[1:]...)
+		}
+	}
+	return hosts
+}
+
+// diffSSHEntries compares the hosts in the brev ssh config with the ones the
+// running workspaces should have, the way ConfigUpdater writes them
+func diffSSHEntries(hosts []string, workspaces []entity.Workspace) ([]string, []string) {
+	want := map[string]bool{}
+	for _, w := range workspaces {
+		if w.Status == entity.Running {
+			want[string(w.GetLocalIdentifier())] = true
+		}
+	}
+	have := map[string]bool{}
+	stale := []string{}
+	for _, h := range hosts {
+		have[h] = true
+		if !want[h] && !want[strings.TrimSuffix(h, "-host")] {
+			stale = append(stale, h)
+		}
+	}
+	missing := []string{}
+	for w := range want {
+		if !have[w] {
+			missing = append(missing, w)
+		}
+	}
+	sort.Strings(stale)
+	sort.Strings(missing)
+	return stale, missing
+}
+
+type daemonStore interface {
+	FileExists(path string) (bool, error)
+	UserHomeDir() (string, error)
+}
+
+// checkDaemon looks for the ssh config daemon brev tasks configure installs
+func checkDaemon(s daemonStore) Result {
+	var unit string
+	var running func() bool
+	switch runtime.GOOS {
+	case "linux":
+		unit = "/etc/systemd/system/brevsshcd.service"
+		running = func() bool {
+			_, err := runCommand("systemctl", "is-active", "--quiet", "brevsshcd.service")
+			return err == nil
+		}
+	case "darwin":
+		home, err := s.UserHomeDir()
+		if err != nil {
+			return Result{Status: Skip, Message: fmt.Sprintf("can't find your home dir: %v", err)}
+		}
+		unit = filepath.Join(home, "Library", "LaunchDaemons", "com.brev.sshcd.plist")
+		running = func() bool {
+			_, err := runCommand("launchctl", "list", "com.brev.sshcd")
+			return err == nil
+		}
+	default:
+		return Result{Status: Skip, Message: fmt.Sprintf("not supported on %s", runtime.GOOS)}
+	}
+	installed, err := s.FileExists(unit)
+	if err != nil || !installed {
+		return Result{Status: Skip, Message: "not installed, the ssh config refreshes whenever brev runs"}
+	}
+	if !running() {
+		return Result{
+			Status:      Warn,
+			Message:     fmt.Sprintf("%s is installed but not running, the ssh config won't follow instance changes", unit),
+			Remediation: "run sudo brev tasks configure",
+		}
+	}
+	return Result{Status: Pass, Message: "the ssh config daemon is running"}
+}
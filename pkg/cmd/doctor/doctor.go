@@ -0,0 +1,150 @@
+// Package doctor checks the local setup brev depends on and fixes what it can
+package doctor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorLong = `Check the local setup brev depends on: the api, your clock, login, the ssh
+config and key, cloudflared and the background ssh config daemon. Each check
+passes, warns or fails with what to do about it, --fix does it where it can.`
+	doctorExample = `  brev doctor
+  brev doctor --fix`
+)
+
+type DoctorStore interface {
+	refresh.RefreshStore
+	Healthcheck() error
+	ServerTime() (time.Time, error)
+	GetAuthTokens() (*entity.AuthTokens, error)
+}
+
+func NewCmdDoctor(t *terminal.Terminal, store DoctorStore) *cobra.Command {
+	var fix bool
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "doctor",
+		DisableFlagsInUseLine: true,
+		Short:                 "Diagnose problems with your local brev setup",
+		Long:                  doctorLong,
+		Example:               doctorExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunDoctor(t, store, fix)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&fix, "fix", false, "fix the problems doctor knows how to fix")
+
+	return cmd
+}
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Result is what a check found, Fix is nil when doctor can't fix it itself
+type Result struct {
+	Status      Status
+	Message     string
+	Remediation string
+	Fix         func() error
+}
+
+type Check struct {
+	Name string
+	Run  func() Result
+}
+
+func makeChecks(store DoctorStore) []Check {
+	refreshFix := func() error {
+		return refresh.RunRefresh(store)
+	}
+	return []Check{
+		{"api", func() Result { return checkAPI(store) }},
+		{"clock", func() Result { return checkClock(store, time.Now) }},
+		{"login", func() Result { return checkLogin(store, time.Now) }},
+		{"ssh config", func() Result { return checkSSHInclude(store) }},
+		{"private key", func() Result { return checkPrivateKey(store, refreshFix) }},
+		{"cloudflared", func() Result { return checkCloudflared(store) }},
+		{"ssh entries", func() Result { return checkSSHEntries(store, refreshFix) }},
+		{"daemon", func() Result { return checkDaemon(store) }},
+	}
+}
+
+func RunDoctor(t *terminal.Terminal, store DoctorStore, fix bool) error {
+	results := runChecks(t, makeChecks(store), fix)
+	failed, fixable := 0, 0
+	for _, r := range results {
+		if r.Status == Fail {
+			failed++
+		}
+		if r.Fix != nil && (r.Status == Fail || r.Status == Warn) {
+			fixable++
+		}
+	}
+	if fixable > 0 && !fix {
+		t.Vprintf("\nrun %s to fix %d of these\n", t.Green("brev doctor --fix"), fixable)
+	}
+	if failed > 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("%d of %d checks failed", failed, len(results)))
+	}
+	return nil
+}
+
+// runChecks runs and prints each check, fixing and rechecking when fix is set
+func runChecks(t *terminal.Terminal, checks []Check, fix bool) []Result {
+	results := []Result{}
+	for _, c := range checks {
+		r := c.Run()
+		if fix && r.Fix != nil && (r.Status == Fail || r.Status == Warn) {
+			fixErr := r.Fix()
+			if fixErr != nil {
+				r.Message = fmt.Sprintf("%s (fix failed: %v)", r.Message, fixErr)
+			} else {
+				r = c.Run()
+				if r.Status == Pass {
+					r.Message = "fixed, " + r.Message
+				}
+			}
+		}
+		printResult(t, c.Name, r)
+		results = append(results, r)
+	}
+	return results
+}
+
+func printResult(t *terminal.Terminal, name string, r Result) {
+	var mark string
+	switch r.Status {
+	case Pass:
+		mark = t.Green("✓")
+	case Warn:
+		mark = t.Yellow("!")
+	case Fail:
+		mark = t.Red("✗")
+	default:
+		mark = "-"
+	}
+	t.Vprintf("%s %-12s %s\n", mark, name, r.Message)
+	if r.Remediation != "" && (r.Status == Warn || r.Status == Fail) {
+		t.Vprintf("  %s\n", t.Yellow(r.Remediation))
+	}
+}
@@ -0,0 +1,176 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+type fakeSSHStore struct {
+	conf string
+}
+
+func (f *fakeSSHStore) GetUserSSHConfig() (string, error) { return f.conf, nil }
+func (f *fakeSSHStore) WriteUserSSHConfig(conf string) error {
+	f.conf = conf
+	return nil
+}
+func (f *fakeSSHStore) GetUserSSHConfigPath() (string, error) { return "/home/me/.ssh/config", nil }
+func (f *fakeSSHStore) GetBrevSSHConfigPath() (string, error) {
+	return "/home/me/.brev/ssh_config", nil
+}
+
+func TestCheckSSHInclude(t *testing.T) {
+	s := &fakeSSHStore{conf: "Host github.com\n  User git\n"}
+	r := checkSSHInclude(s)
+	assert.Equal(t, Fail, r.Status)
+	require.NotNil(t, r.Fix)
+
+	require.NoError(t, r.Fix())
+	assert.Equal(t, "Include \"/home/me/.brev/ssh_config\"\nHost github.com\n  User git\n", s.conf)
+	assert.Equal(t, Pass, checkSSHInclude(s).Status)
+}
+
+type fakeKeyStore struct {
+	path string
+}
+
+func (f fakeKeyStore) GetPrivateKeyPath() (string, error) { return f.path, nil }
+func (f fakeKeyStore) Chmod(path string, mode os.FileMode) error {
+	return os.Chmod(path, mode) //nolint:wrapcheck // test
+}
+
+func TestCheckPrivateKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	s := fakeKeyStore{path: filepath.Join(t.TempDir(), "brev.pem")}
+	refreshed := false
+	refreshFix := func() error {
+		refreshed = true
+		return os.WriteFile(s.path, []byte("key"), 0o644) //nolint:wrapcheck // test
+	}
+
+	r := checkPrivateKey(s, refreshFix)
+	assert.Equal(t, Fail, r.Status)
+	require.NoError(t, r.Fix())
+	assert.True(t, refreshed)
+
+	r = checkPrivateKey(s, refreshFix)
+	assert.Equal(t, Fail, r.Status)
+	assert.Contains(t, r.Message, "-rw-r--r--")
+	require.NoError(t, r.Fix())
+	assert.Equal(t, Pass, checkPrivateKey(s, refreshFix).Status)
+}
+
+type fakeLoginStore struct {
+	tokens  *entity.AuthTokens
+	userErr error
+}
+
+func (f fakeLoginStore) GetAuthTokens() (*entity.AuthTokens, error) { return f.tokens, nil }
+func (f fakeLoginStore) GetCurrentUser() (*entity.User, error) {
+	return &entity.User{}, f.userErr
+}
+
+func makeToken(t *testing.T, exp time.Time) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": exp.Unix(), "email": "me@example.com"}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func TestCheckLogin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	assert.Equal(t, Fail, checkLogin(fakeLoginStore{}, clock).Status)
+
+	valid := &entity.AuthTokens{AccessToken: makeToken(t, now.Add(2*time.Hour)), RefreshToken: "r"}
+	r := checkLogin(fakeLoginStore{tokens: valid}, clock)
+	assert.Equal(t, Pass, r.Status)
+	assert.Equal(t, "logged in as me@example.com, token valid for 2h0m0s", r.Message)
+
+	r = checkLogin(fakeLoginStore{tokens: valid, userErr: errors.New("401")}, clock)
+	assert.Equal(t, Fail, r.Status)
+
+	expired := &entity.AuthTokens{AccessToken: makeToken(t, now.Add(-time.Hour)), RefreshToken: "r"}
+	r = checkLogin(fakeLoginStore{tokens: expired}, clock)
+	assert.Equal(t, Warn, r.Status)
+	assert.NotNil(t, r.Fix)
+
+	expired.RefreshToken = ""
+	assert.Equal(t, Fail, checkLogin(fakeLoginStore{tokens: expired}, clock).Status)
+}
+
+type fakeClockStore time.Time
+
+func (f fakeClockStore) ServerTime() (time.Time, error) { return time.Time(f), nil }
+
+func TestCheckClock(t *testing.T) {
+	server := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for skew, want := range map[time.Duration]Status{
+		2 * time.Second:   Pass,
+		-time.Minute:      Warn,
+		10 * time.Minute:  Fail,
+		-10 * time.Minute: Fail,
+	} {
+		r := checkClock(fakeClockStore(server), func() time.Time { return server.Add(skew) })
+		assert.Equal(t, want, r.Status, skew.String())
+	}
+}
+
+func TestDiffSSHEntries(t *testing.T) {
+	conf := `Host my-app
+  Hostname 1.2.3.4
+Host my-app-host
+  Hostname 1.2.3.4
+Host old-app
+  Hostname 5.6.7.8
+`
+	workspaces := []entity.Workspace{
+		{Name: "my-app", Status: entity.Running},
+		{Name: "new-app", Status: entity.Running},
+		{Name: "stopped-app", Status: entity.Stopped},
+	}
+	hosts := sshConfigHosts(conf)
+	assert.Equal(t, []string{"my-app", "my-app-host", "old-app"}, hosts)
+
+	stale, missing := diffSSHEntries(hosts, workspaces)
+	assert.Equal(t, []string{"old-app"}, stale)
+	assert.Equal(t, []string{"new-app"}, missing)
+}
+
+func TestRunChecksFix(t *testing.T) {
+	broken := true
+	checks := []Check{
+		{"fixable", func() Result {
+			if broken {
+				return Result{Status: Fail, Message: "broken", Fix: func() error {
+					broken = false
+					return nil
+				}}
+			}
+			return Result{Status: Pass, Message: "ok"}
+		}},
+		{"unfixable", func() Result { return Result{Status: Warn, Message: "meh", Remediation: "do it yourself"} }},
+	}
+
+	results := runChecks(terminal.New(), checks, false)
+	assert.Equal(t, Fail, results[0].Status)
+	assert.True(t, broken)
+
+	results = runChecks(terminal.New(), checks, true)
+	assert.Equal(t, Pass, results[0].Status)
+	assert.Equal(t, "fixed, ok", results[0].Message)
+	assert.Equal(t, Warn, results[1].Status)
+}
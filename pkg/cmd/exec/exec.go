@@ -0,0 +1,280 @@
+// Package exec runs non-interactive commands on instances over ssh
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	execLong = `Run a command on one or more instances without opening a shell.
+
+Everything after -- is the command. A single argument is run by the remote
+shell as is, so pipes and && work when quoted; several arguments are quoted
+one by one.
+
+With more than one instance every output line is prefixed with the instance
+name. brev exec exits with the remote exit code, or the highest one when
+running on several instances.`
+	execExample = `
+  brev exec my-instance -- nvidia-smi
+  brev exec my-instance -- 'cd ~/app && git pull'
+  brev exec --all --parallel 8 -- sudo apt-get update
+  brev exec --selector team=ml,env!=prod -- df -h
+	`
+)
+
+type ExecStore interface {
+	completions.CompletionStore
+	util.GetWorkspaceByNameOrIDErrStore
+	util.GetUserWorkspacesStore
+	refresh.RefreshStore
+}
+
+type ExecOptions struct {
+	All      bool
+	Selector string
+	Parallel int
+	Host     bool
+}
+
+func NewCmdExec(t *terminal.Terminal, store ExecStore, noLoginStore ExecStore) *cobra.Command {
+	var opts ExecOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"ssh": ""},
+		Use:                   "exec [instance...] -- command [args...]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Run a command on your instances",
+		Long:                  execLong,
+		Example:               execExample,
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 || dash == len(args) {
+				return breverrors.NewValidationError("please provide a command after --, ex: brev exec my-instance -- uptime")
+			}
+			err := RunExec(t, store, args[:dash], args[dash:], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.All, "all", "a", false, "run on all of your running instances")
+	cmd.Flags().StringVarP(&opts.Selector, "selector", "l", "", "run on running instances matching this label selector, ex: team=ml,env!=prod")
+	cmd.Flags().IntVarP(&opts.Parallel, "parallel", "p", 4, "how many instances to run on at once")
+	cmd.Flags().BoolVar(&opts.Host, "host", false, "run on the host machine instead of the container")
+	return cmd
+}
+
+func RunExec(t *terminal.Terminal, execStore ExecStore, names []string, command []string, opts ExecOptions) error {
+	if opts.Parallel < 1 {
+		return breverrors.NewValidationError("--parallel must be at least 1")
+	}
+	selector, err := labels.ParseSelector(opts.Selector)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspaces, err := getTargets(t, execStore, names, opts.All, selector)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(workspaces) == 0 {
+		return breverrors.NewValidationError("no running instances matched")
+	}
+
+	// the aliases have to be in the ssh config before we can use them
+	err = refresh.RunRefresh(execStore)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	targets := make([]Target, len(workspaces))
+	for i, w := range workspaces {
+		targets[i] = Target{Name: w.Name, Alias: string(w.GetLocalIdentifier())}
+		if opts.Host {
+			targets[i].Alias = string(w.GetHostIdentifier())
+		}
+	}
+	results := Run(targets, RemoteCommand(command), opts.Parallel, os.Stdout, os.Stderr, sshRunner)
+	return summarize(t, results)
+}
+
+func getTargets(t *terminal.Terminal, execStore ExecStore, names []string, all bool, selector labels.Selector) ([]entity.Workspace, error) {
+	if len(names) > 0 && (all || !selector.Empty()) {
+		return nil, breverrors.NewValidationError("pass instance names or --all/--selector, not both")
+	}
+	if len(names) == 0 && !all && selector.Empty() {
+		return nil, breverrors.NewValidationError("please provide an instance, --all or --selector")
+	}
+
+	if len(names) > 0 {
+		workspaces := []entity.Workspace{}
+		for _, name := range names {
+			workspace, err := util.GetUserWorkspaceByNameOrIDErr(execStore, name)
+			if err != nil {
+				return nil, breverrors.WrapAndTrace(err)
+			}
+			if workspace.Status != entity.Running {
+				return nil, breverrors.NewValidationError(fmt.Sprintf("%s is %s, start it with: brev start %s", workspace.Name, strings.ToLower(workspace.Status), workspace.Name))
+			}
+			workspaces = append(workspaces, *workspace)
+		}
+		return workspaces, nil
+	}
+
+	workspaces, err := util.GetUserWorkspacesBySelector(execStore, selector)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	matched := []entity.Workspace{}
+	for _, w := range workspaces {
+		if w.Status != entity.Running {
+			t.Vprintf(t.Yellow("skipping %s, it is %s\n", w.Name, strings.ToLower(w.Status)))
+			continue
+		}
+		matched = append(matched, w)
+	}
+	return matched, nil
+}
+
+// RemoteCommand builds the string ssh hands to the remote shell
+func RemoteCommand(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type Target struct {
+	Name  string
+	Alias string
+}
+
+type Result struct {
+	Target   Target
+	ExitCode int
+	Err      error // set when the command could not be run at all
+}
+
+// Runner runs command on alias and returns the remote exit code
+type Runner func(alias, command string, stdout, stderr io.Writer) (int, error)
+
+// Run fans command out to targets, at most parallel at a time. Output is
+// prefixed with the target name when there is more than one target.
+func Run(targets []Target, command string, parallel int, stdout, stderr io.Writer, runner Runner) []Result {
+	results := make([]Result, len(targets))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			out, errOut := stdout, stderr
+			var prefixed []*PrefixWriter
+			if len(targets) > 1 {
+				prefix := "[" + target.Name + "] "
+				prefixed = []*PrefixWriter{NewPrefixWriter(stdout, prefix, &mu), NewPrefixWriter(stderr, prefix, &mu)}
+				out, errOut = prefixed[0], prefixed[1]
+			}
+			code, err := runner(target.Alias, command, out, errOut)
+			for _, p := range prefixed {
+				_ = p.Flush()
+			}
+			results[i] = Result{Target: target, ExitCode: code, Err: err}
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// sshFailedExitCode is what ssh exits with when it fails itself
+const sshFailedExitCode = 255
+
+func sshRunner(alias, command string, stdout, stderr io.Writer) (int, error) {
+	cmd := osexec.Command("ssh", "-o", "BatchMode=yes", alias, command) //nolint:gosec // the command is what the user asked to run
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err == nil {
+		return 0, nil
+	}
+	if exitErr, ok := err.(*osexec.ExitError); ok { //nolint:errorlint // Run returns it unwrapped
+		return exitErr.ExitCode(), nil
+	}
+	return -1, breverrors.WrapAndTrace(err)
+}
+
+// ExitError carries the remote exit code out to main
+type ExitError struct {
+	Code   int
+	Failed []string
+}
+
+func (e ExitError) ExitCode() int {
+	return e.Code
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("command failed on %s", strings.Join(e.Failed, ", "))
+}
+
+var _ breverrors.ExitCoder = ExitError{}
+
+func summarize(t *terminal.Terminal, results []Result) error {
+	failed := []string{}
+	code := 0
+	for _, r := range results {
+		if r.Err != nil {
+			t.Eprint(t.Red(fmt.Sprintf("%s: %s\n", r.Target.Name, r.Err.Error())))
+			return breverrors.WrapAndTrace(r.Err)
+		}
+		if r.ExitCode != 0 {
+			failed = append(failed, fmt.Sprintf("%s (exit %d)", r.Target.Name, r.ExitCode))
+			// ssh killed by a signal reports -1, fail like ssh does
+			rc := r.ExitCode
+			if rc < 1 {
+				rc = sshFailedExitCode
+			}
+			if rc > code {
+				code = rc
+			}
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return ExitError{Code: code, Failed: failed}
+}
@@ -0,0 +1,84 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+func TestRemoteCommand(t *testing.T) {
+	assert.Equal(t, "cd app && make", RemoteCommand([]string{"cd app && make"}))
+	assert.Equal(t, "ls -la 'my dir' 'it'\\''s'", RemoteCommand([]string{"ls", "-la", "my dir", "it's"}))
+	assert.Equal(t, "echo ''", RemoteCommand([]string{"echo", ""}))
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewPrefixWriter(&out, "[a] ", &sync.Mutex{})
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree"))
+	assert.Equal(t, "[a] one\n[a] two\n", out.String())
+	assert.Nil(t, w.Flush())
+	assert.Equal(t, "[a] one\n[a] two\n[a] three\n", out.String())
+}
+
+func TestRun(t *testing.T) {
+	targets := []Target{{Name: "a", Alias: "a-alias"}, {Name: "b", Alias: "b-alias"}}
+	runner := func(alias, command string, stdout, _ io.Writer) (int, error) {
+		_, _ = fmt.Fprintf(stdout, "%s ran %s\n", alias, command)
+		if alias == "b-alias" {
+			return 3, nil
+		}
+		return 0, nil
+	}
+	var out, errOut bytes.Buffer
+	results := Run(targets, "uptime", 1, &out, &errOut, runner)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+	assert.Equal(t, []string{"[a] a-alias ran uptime", "[b] b-alias ran uptime"}, lines)
+	assert.Equal(t, 0, results[0].ExitCode)
+	assert.Equal(t, 3, results[1].ExitCode)
+
+	out.Reset()
+	Run(targets[:1], "uptime", 4, &out, &errOut, runner)
+	assert.Equal(t, "a-alias ran uptime\n", out.String())
+}
+
+func TestExitError(t *testing.T) {
+	err := breverrors.WrapAndTrace(ExitError{Code: 3, Failed: []string{"b (exit 3)"}})
+	assert.Equal(t, 3, breverrors.GetExitCode(err))
+}
+
+func TestSummarize(t *testing.T) {
+	a, b := Target{Name: "a"}, Target{Name: "b"}
+	tests := []struct {
+		name    string
+		results []Result
+		want    int
+	}{
+		{"all ok", []Result{{Target: a}, {Target: b}}, 0},
+		{"highest code wins", []Result{{Target: a, ExitCode: 2}, {Target: b, ExitCode: 7}}, 7},
+		{"killed by a signal", []Result{{Target: a}, {Target: b, ExitCode: -1}}, 255},
+		{"signal and a code", []Result{{Target: a, ExitCode: 3}, {Target: b, ExitCode: -1}}, 255},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := summarize(terminal.New(), tt.results)
+			if tt.want == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.want, breverrors.GetExitCode(err))
+		})
+	}
+}
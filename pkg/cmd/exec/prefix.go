@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// PrefixWriter writes whole lines with a prefix, so output from several
+// instances sharing a terminal doesn't interleave mid line
+type PrefixWriter struct {
+	out    io.Writer
+	prefix []byte
+	mu     *sync.Mutex // shared by every writer on the same terminal
+	buf    bytes.Buffer
+}
+
+func NewPrefixWriter(out io.Writer, prefix string, mu *sync.Mutex) *PrefixWriter {
+	return &PrefixWriter{out: out, prefix: []byte(prefix), mu: mu}
+}
+
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		err := w.writeLine(w.buf.Next(i + 1))
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes a trailing partial line, if any
+func (w *PrefixWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := append(w.buf.Next(w.buf.Len()), '\n')
+	return w.writeLine(line)
+}
+
+func (w *PrefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.out.Write(append(append([]byte{}, w.prefix...), line...))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package label sets and removes labels on existing instances
+package label
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	labelLong = `Set or remove labels on an instance.
+
+key=value sets a label and key- removes it. With no changes the instance's
+current labels are printed. Labels can be used to filter most commands with
+--selector, ex: brev ls --selector team=ml`
+	labelExample = `
+  brev label my-instance team=ml env=dev
+  brev label my-instance env-
+  brev label my-instance
+	`
+)
+
+type LabelStore interface {
+	completions.CompletionStore
+	util.GetWorkspaceByNameOrIDErrStore
+	ModifyWorkspace(workspaceID string, options *store.ModifyWorkspaceRequest) (*entity.Workspace, error)
+}
+
+func NewCmdLabel(t *terminal.Terminal, labelStore LabelStore, noLoginLabelStore LabelStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "label <instance> [key=value...] [key-...]",
+		DisableFlagsInUseLine: true,
+		Short:                 "Set or remove labels on an instance",
+		Long:                  labelLong,
+		Example:               labelExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.MinimumNArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginLabelStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunLabel(t, labelStore, args[0], args[1:])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func RunLabel(t *terminal.Terminal, labelStore LabelStore, name string, changes []string) error {
+	set, remove, err := labels.ParseChanges(changes)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(labelStore, name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(changes) == 0 {
+		printLabels(t, workspace.Name, workspace.Labels)
+		return nil
+	}
+
+	updated := labels.Merge(workspace.Labels, set, remove)
+	if labels.Format(updated) == labels.Format(workspace.Labels) {
+		t.Vprintf("%s labels unchanged\n", workspace.Name)
+		return nil
+	}
+	ws, err := labelStore.ModifyWorkspace(workspace.ID, &store.ModifyWorkspaceRequest{Labels: &updated})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	printLabels(t, ws.Name, updated)
+	return nil
+}
+
+func printLabels(t *terminal.Terminal, name string, l map[string]string) {
+	if len(l) == 0 {
+		t.Vprintf("%s has no labels\n", name)
+		return
+	}
+	t.Vprintf("%s labels: %s\n", name, t.Green(labels.Format(l)))
+}
@@ -0,0 +1,309 @@
+// Package logs shows setup, exec, verb build and background logs from an instance
+package logs
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/cp"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/setupworkspace"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+var (
+	logsLong = `Show logs from an instance without having to know where they are kept.
+
+With no flags the setup log is shown. --exec shows the latest run of a setup
+exec, add --since to also see earlier runs. Flags can be combined, each line
+is prefixed with where it came from and, when following, the time it was
+written.`
+	logsExample = `
+  brev logs my-instance
+  brev logs my-instance --exec build -f
+  brev logs my-instance --exec build --since 2h
+  brev logs my-instance --setup --background -f
+  brev logs my-instance --verb
+	`
+)
+
+// where brev background writes on the instance
+const backgroundLogPath = "~/brev-background-logs/log.txt"
+
+type LogsStore interface {
+	cp.CpStore
+	GetVerbLogPath(workspaceID string) (string, error)
+}
+
+type LogsOptions struct {
+	Execs      []string
+	Setup      bool
+	Verb       bool
+	Background bool
+	Follow     bool
+	Since      string
+}
+
+func NewCmdLogs(t *terminal.Terminal, store LogsStore, noLoginStore LogsStore) *cobra.Command {
+	var opts LogsOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "logs <instance>",
+		DisableFlagsInUseLine: true,
+		Short:                 "Show setup, exec and build logs from an instance",
+		Long:                  logsLong,
+		Example:               logsExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunLogs(t, cmd.OutOrStdout(), store, args[0], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&opts.Execs, "exec", nil, "show the log of a setup exec, can be repeated")
+	cmd.Flags().BoolVar(&opts.Setup, "setup", false, "show the setup log (the default)")
+	cmd.Flags().BoolVar(&opts.Verb, "verb", false, "show the log of the last verb container build")
+	cmd.Flags().BoolVar(&opts.Background, "background", false, "show the log of commands started with brev background")
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "keep printing new lines as they are written")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "only logs written since a duration ago (ex: 30m) or a time (RFC3339)")
+	return cmd
+}
+
+// LogSource is one log file on the instance, runs before the latest are
+// kept in ArchiveDir when the file has one
+type LogSource struct {
+	Label      string
+	Host       bool
+	Path       string
+	ArchiveDir string
+}
+
+func RunLogs(t *terminal.Terminal, out io.Writer, store LogsStore, workspaceNameOrID string, opts LogsOptions) error {
+	since, err := ParseSince(opts.Since, time.Now())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(store, workspaceNameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	remote, err := cp.GetRemote(store, workspaceNameOrID, false)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	verbLogPath := ""
+	if opts.Verb {
+		verbLogPath, err = store.GetVerbLogPath(workspace.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	sources, err := Sources(*workspace, opts, verbLogPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	var hostRemote *filesync.Remote
+	for _, s := range sources {
+		if s.Host && hostRemote == nil {
+			hostRemote, err = cp.GetRemote(store, workspaceNameOrID, true)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var logsErr error
+	for _, s := range sources {
+		r := remote
+		if s.Host {
+			r = hostRemote
+		}
+		w := &prefixWriter{mu: &mu, out: out, prefix: t.Yellow("[%s] ", s.Label), stamp: opts.Follow, now: time.Now}
+		script := s.Script(opts.Follow, since)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runErr := r.Run(script, w)
+			w.Flush()
+			if runErr != nil {
+				mu.Lock()
+				logsErr = multierror.Append(logsErr, breverrors.Wrap(runErr, s.Label))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if logsErr != nil {
+		return breverrors.WrapAndTrace(logsErr)
+	}
+	return nil
+}
+
+// Sources finds the files to show, exec logs are where setupworkspace writes
+// them for the instance's execs
+func Sources(workspace entity.Workspace, opts LogsOptions, verbLogPath string) ([]LogSource, error) {
+	sources := []LogSource{}
+	if opts.Setup || (len(opts.Execs) == 0 && !opts.Verb && !opts.Background) {
+		sources = append(sources, LogSource{Label: "setup", Path: setupworkspace.SetupLogPath})
+	}
+	wi := setupworkspace.WorkspaceIniter{WorkspaceDir: setupworkspace.DefaultWorkspaceDir}
+	for _, name := range opts.Execs {
+		execName := entity.ExecName(name)
+		var logFile, archiveDir string
+		if workspace.ExecsV1 != nil {
+			if e, ok := (*workspace.ExecsV1)[execName]; ok {
+				var err error
+				logFile, archiveDir, err = wi.GetExecLogFiles(execName, e)
+				if err != nil {
+					return nil, breverrors.WrapAndTrace(err)
+				}
+			}
+		}
+		if logFile == "" {
+			if !isExecV0(workspace, execName) {
+				return nil, breverrors.NewValidationError(fmt.Sprintf("%s has no exec %s, execs are: %s", workspace.Name, name, strings.Join(execNames(workspace), ", ")))
+			}
+			logFile, archiveDir = wi.GetExecV0LogFiles(execName)
+		}
+		sources = append(sources, LogSource{Label: "exec " + name, Path: logFile, ArchiveDir: archiveDir})
+	}
+	if opts.Verb {
+		if verbLogPath == "" {
+			return nil, breverrors.NewValidationError("no verb build of this instance was started from this machine, so its log location isn't known")
+		}
+		sources = append(sources, LogSource{Label: "verb", Host: true, Path: verbLogPath})
+	}
+	if opts.Background {
+		sources = append(sources, LogSource{Label: "background", Path: backgroundLogPath})
+	}
+	return sources, nil
+}
+
+// setup.sh is made from the setup script on every instance
+func isExecV0(workspace entity.Workspace, name entity.ExecName) bool {
+	if name == "setup.sh" {
+		return true
+	}
+	_, ok := workspace.ExecsV0[name]
+	return ok
+}
+
+func execNames(workspace entity.Workspace) []string {
+	names := []string{"setup.sh"}
+	if workspace.ExecsV1 != nil {
+		for n := range *workspace.ExecsV1 {
+			names = append(names, string(n))
+		}
+	}
+	for n := range workspace.ExecsV0 {
+		if n != "setup.sh" {
+			names = append(names, string(n))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Script prints the log on the instance. With since, every archived run
+// started after it is printed; otherwise only the latest run is.
+func (s LogSource) Script(follow bool, since time.Time) string {
+	var b strings.Builder
+	b.WriteString(`header() { echo "==> $1 (last written $(date -u -r "$1" +%Y-%m-%dT%H:%M:%SZ)) <=="; }; `)
+	p := filesync.RemotePath(s.Path)
+	newer := "true"
+	if !since.IsZero() {
+		newer = fmt.Sprintf(`[ "$(stat -c %%Y "$f")" -ge %d ]`, since.Unix())
+	}
+	if !since.IsZero() && s.ArchiveDir != "" {
+		// the archive has every run including the latest, so only new lines of it are followed
+		prefix := strings.TrimSuffix(path.Base(s.Path), ".log")
+		fmt.Fprintf(&b, `for f in $(ls -1tr %s/%s-*.log 2>/dev/null); do if %s; then header "$f"; cat "$f"; fi; done; `,
+			filesync.RemotePath(s.ArchiveDir), filesync.RemotePath(prefix), newer)
+		if follow {
+			fmt.Fprintf(&b, "exec tail -n 0 -F %s 2>/dev/null", p)
+		}
+		return b.String()
+	}
+	if follow {
+		fmt.Fprintf(&b, "f=%[1]s; if [ -f \"$f\" ]; then header \"$f\"; fi; exec tail -n +1 -F \"$f\" 2>/dev/null", p)
+		return b.String()
+	}
+	fmt.Fprintf(&b, `f=%s; if [ ! -f "$f" ]; then echo "no log at $f yet"; elif %s; then header "$f"; cat "$f"; fi`, p, newer)
+	return b.String()
+}
+
+// ParseSince takes a duration before now or an RFC3339 time, empty is the zero time
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseDuration(since)
+	if err == nil {
+		return now.Add(-d), nil
+	}
+	ts, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, breverrors.NewValidationError(fmt.Sprintf("--since %s: expected a duration like 30m or a time like 2024-01-02T15:04:05Z", since))
+	}
+	return ts, nil
+}
+
+// prefixWriter writes whole lines with a prefix so concurrent logs don't
+// interleave mid line
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	stamp  bool
+	now    func() time.Time
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := strings.IndexByte(string(w.buf), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.writeLine(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes a last line that didn't end in a newline
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stamp {
+		_, _ = fmt.Fprintf(w.out, "%s %s%s\n", w.now().Format("15:04:05"), w.prefix, line)
+		return
+	}
+	_, _ = fmt.Fprintf(w.out, "%s%s\n", w.prefix, line)
+}
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestSources(t *testing.T) {
+	logPath := "/tmp/build-logs"
+	workspace := entity.Workspace{
+		Name: "my-instance",
+		ExecsV1: &entity.ExecsV1{
+			"build": {Type: entity.StringExecType},
+			"serve": {Type: entity.PathExecType, PathExec: entity.PathExec{ExecPath: "app/serve.sh"}, ExecOptions: entity.ExecOptions{LogPath: &logPath}},
+		},
+	}
+
+	sources, err := Sources(workspace, LogsOptions{}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []LogSource{{Label: "setup", Path: "/var/log/brev-workspace.log"}}, sources)
+
+	sources, err = Sources(workspace, LogsOptions{Execs: []string{"build", "serve", "setup.sh"}, Background: true}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []LogSource{
+		{Label: "exec build", Path: "/home/brev/workspace/.brev/logs/build.log", ArchiveDir: "/home/brev/workspace/.brev/logs/archive"},
+		{Label: "exec serve", Path: "/tmp/build-logs/serve.log", ArchiveDir: "/tmp/build-logs/archive"},
+		{Label: "exec setup.sh", Path: "/home/brev/workspace/.brev/logs/setup.log", ArchiveDir: "/home/brev/workspace/.brev/logs/archive"},
+		{Label: "background", Path: "~/brev-background-logs/log.txt"},
+	}, sources)
+
+	_, err = Sources(workspace, LogsOptions{Execs: []string{"nope"}}, "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "execs are: build, serve, setup.sh")
+	}
+
+	_, err = Sources(workspace, LogsOptions{Verb: true}, "")
+	assert.Error(t, err)
+	sources, err = Sources(workspace, LogsOptions{Verb: true}, "/var/log/verb.log")
+	assert.Nil(t, err)
+	assert.Equal(t, []LogSource{{Label: "verb", Host: true, Path: "/var/log/verb.log"}}, sources)
+}
+
+func TestScript(t *testing.T) {
+	s := LogSource{Label: "exec build", Path: "/w/.brev/logs/build.log", ArchiveDir: "/w/.brev/logs/archive"}
+	assert.Contains(t, s.Script(false, time.Time{}), `f=/w/.brev/logs/build.log; if [ ! -f "$f" ]`)
+	assert.Contains(t, s.Script(true, time.Time{}), `exec tail -n +1 -F "$f"`)
+
+	since := time.Unix(1700000000, 0)
+	script := s.Script(true, since)
+	assert.Contains(t, script, "ls -1tr /w/.brev/logs/archive/build-*.log")
+	assert.Contains(t, script, "-ge 1700000000")
+	assert.Contains(t, script, "exec tail -n 0 -F /w/.brev/logs/build.log")
+
+	s = LogSource{Label: "background", Path: "~/brev-background-logs/log.txt"}
+	assert.Contains(t, s.Script(false, since), "f=brev-background-logs/log.txt;")
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	ts, err := ParseSince("", now)
+	assert.Nil(t, err)
+	assert.True(t, ts.IsZero())
+	ts, err = ParseSince("90m", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), ts)
+	ts, err = ParseSince("2024-01-02T10:00:00Z", now)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, ts.Hour())
+	_, err = ParseSince("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out strings.Builder
+	var mu sync.Mutex
+	w := &prefixWriter{mu: &mu, out: &out, prefix: "[setup] ", now: func() time.Time { return time.Date(2024, 1, 1, 9, 8, 7, 0, time.UTC) }}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree"))
+	w.Flush()
+	assert.Equal(t, "[setup] one\n[setup] two\n[setup] three\n", out.String())
+
+	out.Reset()
+	w.stamp = true
+	_, _ = w.Write([]byte("four\n"))
+	assert.Equal(t, "09:08:07 [setup] four\n", out.String())
+}
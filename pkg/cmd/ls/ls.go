@@ -16,6 +16,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/entity/virtualproject"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/labels"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/brevdev/brev-cli/pkg/util"
@@ -37,6 +38,8 @@ type LsStore interface {
 func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore) *cobra.Command {
 	var showAll bool
 	var org string
+	var outputFlag string
+	var selectorFlag string
 
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"context": ""},
@@ -48,8 +51,19 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
   brev ls
   brev ls orgs
   brev ls --org <orgid>
+  brev ls --selector team=ml,env=dev
+  brev ls --output json
+  brev ls --output custom-columns=NAME:.name,STATUS:.displayStatus
 		`,
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if output.IsStructured() {
+				// don't interleave onboarding hints with machine readable output
+				return nil
+			}
 			if hello.ShouldWeRunOnboardingLSStep(noLoginLsStore) && hello.ShouldWeRunOnboarding(noLoginLsStore) {
 				// Getting the workspaces should go in the hello.go file but then
 				// requires passing in stores and that makes it hard to use in other commands
@@ -94,7 +108,15 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 		Args:      cmderrors.TransformToValidationError(cobra.MinimumNArgs(0)),
 		ValidArgs: []string{"orgs", "workspaces"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunLs(t, loginLsStore, args, org, showAll)
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			selector, err := labels.ParseSelector(selectorFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunLs(t, loginLsStore, args, org, showAll, *output, selector)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -123,6 +145,8 @@ func NewCmdLs(t *terminal.Terminal, loginLsStore LsStore, noLoginLsStore LsStore
 	}
 
 	cmd.Flags().BoolVar(&showAll, "all", false, "show all workspaces in org")
+	cmd.Flags().StringVar(&outputFlag, "output", config.GlobalConfig.GetDefaultOutput(), terminal.OutputFlagUsage)
+	cmd.Flags().StringVarP(&selectorFlag, "selector", "l", "", labels.SelectorFlagUsage)
 
 	return cmd
 }
@@ -136,7 +160,7 @@ func getOrgForRunLs(lsStore LsStore, orgflag string) (*entity.Organization, erro
 			return nil, breverrors.WrapAndTrace(err)
 		}
 		if len(orgs) == 0 {
-			return nil, breverrors.NewValidationError(fmt.Sprintf("no org found with name %s", orgflag))
+			return nil, noOrgError(orgflag)
 		} else if len(orgs) > 1 {
 			return nil, breverrors.NewValidationError(fmt.Sprintf("more than one org found with name %s", orgflag))
 		}
@@ -156,8 +180,17 @@ func getOrgForRunLs(lsStore LsStore, orgflag string) (*entity.Organization, erro
 	return org, nil
 }
 
-func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool) error {
-	ls := NewLs(lsStore, t)
+// noOrgError points out -o is --org when the org looks like an output format
+func noOrgError(orgflag string) error {
+	msg := fmt.Sprintf("no org found with name %s", orgflag)
+	if _, err := terminal.ParseOutput(orgflag); err == nil {
+		msg += fmt.Sprintf(", -o is --org, for the output format use --output %s", orgflag)
+	}
+	return breverrors.NewValidationError(msg)
+}
+
+func RunLs(t *terminal.Terminal, lsStore LsStore, args []string, orgflag string, showAll bool, output terminal.Output, selector labels.Selector) error {
+	ls := NewLs(lsStore, t, output, selector)
 	user, err := lsStore.GetCurrentUser()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -221,12 +254,16 @@ func handleLsArg(ls *Ls, arg string, user *entity.User, org *entity.Organization
 type Ls struct {
 	lsStore  LsStore
 	terminal *terminal.Terminal
+	output   terminal.Output
+	selector labels.Selector
 }
 
-func NewLs(lsStore LsStore, terminal *terminal.Terminal) *Ls {
+func NewLs(lsStore LsStore, terminal *terminal.Terminal, output terminal.Output, selector labels.Selector) *Ls {
 	return &Ls{
 		lsStore:  lsStore,
 		terminal: terminal,
+		output:   output,
+		selector: selector,
 	}
 }
 
@@ -235,7 +272,7 @@ func (ls Ls) RunOrgs() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if len(orgs) == 0 {
+	if len(orgs) == 0 && !ls.output.IsStructured() {
 		ls.terminal.Vprint(ls.terminal.Yellow(fmt.Sprintf("You don't have any orgs. Create one! %s", config.ConsoleBaseURL)))
 		return nil
 	}
@@ -244,6 +281,13 @@ func (ls Ls) RunOrgs() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.output.IsStructured() {
+		err = ls.terminal.PrintStructured(ls.output, utilities.NewOrganizationOutputs(orgs, defaultOrg))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	ls.terminal.Vprint(ls.terminal.Yellow("Your organizations:"))
 	displayOrgTable(ls.terminal, orgs, defaultOrg)
 	if len(orgs) > 1 {
@@ -304,7 +348,9 @@ func (ls Ls) ShowUserWorkspaces(org *entity.Organization, otherOrgs []entity.Org
 }
 
 func (ls Ls) displayWorkspacesAndHelp(org *entity.Organization, otherOrgs []entity.Organization, userWorkspaces []entity.Workspace, allWorkspaces []entity.Workspace, userID string) {
-	if len(userWorkspaces) == 0 {
+	if len(userWorkspaces) == 0 && !ls.selector.Empty() {
+		ls.terminal.Vprint(ls.terminal.Yellow("No instances in org %s match %s\n", org.Name, ls.selector))
+	} else if len(userWorkspaces) == 0 {
 		ls.terminal.Vprint(ls.terminal.Yellow("No instances in org %s\n", org.Name))
 		if len(allWorkspaces) > 0 {
 			ls.terminal.Vprintf(ls.terminal.Green("See teammates' instances:\n"))
@@ -319,7 +365,7 @@ func (ls Ls) displayWorkspacesAndHelp(org *entity.Organization, otherOrgs []enti
 		}
 	} else {
 		ls.terminal.Vprintf("You have %d instances in Org "+ls.terminal.Yellow(org.Name)+"\n", len(userWorkspaces))
-		displayWorkspacesTable(ls.terminal, userWorkspaces, userID)
+		displayWorkspacesTable(ls.terminal, userWorkspaces, userID, ls.output.IsWide())
 
 		fmt.Print("\n")
 
@@ -340,7 +386,7 @@ func (ls Ls) displayWorkspacesAndHelp(org *entity.Organization, otherOrgs []enti
 func displayLsResetBreadCrumb(t *terminal.Terminal, workspaces []entity.Workspace) {
 	foundAResettableWorkspace := false
 	for _, w := range workspaces {
-		if w.Status == entity.Failure || getWorkspaceDisplayStatus(w) == entity.Unhealthy {
+		if w.Status == entity.Failure || utilities.GetWorkspaceDisplayStatus(w) == entity.Unhealthy {
 			if !foundAResettableWorkspace {
 				t.Vprintf(t.Red("Reset unhealthy or failed instance:\n"))
 			}
@@ -358,6 +404,19 @@ func (ls Ls) RunWorkspaces(org *entity.Organization, user *entity.User, showAll
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	allWorkspaces = ls.selector.Filter(allWorkspaces)
+
+	if ls.output.IsStructured() {
+		workspaces := allWorkspaces
+		if !showAll {
+			workspaces = store.FilterForUserWorkspaces(allWorkspaces, user.ID)
+		}
+		err = ls.terminal.PrintStructured(ls.output, utilities.NewWorkspaceOutputs(workspaces, user.ID))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 
 	orgs, err := ls.lsStore.GetOrganizations(nil)
 	if err != nil {
@@ -382,6 +441,13 @@ func (ls Ls) RunHosts(org *entity.Organization) error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if ls.output.IsStructured() {
+		err = ls.terminal.PrintStructured(ls.output, utilities.NewWorkspaceOutputs(workspaces, user.ID))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	for _, workspace := range workspaces {
 		fmt.Println(workspace.GetNodeIdentifierForVPN())
 	}
@@ -416,7 +482,7 @@ func getBrevTableOptions() table.Options {
 	return options
 }
 
-func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace, userID string) {
+func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace, userID string, wide bool) {
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
 	ta.Style().Options = getBrevTableOptions()
@@ -424,29 +490,43 @@ func displayWorkspacesTable(t *terminal.Terminal, workspaces []entity.Workspace,
 	if enableSSHCol {
 		header = table.Row{"Name", "Status", "SSH", "ID", "Machine"}
 	}
+	if wide {
+		header = table.Row{"Name", "Status", "Health", "ID", "Machine", "SSH", "DNS", "Created By"}
+	}
+	showLabels := wide || anyLabels(workspaces)
+	if showLabels {
+		header = append(header, "Labels")
+	}
 	ta.AppendHeader(header)
 	for _, w := range workspaces {
 		isShared := ""
 		if w.IsShared(userID) {
 			isShared = "(shared)"
 		}
-		status := getWorkspaceDisplayStatus(w)
+		status := utilities.GetWorkspaceDisplayStatus(w)
 		instanceString := utilities.GetInstanceString(w)
 		workspaceRow := []table.Row{{fmt.Sprintf("%s %s", w.Name, isShared), getStatusColoredText(t, status), w.ID, instanceString}}
 		if enableSSHCol {
 			workspaceRow = []table.Row{{w.Name, getStatusColoredText(t, status), w.GetLocalIdentifier(), w.ID, instanceString}}
 		}
+		if wide {
+			workspaceRow = []table.Row{{fmt.Sprintf("%s %s", w.Name, isShared), getStatusColoredText(t, status), w.HealthStatus, w.ID, instanceString, w.GetLocalIdentifier(), w.GetHostname(), w.CreatedByUserID}}
+		}
+		if showLabels {
+			workspaceRow[0] = append(workspaceRow[0], labels.Format(w.Labels))
+		}
 		ta.AppendRows(workspaceRow)
 	}
 	ta.Render()
 }
 
-func getWorkspaceDisplayStatus(w entity.Workspace) string {
-	status := w.Status
-	if w.Status == entity.Running && w.HealthStatus == entity.Unhealthy {
-		status = w.HealthStatus
+func anyLabels(workspaces []entity.Workspace) bool {
+	for _, w := range workspaces {
+		if len(w.Labels) > 0 {
+			return true
+		}
 	}
-	return status
+	return false
 }
 
 func displayOrgTable(t *terminal.Terminal, orgs []entity.Organization, currentOrg *entity.Organization) {
@@ -1 +1,22 @@
 package ls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoOrgError(t *testing.T) {
+	tests := []struct {
+		org  string
+		want string
+	}{
+		{"my-org", "no org found with name my-org"},
+		{"json", "no org found with name json, -o is --org, for the output format use --output json"},
+		{"custom-columns=NAME:.name", "no org found with name custom-columns=NAME:.name, -o is --org, for the output format use --output custom-columns=NAME:.name"},
+		{"jsn", "no org found with name jsn"},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, noOrgError(tt.org), tt.want, tt.org)
+	}
+}
@@ -65,15 +65,12 @@ func NewCmdNotebook(store NotebookStore, _ *terminal.Terminal) *cobra.Command {
 
 			hello.TypeItToMeUnskippable27("\nClick here to go to your Jupyter notebook:\n\t 👉" + urlType("http://localhost:8888") + "👈\n\n\n")
 
-			// Port forward on 8888
+			// Port forward on 8888, runs until ctrl-c
 			err2 := portforward.RunPortforward(store, args[0], "8888:8888", false)
 			if err2 != nil {
 				return breverrors.WrapAndTrace(err2)
 			}
 
-			// Print out a link for the user
-			hello.TypeItToMeUnskippable27("Your notebook is accessible at: http://localhost:8888")
-
 			return nil
 		},
 	}
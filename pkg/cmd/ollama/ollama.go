@@ -43,6 +43,7 @@ type OllamaStore interface {
 	CreateWorkspace(organizationID string, options *store.CreateWorkspacesOptions) (*entity.Workspace, error)
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	BuildVerbContainer(workspaceID string, verbYaml string) (*store.BuildVerbRes, error)
+	SaveVerbLogPath(workspaceID string, logFilePath string) error
 	ModifyPublicity(workspace *entity.Workspace, applicationName string, publicity bool) (*entity.Tunnel, error)
 }
 
@@ -197,10 +198,18 @@ func runOllamaWorkspace(t *terminal.Terminal, opts RunOptions, ollamaStore Ollam
 
 	s.Suffix = " Building the Ollama container. Hang tight 🤙"
 
-	_, err = verbBuildRes.Await()
+	buildRes, err := verbBuildRes.Await()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if buildRes.LogFilePath != "" {
+		// the api only hands out the path here, keep it for brev logs --verb.
+		// the build has started either way so a failed write is only a warning
+		errr := ollamaStore.SaveVerbLogPath(w.ID, buildRes.LogFilePath)
+		if errr != nil {
+			t.Vprint(t.Yellow("couldn't save the build log path, brev logs --verb won't find it: %v\n", errr))
+		}
+	}
 
 	var vstatus bool
 	// TODO: 15 min for now because the image is not cached and takes a while to build. Remove this when the image is cached
@@ -0,0 +1,314 @@
+package open
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alessio/shellescape"
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	uutil "github.com/brevdev/brev-cli/pkg/util"
+	"github.com/google/shlex"
+	"github.com/pkg/browser"
+)
+
+const (
+	editorKey        = "editor"
+	editorCommandKey = "editor-command"
+	customEditorName = "custom"
+	defaultEditor    = "code"
+)
+
+// Target is where an editor connects: an instance folder over ssh
+type Target struct {
+	SSHAlias string // host in the brev ssh config
+	Path     string // folder on the instance
+	// for editors that keep their own ssh config, like gateway
+	Hostname string
+	Port     int
+	User     string
+}
+
+// Editor opens a Target
+type Editor interface {
+	Name() string
+	Open(t *terminal.Terminal, target Target) error
+}
+
+// EditorSettings is the saved default editor, Command is only for custom
+type EditorSettings struct {
+	Editor  string
+	Command string
+}
+
+// the editors --editor takes, terminal editors run over ssh in this terminal
+var editors = map[string]func(settings EditorSettings, store vscodePathStore) (Editor, error){
+	"code": func(_ EditorSettings, store vscodePathStore) (Editor, error) {
+		return vscodeEditor{windowsPaths: getWindowsVsCodePaths(store)}, nil
+	},
+	"cursor": func(_ EditorSettings, store vscodePathStore) (Editor, error) {
+		return cursorEditor{windowsPaths: getWindowsCursorPaths(store)}, nil
+	},
+	"gateway": func(EditorSettings, vscodePathStore) (Editor, error) { return gatewayEditor{}, nil },
+	"zed":     func(EditorSettings, vscodePathStore) (Editor, error) { return zedEditor{}, nil },
+	"nvim":    func(EditorSettings, vscodePathStore) (Editor, error) { return terminalEditor{command: "nvim"}, nil },
+	"vim":     func(EditorSettings, vscodePathStore) (Editor, error) { return terminalEditor{command: "vim"}, nil },
+	"emacs": func(EditorSettings, vscodePathStore) (Editor, error) {
+		return terminalEditor{command: "emacs -nw"}, nil
+	},
+	"helix": func(EditorSettings, vscodePathStore) (Editor, error) { return terminalEditor{command: "hx"}, nil },
+	customEditorName: func(settings EditorSettings, _ vscodePathStore) (Editor, error) {
+		return newCustomEditor(settings.Command)
+	},
+}
+
+var editorAliases = map[string]string{
+	"vscode":    "code",
+	"jetbrains": "gateway",
+	"neovim":    "nvim",
+	"hx":        "helix",
+}
+
+// EditorNames are the names --editor takes
+func EditorNames() []string {
+	names := []string{}
+	for name := range editors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEditor looks up an editor by name, a custom editor runs settings.Command
+func NewEditor(settings EditorSettings, store vscodePathStore) (Editor, error) {
+	name := strings.ToLower(settings.Editor)
+	if alias, ok := editorAliases[name]; ok {
+		name = alias
+	}
+	newEditor, ok := editors[name]
+	if !ok {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unknown editor %s, expected one of: %s", settings.Editor, strings.Join(EditorNames(), ", ")))
+	}
+	editor, err := newEditor(settings, store)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return editor, nil
+}
+
+// LoadEditorSettings reads the editor from brev config, VS Code when it has none
+func LoadEditorSettings(brevHome string) (EditorSettings, error) {
+	layers := config.DefaultLayers()
+	layers.UserFile = config.UserFilePath(brevHome)
+	editor, err := layers.Resolve(editorKey)
+	if err != nil {
+		return EditorSettings{Editor: defaultEditor}, breverrors.WrapAndTrace(err)
+	}
+	if editor.Value == "" {
+		return EditorSettings{Editor: defaultEditor}, nil
+	}
+	command, err := layers.Resolve(editorCommandKey)
+	if err != nil {
+		return EditorSettings{Editor: defaultEditor}, breverrors.WrapAndTrace(err)
+	}
+	return EditorSettings{Editor: editor.Value, Command: command.Value}, nil
+}
+
+// SaveEditorSettings saves the default editor to the user's brev config
+func SaveEditorSettings(brevHome string, settings EditorSettings) error {
+	path := config.UserFilePath(brevHome)
+	err := config.SetInFile(path, editorKey, settings.Editor)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if settings.Command == "" {
+		err = config.UnsetInFile(path, editorCommandKey)
+	} else {
+		err = config.SetInFile(path, editorCommandKey, settings.Command)
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func remoteFolderURI(target Target) string {
+	return fmt.Sprintf("vscode-remote://ssh-remote+%s%s", target.SSHAlias, target.Path)
+}
+
+type vscodeEditor struct {
+	windowsPaths []string
+}
+
+func (vscodeEditor) Name() string { return "VS Code" }
+
+func (e vscodeEditor) Open(t *terminal.Terminal, target Target) error {
+	tryToInstallExtensions(t, []string{"ms-vscode-remote.remote-ssh", "ms-toolsai.jupyter-keymap", "ms-python.python"})
+	folderURI := shellescape.QuoteCommand([]string{remoteFolderURI(target)})
+	_, err := uutil.TryRunVsCodeCommand([]string{"--folder-uri", folderURI}, e.windowsPaths...)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+var commonCursorPaths = []string{
+	"cursor",
+	"/Applications/Cursor.app/Contents/Resources/app/bin/cursor",
+}
+
+func getWindowsCursorPaths(store vscodePathStore) []string {
+	wd, _ := store.GetWindowsDir()
+	return []string{fmt.Sprintf("%s/AppData/Local/Programs/cursor/resources/app/bin/cursor", wd)}
+}
+
+// cursorEditor is VS Code under another name, with remote ssh built in
+type cursorEditor struct {
+	windowsPaths []string
+}
+
+func (cursorEditor) Name() string { return "Cursor" }
+
+func (e cursorEditor) Open(_ *terminal.Terminal, target Target) error {
+	return runFirstFound(append(commonCursorPaths, e.windowsPaths...), "--folder-uri", remoteFolderURI(target))
+}
+
+// gatewayEditor opens JetBrains Gateway with a deep link to the same host,
+// port and user the brev Gateway ssh config has for the instance
+type gatewayEditor struct{}
+
+func (gatewayEditor) Name() string { return "JetBrains Gateway" }
+
+func (gatewayEditor) Open(_ *terminal.Terminal, target Target) error {
+	err := browser.OpenURL(gatewayURL(target))
+	if err != nil {
+		return breverrors.Wrap(err, "couldn't open JetBrains Gateway, is it installed?")
+	}
+	return nil
+}
+
+func gatewayURL(target Target) string {
+	host := target.Hostname
+	if host == "" || host == "-" {
+		host = target.SSHAlias
+	}
+	params := []string{
+		"type=ssh",
+		"deploy=false",
+		"host=" + queryEscape(host),
+		"port=" + strconv.Itoa(target.Port),
+		"user=" + queryEscape(target.User),
+		"projectPath=" + queryEscape(target.Path),
+	}
+	return "jetbrains-gateway://connect#" + strings.Join(params, "&")
+}
+
+// queryEscape escapes spaces as %20, a + in a fragment isn't always a space
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+var commonZedPaths = []string{
+	"zed",
+	"zeditor",
+	"/Applications/Zed.app/Contents/MacOS/cli",
+}
+
+// zedEditor uses zed's remote development, which connects with the ssh config
+type zedEditor struct{}
+
+func (zedEditor) Name() string { return "Zed" }
+
+func (zedEditor) Open(_ *terminal.Terminal, target Target) error {
+	return runFirstFound(commonZedPaths, fmt.Sprintf("ssh://%s/%s", target.SSHAlias, strings.TrimPrefix(target.Path, "/")))
+}
+
+// terminalEditor runs an editor on the instance over ssh, like brev shell
+type terminalEditor struct {
+	command string
+}
+
+func (e terminalEditor) Name() string { return e.command }
+
+func (e terminalEditor) Open(_ *terminal.Terminal, target Target) error {
+	remote := fmt.Sprintf("cd %s && exec %s .", shellescape.Quote(target.Path), e.command)
+	cmd := exec.Command("ssh", "-t", target.SSHAlias, remote) //nolint:gosec // the alias and editor are the user's
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// customEditor runs a user's command template, each argument can use
+// {alias}, {path}, {host}, {port} and {user}
+type customEditor struct {
+	args []string
+}
+
+func newCustomEditor(template string) (Editor, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, breverrors.NewValidationError("the custom editor needs a command, ex: --editor-cmd 'subl --remote {alias}:{path}'")
+	}
+	args, err := shlex.Split(template)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("can't parse editor command %q: %v", template, err))
+	}
+	return customEditor{args: args}, nil
+}
+
+func (e customEditor) Name() string { return e.args[0] }
+
+func (e customEditor) Open(_ *terminal.Terminal, target Target) error {
+	args := e.Command(target)
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // the user's own command
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Command is the template filled in for target
+func (e customEditor) Command(target Target) []string {
+	replacer := strings.NewReplacer(
+		"{alias}", target.SSHAlias,
+		"{path}", target.Path,
+		"{host}", target.Hostname,
+		"{port}", strconv.Itoa(target.Port),
+		"{user}", target.User,
+	)
+	args := []string{}
+	for _, a := range e.args {
+		args = append(args, replacer.Replace(a))
+	}
+	return args
+}
+
+// runFirstFound runs the first of paths that exists with args
+func runFirstFound(paths []string, args ...string) error {
+	for _, p := range paths {
+		bin, err := exec.LookPath(p)
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command(bin, args...).CombinedOutput() //nolint:gosec // known editor binaries
+		if err != nil {
+			return breverrors.Wrap(err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return breverrors.NewValidationError(fmt.Sprintf("couldn't find %s, add it to your PATH", paths[0]))
+}
@@ -0,0 +1,66 @@
+package open
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWindowsDirStore struct{}
+
+func (fakeWindowsDirStore) GetWindowsDir() (string, error) {
+	return "/mnt/c/Users/me", nil
+}
+
+func TestNewEditor(t *testing.T) {
+	for name, want := range map[string]string{
+		"code":      "VS Code",
+		"VSCode":    "VS Code",
+		"cursor":    "Cursor",
+		"jetbrains": "JetBrains Gateway",
+		"zed":       "Zed",
+		"neovim":    "nvim",
+		"hx":        "hx",
+	} {
+		editor, err := NewEditor(EditorSettings{Editor: name}, fakeWindowsDirStore{})
+		require.NoError(t, err, name)
+		assert.Equal(t, want, editor.Name(), name)
+	}
+
+	_, err := NewEditor(EditorSettings{Editor: "notepad"}, fakeWindowsDirStore{})
+	assert.ErrorContains(t, err, "unknown editor notepad")
+	_, err = NewEditor(EditorSettings{Editor: "custom"}, fakeWindowsDirStore{})
+	assert.ErrorContains(t, err, "needs a command")
+}
+
+func TestEditorSettings(t *testing.T) {
+	home := t.TempDir()
+	settings, err := LoadEditorSettings(home)
+	require.NoError(t, err)
+	assert.Equal(t, EditorSettings{Editor: "code"}, settings)
+
+	saved := EditorSettings{Editor: "custom", Command: "subl {alias}:{path}"}
+	require.NoError(t, SaveEditorSettings(home, saved))
+	settings, err = LoadEditorSettings(home)
+	require.NoError(t, err)
+	assert.Equal(t, saved, settings)
+	assert.FileExists(t, filepath.Join(home, "config.yaml"))
+}
+
+func TestCustomEditorCommand(t *testing.T) {
+	editor, err := newCustomEditor(`my-ide --remote "{user}@{host}:{port}" '{path}' --name {alias}`)
+	require.NoError(t, err)
+	target := Target{SSHAlias: "my-app", Path: "/home/ubuntu/my project", Hostname: "my-app.brev.sh", Port: 2222, User: "ubuntu"}
+
+	assert.Equal(t, []string{"my-ide", "--remote", "ubuntu@my-app.brev.sh:2222", "/home/ubuntu/my project", "--name", "my-app"}, editor.(customEditor).Command(target))
+}
+
+func TestGatewayURL(t *testing.T) {
+	target := Target{SSHAlias: "my-app", Path: "/home/ubuntu/my app", Hostname: "my-app.brev.sh", Port: 22, User: "ubuntu"}
+	assert.Equal(t, "jetbrains-gateway://connect#type=ssh&deploy=false&host=my-app.brev.sh&port=22&user=ubuntu&projectPath=%2Fhome%2Fubuntu%2Fmy%20app", gatewayURL(target))
+
+	target.Hostname = "-"
+	assert.Contains(t, gatewayURL(target), "host=my-app&")
+}
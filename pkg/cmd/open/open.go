@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/alessio/shellescape"
 	"github.com/brevdev/brev-cli/pkg/analytics"
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
@@ -19,6 +18,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	uutil "github.com/brevdev/brev-cli/pkg/util"
+	"github.com/brevdev/brev-cli/pkg/wait"
 	"github.com/brevdev/brev-cli/pkg/writeconnectionevent"
 	"github.com/briandowns/spinner"
 	"github.com/hashicorp/go-multierror"
@@ -28,8 +28,12 @@ import (
 )
 
 var (
-	openLong    = "[command in beta] This will open VS Code SSH-ed in to your workspace. You must have 'code' installed in your path."
-	openExample = "brev open workspace_id_or_name\nbrev open my-app\nbrev open h9fp5vxwe"
+	openLong    = "[command in beta] This will open an editor SSH-ed in to your workspace, VS Code unless --editor or a saved default says otherwise. The editor must be installed and in your path."
+	openExample = `brev open workspace_id_or_name
+brev open my-app
+brev open my-app --editor zed
+brev open my-app --editor custom --editor-cmd 'subl --remote {alias}:{path}'
+brev open --editor gateway --set-default`
 )
 
 type OpenStore interface {
@@ -43,6 +47,7 @@ type OpenStore interface {
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetWindowsDir() (string, error)
 	IsWorkspace() (bool, error)
+	GetBrevHomePath() (string, error)
 }
 
 func NewCmdOpen(t *terminal.Terminal, store OpenStore, noLoginStartStore OpenStore) *cobra.Command {
@@ -50,29 +55,51 @@ func NewCmdOpen(t *terminal.Terminal, store OpenStore, noLoginStartStore OpenSto
 	var waitForSetupToFinish bool
 	var directory string
 	var host bool
+	var editorName string
+	var editorCmd string
+	var setDefault bool
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"ssh": ""},
 		Use:                   "open",
 		DisableFlagsInUseLine: true,
-		Short:                 "[beta] open VSCode or Cursor to your workspace",
+		Short:                 "[beta] open VS Code, Cursor, Gateway, Zed or another editor to your workspace",
 		Long:                  openLong,
 		Example:               openExample,
-		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		Args:                  cmderrors.TransformToValidationError(cobra.RangeArgs(0, 1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			setupDoneString := "------ Git repo cloned ------"
 			if waitForSetupToFinish {
 				setupDoneString = "------ Done running execs ------"
 			}
-			err := runOpenCommand(t, store, args[0], setupDoneString, directory, host)
+			if openWithCursor {
+				editorName = "cursor"
+			}
+			editor, err := resolveEditor(t, store, editorName, editorCmd, setDefault)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if len(args) == 0 {
+				if setDefault {
+					return nil
+				}
+				return breverrors.NewValidationError("expected the name or id of an instance to open")
+			}
+			err = runOpenCommand(t, store, args[0], setupDoneString, directory, host, editor)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
-	cmd.Flags().BoolVarP(&openWithCursor, "cursor", "c", false, "open cursor instead of VS Code")
+	cmd.Flags().BoolVarP(&openWithCursor, "cursor", "c", false, "open cursor instead of VS Code, same as --editor cursor")
+	cmd.Flags().StringVarP(&editorName, "editor", "e", "", fmt.Sprintf("editor to open: %s", strings.Join(EditorNames(), ", ")))
+	cmd.Flags().StringVar(&editorCmd, "editor-cmd", "", "command for --editor custom, arguments can use {alias}, {path}, {host}, {port} and {user}")
+	cmd.Flags().BoolVar(&setDefault, "set-default", false, "save --editor as the editor brev open uses by default")
+	_ = cmd.RegisterFlagCompletionFunc("editor", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return EditorNames(), cobra.ShellCompDirectiveNoFileComp
+	})
 	cmd.Flags().BoolVarP(&host, "host", "", false, "ssh into the host machine instead of the container")
 	cmd.Flags().BoolVarP(&waitForSetupToFinish, "wait", "w", false, "wait for setup to finish")
 	cmd.Flags().StringVarP(&directory, "dir", "d", "", "directory to open")
@@ -80,8 +107,41 @@ func NewCmdOpen(t *terminal.Terminal, store OpenStore, noLoginStartStore OpenSto
 	return cmd
 }
 
+// resolveEditor picks the editor from the flags or the saved default,
+// saving the flags as the default when asked
+func resolveEditor(t *terminal.Terminal, tstore OpenStore, editorName string, editorCmd string, setDefault bool) (Editor, error) {
+	home, err := tstore.GetBrevHomePath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	settings, err := LoadEditorSettings(home)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if editorCmd != "" && editorName == "" {
+		editorName = customEditorName
+	}
+	if editorName != "" {
+		settings = EditorSettings{Editor: editorName, Command: editorCmd}
+	} else if setDefault {
+		return nil, breverrors.NewValidationError("--set-default needs --editor")
+	}
+	editor, err := NewEditor(settings, tstore)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if setDefault {
+		err = SaveEditorSettings(home, settings)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf("brev open will use %s by default\n", editor.Name())
+	}
+	return editor, nil
+}
+
 // Fetch workspace info, then open code editor
-func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, setupDoneString string, directory string, host bool) error { //nolint:funlen // define brev command
+func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, setupDoneString string, directory string, host bool, editor Editor) error { //nolint:funlen // define brev command
 	// todo check if workspace is stopped and start if it if it is stopped
 	fmt.Println("finding your instance...")
 	res := refresh.RunRefreshAsync(tstore)
@@ -115,9 +175,18 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, s
 	}
 
 	localIdentifier := workspace.GetLocalIdentifier()
+	target := Target{
+		Path:     projPath,
+		Hostname: workspace.GetHostname(),
+		Port:     workspace.GetSSHPort(),
+		User:     workspace.GetUsername(),
+	}
 	if host {
 		localIdentifier = workspace.GetHostIdentifier()
+		target.Port = workspace.GetHostSSHPort()
+		target.User = workspace.GetHostSSHUser()
 	}
+	target.SSHAlias = string(localIdentifier)
 
 	err = res.Await()
 	if err != nil {
@@ -132,7 +201,7 @@ func runOpenCommand(t *terminal.Terminal, tstore OpenStore, wsIDOrName string, s
 	// legacy environments wont support this and cause errrors,
 	// but we don't want to block the user from using vscode
 	_ = writeconnectionevent.WriteWCEOnEnv(tstore, string(localIdentifier))
-	err = openVsCodeWithSSH(t, string(localIdentifier), projPath, tstore, setupDoneString)
+	err = openEditorWithSSH(t, editor, target, tstore)
 	if err != nil {
 		if strings.Contains(err.Error(), `"code": executable file not found in $PATH`) {
 			errMsg := "code\": executable file not found in $PATH\n\nadd 'code' to your $PATH to open VS Code from the terminal\n\texport PATH=\"/Applications/Visual Studio Code.app/Contents/Resources/app/bin:$PATH\""
@@ -176,22 +245,20 @@ func pushOpenAnalytics(tstore OpenStore, workspace *entity.Workspace) error {
 
 func pollUntil(t *terminal.Terminal, wsid string, state string, openStore OpenStore) error {
 	s := t.NewSpinner()
-	isReady := false
 	s.Suffix = " hang tight 🤙"
 	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := openStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is currently " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  workspace is currently " + strings.ToLower(status)
+	}
+	_, err := wait.Until(openStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
 	}
+	s.Suffix = "Workspace is ready!"
+	s.Stop()
 	return nil
 }
 
@@ -232,13 +299,12 @@ func tryToInstallExtensions(
 	}
 }
 
-// Opens code editor. Attempts to install code in path if not installed already
-func openVsCodeWithSSH(
+// Opens the editor once the instance takes ssh connections
+func openEditorWithSSH(
 	t *terminal.Terminal,
-	sshAlias string,
-	path string,
+	editor Editor,
+	target Target,
 	tstore OpenStore,
-	_ string,
 ) error {
 	// infinite for loop:
 	res := refresh.RunRefreshAsync(tstore)
@@ -249,23 +315,22 @@ func openVsCodeWithSSH(
 	s := t.NewSpinner()
 	s.Start()
 	s.Suffix = "  checking if your instance is ready..."
-	err = waitForSSHToBeAvailable(t, s, sshAlias)
+	err = waitForSSHToBeAvailable(t, s, target.SSHAlias)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
 	// todo: add it here
-	s.Suffix = " Instance is ready. Opening VS Code 🤙"
+	s.Suffix = fmt.Sprintf(" Instance is ready. Opening %s 🤙", editor.Name())
 	time.Sleep(250 * time.Millisecond)
 	s.Stop()
 	t.Vprintf("\n")
 
-	tryToInstallExtensions(t, []string{"ms-vscode-remote.remote-ssh", "ms-toolsai.jupyter-keymap", "ms-python.python"})
-
-	err = openVsCode(sshAlias, path, tstore)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
+	openErr := editor.Open(t, target)
+	if openErr == nil {
+		return nil
 	}
+	err = openErr
 
 	// check if we are in a brev environment, if so transform the error message
 	// to indicate that the user should run brev open locally instead of in
@@ -289,7 +354,10 @@ func openVsCodeWithSSH(
 		if strings.Contains(err.Error(), "you are in a remote brev instance;") {
 			return breverrors.WrapAndTrace(err)
 		}
-		return breverrors.WrapAndTrace(fmt.Errorf(t.Red("couldn't open VSCode, try adding it to PATH (you can do this in VSCode by running CMD-SHIFT-P and typing 'install code in path')\n")))
+		if _, ok := editor.(vscodeEditor); ok {
+			return breverrors.WrapAndTrace(fmt.Errorf(t.Red("couldn't open VSCode, try adding it to PATH (you can do this in VSCode by running CMD-SHIFT-P and typing 'install code in path')\n")))
+		}
+		return breverrors.Wrap(openErr, fmt.Sprintf("couldn't open %s", editor.Name()))
 	} else {
 		return nil
 	}
@@ -329,15 +397,3 @@ func getWindowsVsCodePaths(store vscodePathStore) []string {
 	paths := append([]string{}, fmt.Sprintf("%s/AppData/Local/Programs/Microsoft VS Code/Code.exe", wd), fmt.Sprintf("%s/AppData/Local/Programs/Microsoft VS Code/bin/code", wd))
 	return paths
 }
-
-func openVsCode(sshAlias string, path string, store OpenStore) error {
-	vscodeString := fmt.Sprintf("vscode-remote://ssh-remote+%s%s", sshAlias, path)
-	vscodeString = shellescape.QuoteCommand([]string{vscodeString})
-
-	windowsPaths := getWindowsVsCodePaths(store)
-	_, err := uutil.TryRunVsCodeCommand([]string{"--folder-uri", vscodeString}, windowsPaths...)
-	if err != nil {
-		return breverrors.WrapAndTrace(err)
-	}
-	return nil
-}
@@ -7,6 +7,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/invite"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -54,7 +55,7 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 		Args: cmderrors.TransformToValidationError(cobra.NoArgs),
 		// ValidArgs: []string{"new", "ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunOrgs(t, orgcmdStore)
+			err := RunOrgs(t, orgcmdStore, terminal.Output{Format: terminal.TableOutput})
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
@@ -69,11 +70,18 @@ func NewCmdOrg(t *terminal.Terminal, orgcmdStore OrgCmdStore, noorgcmdStore OrgC
 	return cmd
 }
 
-func RunOrgs(t *terminal.Terminal, store OrgCmdStore) error {
+func RunOrgs(t *terminal.Terminal, store OrgCmdStore, output terminal.Output) error {
 	orgs, err := store.GetOrganizations(nil)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if output.IsStructured() {
+		err = printOrgsStructured(t, store, orgs, output)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 	if len(orgs) == 0 {
 		t.Vprint(t.Yellow(fmt.Sprintf("You don't have any orgs. Create one! %s", config.ConsoleBaseURL)))
 		return nil
@@ -96,6 +104,18 @@ func RunOrgs(t *terminal.Terminal, store OrgCmdStore) error {
 	return nil
 }
 
+func printOrgsStructured(t *terminal.Terminal, store OrgCmdStore, orgs []entity.Organization, output terminal.Output) error {
+	defaultOrg, err := store.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = t.PrintStructured(output, util.NewOrganizationOutputs(orgs, defaultOrg))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func getBrevTableOptions() table.Options {
 	options := table.OptionsDefault
 	options.DrawBorder = false
@@ -0,0 +1,225 @@
+package portforward
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+func NewCmdPortForwardLs(t *terminal.Terminal, pfStore PortforwardStore) *cobra.Command {
+	var outputFlag string
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Short:   "List running port-forwards",
+		Example: "brev port-forward ls",
+		Args:    cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunPortForwardLs(t, pfStore, *output)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", config.GlobalConfig.GetDefaultOutput(), terminal.OutputFlagUsage)
+	return cmd
+}
+
+func RunPortForwardLs(t *terminal.Terminal, pfStore PortforwardStore, output terminal.Output) error {
+	home, err := pfStore.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	forwards, err := Registry{Home: home}.ListForwards()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if output.IsStructured() {
+		err = t.PrintStructured(output, forwards)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
+	if len(forwards) == 0 {
+		t.Vprintf("no port-forwards running, start one with: brev port-forward <instance> -p local_port:remote_port -d\n")
+		return nil
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = getBrevTableOptions()
+	ta.AppendHeader(table.Row{"ID", "INSTANCE", "PORTS", "PROFILE", "STATUS", "UPTIME", "PID"})
+	for _, f := range forwards {
+		status := t.Green(f.Status)
+		if f.Status != StatusConnected {
+			status = t.Yellow(f.Status)
+		}
+		ta.AppendRow(table.Row{f.ID, f.Instance, f.PortsString(), f.Profile, status, time.Since(f.Started).Round(time.Second).String(), f.PID})
+	}
+	ta.Render()
+	return nil
+}
+
+func NewCmdPortForwardStop(t *terminal.Terminal, pfStore PortforwardStore) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop running port-forwards",
+		Long:  "Stop running port-forwards by id, profile or instance name",
+		Example: `
+  brev port-forward stop 1a2b3c4d
+  brev port-forward stop my-instance
+  brev port-forward stop --all`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !all && len(args) == 0 {
+				return breverrors.NewValidationError("please provide a port-forward id, profile or instance, or --all")
+			}
+			err := RunPortForwardStop(t, pfStore, args, all)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "stop all port-forwards")
+	return cmd
+}
+
+func RunPortForwardStop(t *terminal.Terminal, pfStore PortforwardStore, targets []string, all bool) error {
+	home, err := pfStore.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	forwards, err := Registry{Home: home}.ListForwards()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	toStop := MatchForwards(forwards, targets, all)
+	if len(toStop) == 0 {
+		return breverrors.NewValidationError(fmt.Sprintf("no running port-forward matches %s, see: brev port-forward ls", strings.Join(targets, ", ")))
+	}
+	for _, f := range toStop {
+		err = StopForward(f)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf(t.Green("stopped %s (%s %s)\n", f.ID, f.Instance, f.PortsString()))
+	}
+	return nil
+}
+
+// MatchForwards picks the forwards whose id, profile or instance is in targets
+func MatchForwards(forwards []Forward, targets []string, all bool) []Forward {
+	matched := []Forward{}
+	for _, f := range forwards {
+		if all || matchesForward(f, targets) {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+func matchesForward(f Forward, targets []string) bool {
+	for _, target := range targets {
+		if target == f.ID || target == f.Profile || target == f.Instance {
+			return true
+		}
+	}
+	return false
+}
+
+func NewCmdPortForwardProfile(t *terminal.Terminal, pfStore PortforwardStore) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage saved port-forward profiles",
+		Long:  "Profiles are saved with brev port-forward --save and started with --profile",
+		Example: `
+  brev port-forward profile ls
+  brev port-forward profile rm ml`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List port-forward profiles",
+		Args:  cmderrors.TransformToValidationError(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runProfileLs(t, pfStore)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rm",
+		Short: "Delete a port-forward profile",
+		Args:  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			home, err := pfStore.GetBrevHomePath()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = Registry{Home: home}.DeleteProfile(args[0])
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			t.Vprintf(t.Green("deleted profile %s\n", args[0]))
+			return nil
+		},
+	})
+	return cmd
+}
+
+func runProfileLs(t *terminal.Terminal, pfStore PortforwardStore) error {
+	home, err := pfStore.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	profiles, err := Registry{Home: home}.LoadProfiles()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(profiles) == 0 {
+		t.Vprintf("no profiles yet, save one with: brev port-forward <instance> -p 8888 --save <name>\n")
+		return nil
+	}
+	names := []string{}
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(os.Stdout)
+	ta.Style().Options = getBrevTableOptions()
+	ta.AppendHeader(table.Row{"NAME", "INSTANCE", "PORTS", "HOST"})
+	for _, name := range names {
+		p := profiles[name]
+		ta.AppendRow(table.Row{p.Name, p.Instance, Forward{Ports: p.Ports}.PortsString(), p.Host})
+	}
+	ta.Render()
+	return nil
+}
+
+func getBrevTableOptions() table.Options {
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	return options
+}
@@ -0,0 +1,166 @@
+package portforward
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// PortMapping forwards LocalPort on this machine to RemotePort on the
+// instance, or with Reverse exposes LocalPort on the instance as RemotePort
+type PortMapping struct {
+	LocalPort  int  `json:"localPort"`
+	RemotePort int  `json:"remotePort"`
+	Reverse    bool `json:"reverse,omitempty"`
+}
+
+func (m PortMapping) String() string {
+	return fmt.Sprintf("%d:%d", m.LocalPort, m.RemotePort)
+}
+
+// Describe shows the direction traffic flows, the local port is always on the left
+func (m PortMapping) Describe() string {
+	if m.Reverse {
+		return fmt.Sprintf("%d<-%d", m.LocalPort, m.RemotePort)
+	}
+	return fmt.Sprintf("%d->%d", m.LocalPort, m.RemotePort)
+}
+
+// SSHFlag is the ssh option and spec for this mapping, ex: -L 8888:127.0.0.1:8888
+func (m PortMapping) SSHFlag() (string, string) {
+	if m.Reverse {
+		return ForwardRemote, fmt.Sprintf("%d:127.0.0.1:%d", m.RemotePort, m.LocalPort)
+	}
+	return ForwardLocal, fmt.Sprintf("%d:127.0.0.1:%d", m.LocalPort, m.RemotePort)
+}
+
+// ParsePortMapping parses local_port:remote_port, a single port forwards to the same port
+func ParsePortMapping(value string) (PortMapping, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return PortMapping{}, breverrors.NewValidationError(fmt.Sprintf("port format invalid %q, use local_port:remote_port", value))
+	}
+	local, err := parsePort(parts[0])
+	if err != nil {
+		return PortMapping{}, breverrors.WrapAndTrace(err)
+	}
+	remote, err := parsePort(parts[1])
+	if err != nil {
+		return PortMapping{}, breverrors.WrapAndTrace(err)
+	}
+	return PortMapping{LocalPort: local, RemotePort: remote}, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, breverrors.NewValidationError(fmt.Sprintf("invalid port %q, ports are between 1 and 65535", value))
+	}
+	return port, nil
+}
+
+// ParsePortMappings parses -p and -R values, both written local_port:remote_port
+func ParsePortMappings(local []string, reverse []string) ([]PortMapping, error) {
+	mappings := []PortMapping{}
+	for _, value := range local {
+		m, err := ParsePortMapping(value)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		mappings = append(mappings, m)
+	}
+	for _, value := range reverse {
+		m, err := ParsePortMapping(value)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		m.Reverse = true
+		mappings = append(mappings, m)
+	}
+	err := checkDuplicatePorts(mappings)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return mappings, nil
+}
+
+// checkDuplicatePorts makes sure no port is bound twice, local ports on this
+// machine and reverse ports on the instance
+func checkDuplicatePorts(mappings []PortMapping) error {
+	local := map[int]bool{}
+	remote := map[int]bool{}
+	for _, m := range mappings {
+		if m.Reverse {
+			if remote[m.RemotePort] {
+				return breverrors.NewValidationError(fmt.Sprintf("remote port %d is used more than once", m.RemotePort))
+			}
+			remote[m.RemotePort] = true
+			continue
+		}
+		if local[m.LocalPort] {
+			return breverrors.NewValidationError(fmt.Sprintf("local port %d is used more than once", m.LocalPort))
+		}
+		local[m.LocalPort] = true
+	}
+	return nil
+}
+
+// CheckLocalPorts fails if a port a mapping binds is already taken, naming
+// the brev forward holding it when there is one. Local forwards bind here,
+// reverse forwards bind on the instance.
+func CheckLocalPorts(instance string, mappings []PortMapping, running []Forward) error {
+	for _, m := range mappings {
+		f, ok := findConflict(instance, m, running)
+		if ok {
+			return breverrors.NewValidationError(fmt.Sprintf("port %s is already forwarded for %s by %s, stop it with: brev port-forward stop %s", m.Describe(), f.Instance, f.ID, f.ID))
+		}
+		if m.Reverse {
+			continue
+		}
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", m.LocalPort))
+		if err != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("local port %d is already in use, pick another with -p other_port:%d", m.LocalPort, m.RemotePort))
+		}
+		_ = l.Close()
+	}
+	return nil
+}
+
+func findConflict(instance string, m PortMapping, running []Forward) (Forward, bool) {
+	for _, f := range running {
+		for _, other := range f.Ports {
+			if m.Reverse && other.Reverse && f.Instance == instance && other.RemotePort == m.RemotePort {
+				return f, true
+			}
+			if !m.Reverse && !other.Reverse && other.LocalPort == m.LocalPort {
+				return f, true
+			}
+		}
+	}
+	return Forward{}, false
+}
+
+// ClosedLocalPorts returns the reverse mappings with nothing listening locally
+// yet, the tunnel still comes up but connections from the instance will fail
+func ClosedLocalPorts(mappings []PortMapping) []PortMapping {
+	closed := []PortMapping{}
+	for _, m := range mappings {
+		if !m.Reverse {
+			continue
+		}
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", m.LocalPort), time.Second)
+		if err != nil {
+			closed = append(closed, m)
+			continue
+		}
+		_ = conn.Close()
+	}
+	return closed
+}
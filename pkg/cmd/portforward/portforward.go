@@ -7,6 +7,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
@@ -15,12 +17,29 @@ import (
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
 var (
-	sshLinkLong    = "Port forward your Brev machine's port to your local port"
-	sshLinkExample = "brev port-forward <ws_name> -p local_port:remote_port"
+	sshLinkLong = `Port forward your Brev machine's ports to your local ports.
+
+-p forwards local_port on this machine to remote_port on the instance. -R
+goes the other way, the instance reaches local_port on this machine at
+remote_port on localhost, ex: for a local database or license server.
+
+The tunnel reconnects on its own if it drops. Use -d to keep it running in
+the background, brev port-forward ls to see what is running and
+brev port-forward stop to end it. --save stores the ports as a profile so
+you can bring them back with --profile.`
+	sshLinkExample = `
+  brev port-forward <ws_name> -p local_port:remote_port
+  brev port-forward my-instance -p 8888:8888 -p 6006 -d
+  brev port-forward my-instance -p 8888 -p 6006 --save ml
+  brev port-forward my-instance -R 5432:5432
+  brev port-forward --profile ml -d
+  brev port-forward ls
+  brev port-forward stop ml`
 )
 
 type PortforwardStore interface {
@@ -28,11 +47,30 @@ type PortforwardStore interface {
 	refresh.RefreshStore
 	util.GetWorkspaceByNameOrIDErrStore
 	util.MakeWorkspaceWithMetaStore
+	GetBrevHomePath() (string, error)
+}
+
+// ssh flags for the two directions a port can be forwarded
+const (
+	ForwardLocal  = "-L"
+	ForwardRemote = "-R"
+)
+
+type PortforwardOptions struct {
+	Ports   []string
+	Reverse []string
+	Host    bool
+	Profile string
+	Save    string
+	Detach  bool
+
+	// set on the background process started by --detach
+	forwardID      string
+	forwardProfile string
 }
 
 func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra.Command {
-	var port string
-	var useHost bool
+	var opts PortforwardOptions
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"ssh": ""},
 		Use:                   "port-forward",
@@ -40,21 +78,36 @@ func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra
 		Short:                 "Enable a local tunnel",
 		Long:                  sshLinkLong,
 		Example:               sshLinkExample,
-		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		Args:                  cmderrors.TransformToValidationError(cobra.MaximumNArgs(1)),
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(pfStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if port == "" {
-				port = startInput(t)
+			nameOrID := ""
+			if len(args) > 0 {
+				nameOrID = args[0]
+			}
+			if len(opts.Ports) == 0 && len(opts.Reverse) == 0 && opts.Profile == "" {
+				if nameOrID == "" {
+					return breverrors.NewValidationError("please provide an instance or --profile")
+				}
+				opts.Ports = []string{startInput(t)}
 			}
-			err := RunPortforward(pfStore, args[0], port, useHost)
+			err := RunPortforwards(t, pfStore, nameOrID, opts)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
-	cmd.Flags().StringVarP(&port, "port", "p", "", "port forward flag describe me better")
-	cmd.Flags().BoolVar(&useHost, "host", false, "Use the -host version of the instance")
+	cmd.Flags().StringArrayVarP(&opts.Ports, "port", "p", []string{}, "local_port:remote_port to forward, a single port forwards to the same port (repeatable)")
+	cmd.Flags().StringArrayVarP(&opts.Reverse, "reverse", "R", []string{}, "local_port:remote_port to expose a port on this machine to the instance (repeatable)")
+	cmd.Flags().BoolVar(&opts.Host, "host", false, "Use the -host version of the instance")
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "forward the instance and ports saved in this profile")
+	cmd.Flags().StringVar(&opts.Save, "save", "", "save the instance and ports as a profile with this name")
+	cmd.Flags().BoolVarP(&opts.Detach, "detach", "d", false, "keep forwarding in the background")
+	cmd.Flags().StringVar(&opts.forwardID, "forward-id", "", "")
+	cmd.Flags().StringVar(&opts.forwardProfile, "forward-profile", "", "")
+	_ = cmd.Flags().MarkHidden("forward-id")
+	_ = cmd.Flags().MarkHidden("forward-profile")
 	err := cmd.RegisterFlagCompletionFunc("port", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return nil, cobra.ShellCompDirectiveNoSpace
 	})
@@ -63,23 +116,102 @@ func NewCmdPortForwardSSH(pfStore PortforwardStore, t *terminal.Terminal) *cobra
 		fmt.Print(breverrors.WrapAndTrace(err))
 	}
 
+	cmd.AddCommand(NewCmdPortForwardLs(t, pfStore))
+	cmd.AddCommand(NewCmdPortForwardStop(t, pfStore))
+	cmd.AddCommand(NewCmdPortForwardProfile(t, pfStore))
 	return cmd
 }
 
+// RunPortforward forwards a single local_port:remote_port until interrupted
 func RunPortforward(pfStore PortforwardStore, nameOrID string, portString string, useHost bool) error {
-	var portSplit []string
-	if strings.Contains(portString, ":") {
-		portSplit = strings.Split(portString, ":")
-		if len(portSplit) != 2 {
-			return breverrors.NewValidationError("port format invalid, use local_port:remote_port")
+	err := RunPortforwards(terminal.New(), pfStore, nameOrID, PortforwardOptions{Ports: []string{portString}, Host: useHost})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// RunReversePortforward exposes a local port on the instance until interrupted,
+// portString is local_port:remote_port
+func RunReversePortforward(pfStore PortforwardStore, nameOrID string, portString string, useHost bool) error {
+	err := RunPortforwards(terminal.New(), pfStore, nameOrID, PortforwardOptions{Reverse: []string{portString}, Host: useHost})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func RunPortforwards(t *terminal.Terminal, pfStore PortforwardStore, nameOrID string, opts PortforwardOptions) error {
+	home, err := pfStore.GetBrevHomePath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	registry := Registry{Home: home}
+
+	profileName := opts.forwardProfile
+	if opts.Profile != "" {
+		profile, profileErr := registry.GetProfile(opts.Profile)
+		if profileErr != nil {
+			return breverrors.WrapAndTrace(profileErr)
 		}
-	} else {
-		return breverrors.NewValidationError("port format invalid, use local_port:remote_port")
+		if nameOrID == "" {
+			nameOrID = profile.Instance
+		}
+		for _, p := range profile.Ports {
+			if p.Reverse {
+				opts.Reverse = append(opts.Reverse, p.String())
+			} else {
+				opts.Ports = append(opts.Ports, p.String())
+			}
+		}
+		opts.Host = opts.Host || profile.Host
+		profileName = profile.Name
+	}
+	if nameOrID == "" {
+		return breverrors.NewValidationError("please provide an instance or --profile")
+	}
+	mappings, err := ParsePortMappings(opts.Ports, opts.Reverse)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(mappings) == 0 {
+		return breverrors.NewValidationError("please provide at least one port with -p or -R local_port:remote_port")
+	}
+
+	if opts.Save != "" {
+		err = registry.SaveProfile(Profile{Name: opts.Save, Instance: nameOrID, Ports: mappings, Host: opts.Host})
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf(t.Green("saved profile %s, forward it again with: brev port-forward --profile %s\n", opts.Save, opts.Save))
+		profileName = opts.Save
+	}
+
+	if opts.forwardID == "" {
+		running, listErr := registry.ListForwards()
+		if listErr != nil {
+			return breverrors.WrapAndTrace(listErr)
+		}
+		err = CheckLocalPorts(nameOrID, mappings, running)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		for _, m := range ClosedLocalPorts(mappings) {
+			t.Vprintf(t.Yellow("nothing is listening on localhost:%d yet, the instance can't reach it until something does\n", m.LocalPort))
+		}
+	}
+
+	if opts.Detach {
+		err = detach(t, registry, nameOrID, mappings, opts.Host, profileName)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
 	}
 
 	res := refresh.RunRefreshAsync(pfStore)
 
-	sshName, err := ConvertNametoSSHName(pfStore, nameOrID, useHost)
+	sshName, err := ConvertNametoSSHName(pfStore, nameOrID, opts.Host)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -89,13 +221,152 @@ func RunPortforward(pfStore PortforwardStore, nameOrID string, portString string
 		return breverrors.WrapAndTrace(err)
 	}
 
-	_, err = RunSSHPortForward("-L", portSplit[0], portSplit[1], sshName)
+	err = runTunnel(t, registry, Forward{
+		ID:       opts.forwardID,
+		Instance: nameOrID,
+		SSHName:  sshName,
+		Ports:    mappings,
+		Profile:  profileName,
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
 
+// runTunnel registers the forward and holds the tunnel until interrupted
+func runTunnel(t *terminal.Terminal, registry Registry, forward Forward) error {
+	keyPath, err := getKeyPath()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	detached := forward.ID != ""
+	if !detached {
+		forward.ID = newForwardID()
+	} else {
+		forward.LogFile = registry.LogPath(forward.ID)
+		// the terminal that started us may go away
+		signal.Ignore(syscall.SIGHUP)
+	}
+	forward.PID = os.Getpid()
+	forward.Started = time.Now()
+	forward.Status = StatusConnected
+	err = registry.SaveForward(forward)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer registry.RemoveForward(forward.ID) //nolint:errcheck // best effort, ls drops stale entries
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	fmt.Println("Port forwarding...")
+	for _, p := range forward.Ports {
+		if p.Reverse {
+			fmt.Printf("localhost:%d <- %s:%d\n", p.LocalPort, forward.SSHName, p.RemotePort)
+		} else {
+			fmt.Printf("localhost:%d -> %s:%d\n", p.LocalPort, forward.SSHName, p.RemotePort)
+		}
+	}
+	if !detached {
+		t.Vprintf(t.Yellow("press ctrl-c to stop\n"))
+	}
+
+	tunnel := Tunnel{
+		SSHName: forward.SSHName,
+		KeyPath: keyPath,
+		Ports:   forward.Ports,
+		Out:     os.Stderr,
+		OnStatus: func(status string) {
+			if forward.Status == status {
+				return
+			}
+			forward.Status = status
+			_ = registry.SaveForward(forward)
+		},
+	}
+	err = tunnel.Run(stop)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// detach starts brev port-forward again as a background process that logs to the registry
+func detach(t *terminal.Terminal, registry Registry, nameOrID string, mappings []PortMapping, host bool, profileName string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	id := newForwardID()
+	args := []string{"port-forward", nameOrID, "--forward-id", id}
+	for _, m := range mappings {
+		if m.Reverse {
+			args = append(args, "-R", m.String())
+		} else {
+			args = append(args, "-p", m.String())
+		}
+	}
+	if host {
+		args = append(args, "--host")
+	}
+	if profileName != "" {
+		args = append(args, "--forward-profile", profileName)
+	}
+
+	err = os.MkdirAll(filepath.Dir(registry.LogPath(id)), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	logFile, err := os.OpenFile(registry.LogPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer logFile.Close() //nolint:errcheck // the child has its own handle
+
+	cmd := exec.Command(exe, args...) //nolint:gosec // re-running ourselves
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	err = cmd.Start()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_ = cmd.Process.Release()
+
+	name := id
+	if profileName != "" {
+		name = profileName
+	}
+	t.Vprintf(t.Green("forwarding in the background as %s\n", id))
+	t.Vprintf("  see it with: brev port-forward ls\n  stop it with: brev port-forward stop %s\n  logs: %s\n", name, registry.LogPath(id))
+	return nil
+}
+
+func newForwardID() string {
+	return strings.Split(uuid.New().String(), "-")[0]
+}
+
+func getKeyPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", breverrors.Wrap(err, "failed to get user home directory")
+	}
+
+	keyPath := filepath.Join(homeDir, ".brev", "brev.pem")
+
+	if _, err = os.Stat(keyPath); os.IsNotExist(err) {
+		return "", breverrors.Wrap(err, fmt.Sprintf("SSH key not found at %s. Please ensure your Brev SSH key is properly set up.", keyPath))
+	}
+	return keyPath, nil
+}
+
 func ConvertNametoSSHName(store PortforwardStore, workspaceNameOrID string, useHost bool) (string, error) {
 	workspace, err := util.GetUserWorkspaceByNameOrIDErr(store, workspaceNameOrID)
 	if err != nil {
@@ -113,17 +384,20 @@ func RunSSHPortForward(forwardType string, localPort string, remotePort string,
 	signal.Notify(signals, os.Interrupt)
 	defer signal.Stop(signals)
 
+	if forwardType != ForwardLocal && forwardType != ForwardRemote {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid forward type %q, use %s or %s", forwardType, ForwardLocal, ForwardRemote))
+	}
+	// -L binds the local port, -R binds the remote one
 	portMapping := fmt.Sprintf("%s:127.0.0.1:%s", localPort, remotePort)
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, breverrors.Wrap(err, "failed to get user home directory")
+	direction := "->"
+	if forwardType == ForwardRemote {
+		portMapping = fmt.Sprintf("%s:127.0.0.1:%s", remotePort, localPort)
+		direction = "<-"
 	}
 
-	keyPath := filepath.Join(homeDir, ".brev", "brev.pem")
-
-	if _, err = os.Stat(keyPath); os.IsNotExist(err) {
-		return nil, breverrors.Wrap(err, fmt.Sprintf("SSH key not found at %s. Please ensure your Brev SSH key is properly set up.", keyPath))
+	keyPath, err := getKeyPath()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
 	}
 
 	cmdSHH := exec.Command("ssh", "-i", keyPath, "-T", forwardType, portMapping, sshName, "-N") //nolint:gosec //ok
@@ -132,7 +406,7 @@ func RunSSHPortForward(forwardType string, localPort string, remotePort string,
 	cmdSHH.Stderr = os.Stderr
 
 	fmt.Println("Port forwarding...")
-	fmt.Printf("localhost:%s -> %s:%s\n", localPort, sshName, remotePort)
+	fmt.Printf("localhost:%s %s %s:%s\n", localPort, direction, sshName, remotePort)
 
 	err = cmdSHH.Start()
 	if err != nil {
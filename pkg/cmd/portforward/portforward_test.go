@@ -1 +1,119 @@
 package portforward
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortMappings(t *testing.T) {
+	mappings, err := ParsePortMappings([]string{"8888:8888", "6006", "9000:80"}, []string{"5432"})
+	assert.Nil(t, err)
+	assert.Equal(t, []PortMapping{{8888, 8888, false}, {6006, 6006, false}, {9000, 80, false}, {5432, 5432, true}}, mappings)
+
+	_, err = ParsePortMappings([]string{"8888:8888", "8888:9999"}, nil)
+	assert.NotNil(t, err)
+	_, err = ParsePortMappings([]string{"1:2:3"}, nil)
+	assert.NotNil(t, err)
+	_, err = ParsePortMappings([]string{"70000"}, nil)
+	assert.NotNil(t, err)
+	// the same local port can be forwarded out and exposed back in
+	_, err = ParsePortMappings([]string{"8888"}, []string{"8888:9999"})
+	assert.Nil(t, err)
+	_, err = ParsePortMappings(nil, []string{"3000:80", "3001:80"})
+	assert.NotNil(t, err)
+}
+
+func TestReverseSSHFlag(t *testing.T) {
+	flag, spec := PortMapping{LocalPort: 5432, RemotePort: 15432, Reverse: true}.SSHFlag()
+	assert.Equal(t, ForwardRemote, flag)
+	assert.Equal(t, "15432:127.0.0.1:5432", spec)
+	flag, spec = PortMapping{LocalPort: 8888, RemotePort: 8889}.SSHFlag()
+	assert.Equal(t, ForwardLocal, flag)
+	assert.Equal(t, "8888:127.0.0.1:8889", spec)
+}
+
+func TestCheckLocalPorts(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer l.Close() //nolint:errcheck // test
+	taken := l.Addr().(*net.TCPAddr).Port
+
+	err = CheckLocalPorts("my-instance", []PortMapping{{taken, 80, false}}, nil)
+	assert.NotNil(t, err)
+	// reverse forwards need the local port to be taken, by the service being exposed
+	err = CheckLocalPorts("my-instance", []PortMapping{{taken, 80, true}}, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, ClosedLocalPorts([]PortMapping{{taken, 80, true}}))
+
+	running := []Forward{{ID: "abc", Instance: "my-instance", Ports: []PortMapping{{5555, 80, false}, {6000, 7000, true}}}}
+	err = CheckLocalPorts("my-instance", []PortMapping{{5555, 80, false}}, running)
+	assert.ErrorContains(t, err, "brev port-forward stop abc")
+	err = CheckLocalPorts("my-instance", []PortMapping{{6001, 7000, true}}, running)
+	assert.ErrorContains(t, err, "brev port-forward stop abc")
+	err = CheckLocalPorts("other-instance", []PortMapping{{6001, 7000, true}}, running)
+	assert.Nil(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := Registry{Home: t.TempDir()}
+
+	err := registry.SaveProfile(Profile{Name: "ml", Instance: "my-instance", Ports: []PortMapping{{8888, 8888, false}}})
+	assert.Nil(t, err)
+	profile, err := registry.GetProfile("ml")
+	assert.Nil(t, err)
+	assert.Equal(t, "my-instance", profile.Instance)
+	assert.Nil(t, registry.DeleteProfile("ml"))
+	_, err = registry.GetProfile("ml")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, registry.SaveForward(Forward{ID: "live", PID: os.Getpid(), Instance: "a"}))
+	assert.Nil(t, registry.SaveForward(Forward{ID: "stale", PID: 0, Instance: "b"}))
+	forwards, err := registry.ListForwards()
+	assert.Nil(t, err)
+	if assert.Len(t, forwards, 1) {
+		assert.Equal(t, "live", forwards[0].ID)
+	}
+	_, err = os.Stat(registry.forwardPath("stale"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Len(t, MatchForwards(forwards, []string{"a"}, false), 1)
+	assert.Len(t, MatchForwards(forwards, []string{"b"}, false), 0)
+	assert.Len(t, MatchForwards(forwards, nil, true), 1)
+}
+
+func TestTunnelReconnects(t *testing.T) {
+	stop := make(chan struct{})
+	delays := []time.Duration{}
+	statuses := []string{}
+	tunnel := Tunnel{
+		SSHName:  "my-instance",
+		Ports:    []PortMapping{{8888, 8888, false}, {6006, 6006, true}},
+		OnStatus: func(status string) { statuses = append(statuses, status) },
+		command:  func(args ...string) *exec.Cmd { return exec.Command("false") },
+		after: func(d time.Duration) <-chan time.Time {
+			delays = append(delays, d)
+			c := make(chan time.Time, 1)
+			if len(delays) == 3 {
+				close(stop)
+				return c
+			}
+			c <- time.Now()
+			return c
+		},
+	}
+	assert.Contains(t, tunnel.SSHArgs(), "8888:127.0.0.1:8888")
+	assert.Contains(t, tunnel.SSHArgs(), ForwardRemote)
+
+	err := tunnel.Run(stop)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, delays)
+	assert.Equal(t, StatusConnected, statuses[0])
+	assert.Equal(t, StatusReconnecting, statuses[1])
+}
@@ -0,0 +1,216 @@
+package portforward
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	forwardsDirName  = "port-forwards"
+	profilesFileName = "port-forward-profiles.json"
+)
+
+// Forward is a running port-forward, written by the process holding the tunnel
+type Forward struct {
+	ID       string        `json:"id"`
+	Instance string        `json:"instance"`
+	SSHName  string        `json:"sshName"`
+	Ports    []PortMapping `json:"ports"`
+	Profile  string        `json:"profile,omitempty"`
+	PID      int           `json:"pid"`
+	Started  time.Time     `json:"started"`
+	Status   string        `json:"status"`
+	LogFile  string        `json:"logFile,omitempty"`
+}
+
+func (f Forward) PortsString() string {
+	ports := []string{}
+	for _, p := range f.Ports {
+		ports = append(ports, p.Describe())
+	}
+	return strings.Join(ports, ",")
+}
+
+// Profile is a saved set of ports for an instance, ex: jupyter and tensorboard
+type Profile struct {
+	Name     string        `json:"name"`
+	Instance string        `json:"instance"`
+	Ports    []PortMapping `json:"ports"`
+	Host     bool          `json:"host,omitempty"`
+}
+
+// Registry keeps forwards and profiles under the brev home dir
+type Registry struct {
+	Home string
+}
+
+func (r Registry) forwardsDir() string {
+	return filepath.Join(r.Home, forwardsDirName)
+}
+
+func (r Registry) forwardPath(id string) string {
+	return filepath.Join(r.forwardsDir(), id+".json")
+}
+
+func (r Registry) LogPath(id string) string {
+	return filepath.Join(r.forwardsDir(), id+".log")
+}
+
+func (r Registry) SaveForward(f Forward) error {
+	err := os.MkdirAll(r.forwardsDir(), 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.WriteFile(r.forwardPath(f.ID), b, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r Registry) RemoveForward(id string) error {
+	err := os.Remove(r.forwardPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// ListForwards returns the running forwards, dropping entries whose process is gone
+func (r Registry) ListForwards() ([]Forward, error) {
+	matches, err := filepath.Glob(filepath.Join(r.forwardsDir(), "*.json"))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	forwards := []Forward{}
+	for _, path := range matches {
+		b, readErr := os.ReadFile(path) //nolint:gosec // our own state dir
+		if readErr != nil {
+			return nil, breverrors.WrapAndTrace(readErr)
+		}
+		var f Forward
+		if json.Unmarshal(b, &f) != nil || !processAlive(f.PID) {
+			_ = os.Remove(path)
+			_ = os.Remove(r.LogPath(strings.TrimSuffix(filepath.Base(path), ".json")))
+			continue
+		}
+		forwards = append(forwards, f)
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].Started.Before(forwards[j].Started) })
+	return forwards, nil
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// FindProcess only succeeds for live processes on windows, which can't signal 0
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// StopForward asks the forward's process to shut down, it cleans up its own entry
+func StopForward(f Forward) error {
+	p, err := os.FindProcess(f.PID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if p.Signal(os.Interrupt) == nil {
+		return nil
+	}
+	err = p.Kill()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r Registry) profilesPath() string {
+	return filepath.Join(r.Home, profilesFileName)
+}
+
+func (r Registry) LoadProfiles() (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+	b, err := os.ReadFile(r.profilesPath())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = json.Unmarshal(b, &profiles)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return profiles, nil
+}
+
+func (r Registry) GetProfile(name string) (*Profile, error) {
+	profiles, err := r.LoadProfiles()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("no port-forward profile named %s, see: brev port-forward profile ls", name))
+	}
+	return &profile, nil
+}
+
+func (r Registry) SaveProfile(profile Profile) error {
+	profiles, err := r.LoadProfiles()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	profiles[profile.Name] = profile
+	return r.writeProfiles(profiles)
+}
+
+func (r Registry) DeleteProfile(name string) error {
+	profiles, err := r.LoadProfiles()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if _, ok := profiles[name]; !ok {
+		return breverrors.NewValidationError(fmt.Sprintf("no port-forward profile named %s", name))
+	}
+	delete(profiles, name)
+	return r.writeProfiles(profiles)
+}
+
+func (r Registry) writeProfiles(profiles map[string]Profile) error {
+	err := os.MkdirAll(r.Home, 0o700)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	b, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.WriteFile(r.profilesPath(), b, 0o600)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	StatusConnected    = "connected"
+	StatusReconnecting = "reconnecting"
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+	// a tunnel that stayed up this long resets the reconnect backoff
+	stableAfter = time.Minute
+)
+
+// Tunnel holds one ssh process for all of an instance's port mappings and
+// restarts it when it drops, ex: laptop sleep or a wifi change
+type Tunnel struct {
+	SSHName  string
+	KeyPath  string
+	Ports    []PortMapping
+	Out      io.Writer
+	OnStatus func(status string)
+
+	command func(args ...string) *exec.Cmd
+	after   func(time.Duration) <-chan time.Time
+}
+
+func (t Tunnel) SSHArgs() []string {
+	args := []string{
+		"-i", t.KeyPath, "-T", "-N",
+		// exit instead of half working so we notice and reconnect
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+	}
+	for _, p := range t.Ports {
+		flag, spec := p.SSHFlag()
+		args = append(args, flag, spec)
+	}
+	return append(args, t.SSHName)
+}
+
+func (t Tunnel) setStatus(status string) {
+	if t.OnStatus != nil {
+		t.OnStatus(status)
+	}
+}
+
+// Run keeps the tunnel up until stop is closed
+func (t Tunnel) Run(stop <-chan struct{}) error {
+	command := t.command
+	if command == nil {
+		command = func(args ...string) *exec.Cmd {
+			return exec.Command("ssh", args...) //nolint:gosec // args are built from parsed ports and the ssh alias
+		}
+	}
+	after := t.after
+	if after == nil {
+		after = time.After
+	}
+
+	delay := minReconnectDelay
+	for {
+		cmd := command(t.SSHArgs()...)
+		cmd.Stdout = t.Out
+		cmd.Stderr = t.Out
+		started := time.Now()
+		err := cmd.Start()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.setStatus(StatusConnected)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- cmd.Wait()
+		}()
+		select {
+		case <-stop:
+			_ = cmd.Process.Kill()
+			<-done
+			return nil
+		case err = <-done:
+		}
+
+		if time.Since(started) > stableAfter {
+			delay = minReconnectDelay
+		}
+		t.setStatus(StatusReconnecting)
+		if t.Out != nil {
+			_, _ = fmt.Fprintf(t.Out, "tunnel to %s dropped (%v), reconnecting in %s\n", t.SSHName, err, delay)
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-after(delay):
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
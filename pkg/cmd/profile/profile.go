@@ -3,13 +3,13 @@ package profile
 import (
 	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmd/start"
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/giturl"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/fatih/color"
 	"github.com/pkg/browser"
@@ -85,12 +85,7 @@ func profile(personalSettingsRepo string, t *terminal.Terminal, profileStore Pro
 		return nil
 	}
 
-	isURL := false
-	if strings.Contains(personalSettingsRepo, "https://") || strings.Contains(personalSettingsRepo, "git@") {
-		isURL = true
-	}
-
-	if !isURL {
+	if !giturl.IsGitURL(personalSettingsRepo) {
 		err = errors.New("please use a valid git url")
 		return breverrors.WrapAndTrace(err)
 	}
@@ -26,6 +26,7 @@ type RefreshStore interface {
 	MkdirAll(string, fs.FileMode) error
 	GetBrevCloudflaredBinaryPath() (string, error)
 	Create(string) (io.WriteCloser, error)
+	Rename(string, string) error
 }
 
 func NewCmdRefresh(t *terminal.Terminal, store RefreshStore) *cobra.Command {
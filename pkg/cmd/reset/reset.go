@@ -12,6 +12,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/featureflag"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/spf13/cobra"
@@ -194,25 +195,23 @@ func hardResetCreateEmptyWorkspace(t *terminal.Terminal, resetStore ResetStore,
 
 func pollUntil(t *terminal.Terminal, wsid string, state string, resetStore ResetStore, canSafelyExit bool) error {
 	s := t.NewSpinner()
-	isReady := false
 	if canSafelyExit {
 		t.Vprintf("You can safely ctrl+c to exit\n")
 	}
 	s.Suffix = " hang tight 🤙"
 	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := resetStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  workspace is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Workspace is ready!"
-			s.Stop()
-			isReady = true
-		}
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  workspace is " + strings.ToLower(status)
+	}
+	_, err := wait.Until(resetStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
 	}
+	s.Suffix = "Workspace is ready!"
+	s.Stop()
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
 	"github.com/brevdev/brev-cli/pkg/cmdcontext"
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -44,6 +45,9 @@ func NewCmdSet(t *terminal.Terminal, loginSetStore SetStore, noLoginSetStore Set
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
+			if envOrg := config.GlobalConfig.GetOrgOverride(); envOrg != "" {
+				t.Vprint(t.Yellow("BREV_ORG is set to %s, brev uses that org until it's unset\n", envOrg))
+			}
 			return nil
 		},
 	}
@@ -3,6 +3,7 @@ package setupworkspace
 import (
 	"fmt"
 
+	"github.com/brevdev/brev-cli/pkg/autostartconf"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
@@ -12,6 +13,7 @@ import (
 )
 
 type SetupWorkspaceStore interface {
+	autostartconf.AutoStartStore
 	GetSetupParams() (*store.SetupParamsV0, error)
 	WriteSetupScript(script string) error
 	GetSetupScriptPath() string
@@ -24,6 +26,8 @@ const Name = "setupworkspace"
 // Internal command for setting up workspace // v1 similar to k8s post-start script
 func NewCmdSetupWorkspace(store SetupWorkspaceStore) *cobra.Command {
 	var forceEnableSetup bool
+	var startExecs bool
+	var opts setupworkspace.SetupOptions
 	cmd := &cobra.Command{
 		Annotations: map[string]string{"hidden": ""},
 		Use:         Name,
@@ -55,15 +59,39 @@ func NewCmdSetupWorkspace(store SetupWorkspaceStore) *cobra.Command {
 				return nil
 			}
 
-			err = setupworkspace.SetupWorkspace(params)
+			if startExecs {
+				err = setupworkspace.RunStartExecs(params)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
+
+			setupErr := setupworkspace.SetupWorkspace(params, opts)
+			// start execs run from a unit so they don't hold up setup and come back on every
+			// boot, the ones that don't depend on a failed build exec still run
+			if setupworkspace.HasStartExecs(params.ExecsV1) {
+				err = autostartconf.NewStartExecsConfigurer(store).Install()
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+			}
+			// a broken autostop unit shouldn't fail setup, the instance just won't stop itself
+			err = autostartconf.NewIdleStopConfigurer(store).Install()
 			if err != nil {
-				return breverrors.WrapAndTrace(err)
+				fmt.Printf("WARNING: could not install autostop: %v\n", err)
+			}
+			if setupErr != nil {
+				return breverrors.WrapAndTrace(setupErr)
 			}
 			fmt.Println("done setting up instance")
 			return nil
 		},
 	}
 	cmd.PersistentFlags().BoolVar(&forceEnableSetup, "force-enable", false, "force the setup script to run despite params")
+	cmd.PersistentFlags().BoolVar(&startExecs, "start-execs", false, "only run the start stage execs, used on boot")
+	cmd.PersistentFlags().BoolVar(&opts.Resume, "resume", false, "skip steps that completed last time with the same inputs")
+	cmd.PersistentFlags().StringSliceVar(&opts.Force, "force", nil, "re-run a step or group even when resuming, ex: --force execs/build or --force repos")
 
 	return cmd
 }
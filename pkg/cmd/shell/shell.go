@@ -18,6 +18,7 @@ import (
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
 	"github.com/brevdev/brev-cli/pkg/writeconnectionevent"
 	"github.com/briandowns/spinner"
 
@@ -209,19 +210,13 @@ func startWorkspaceIfStopped(t *terminal.Terminal, s *spinner.Spinner, tstore Sh
 }
 
 func pollUntil(s *spinner.Spinner, wsid string, state string, shellStore ShellStore, waitMsg string) error {
-	isReady := false
 	s.Suffix = waitMsg
 	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := shellStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = waitMsg
-		if ws.Status == state {
-			isReady = true
-		}
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	_, err := wait.Until(shellStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
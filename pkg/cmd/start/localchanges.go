@@ -0,0 +1,210 @@
+package start
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cp"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/filesync"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	"github.com/brevdev/brev-cli/pkg/wait"
+)
+
+const (
+	// where the changes are uploaded on the instance, removed once applied
+	localChangesRemoteDir = "~/.brev/local-changes"
+	localChangesBundle    = "local.bundle"
+	localChangesDiff      = "working-tree.diff"
+	// how long to wait for setup to clone the project before applying
+	projectCloneTimeout = 10 * time.Minute
+)
+
+// LocalChanges is what a local repo has that its remote doesn't: commits on
+// branches that aren't pushed and the uncommitted state of the working tree
+type LocalChanges struct {
+	Dir    string // local temp dir holding the bundle and diff
+	Bundle bool   // false when every branch is pushed
+	Diff   bool   // false when the working tree is clean
+	Head   string // branch checked out locally, or a sha when detached
+}
+
+// PackageLocalChanges bundles the unpushed commits of repoPath and diffs its
+// working tree, including untracked files that aren't ignored, against HEAD.
+// The repo's index is left alone.
+func PackageLocalChanges(repoPath string) (*LocalChanges, error) {
+	dir, err := os.MkdirTemp("", "brev-local-changes-")
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	changes := &LocalChanges{Dir: dir}
+	err = changes.pack(repoPath)
+	if err != nil {
+		changes.Cleanup()
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return changes, nil
+}
+
+func (l *LocalChanges) pack(repoPath string) error {
+	head, err := gitOutput(repoPath, nil, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		head, err = gitOutput(repoPath, nil, "rev-parse", "HEAD")
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	l.Head = head
+
+	unpushed, err := gitOutput(repoPath, nil, "rev-list", "--count", "--branches", "--not", "--remotes")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if unpushed != "0" {
+		_, err = gitOutput(repoPath, nil, "bundle", "create", filepath.Join(l.Dir, localChangesBundle), "--branches", "--not", "--remotes")
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		l.Bundle = true
+	}
+
+	// stage everything into a copy of the index so untracked files are in the diff
+	indexPath, err := gitOutput(repoPath, nil, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmpIndex := filepath.Join(l.Dir, "index")
+	index, err := os.ReadFile(indexPath) //nolint:gosec // the repo's own index
+	if err == nil {
+		err = os.WriteFile(tmpIndex, index, 0o600)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	env := []string{"GIT_INDEX_FILE=" + tmpIndex}
+	_, err = gitOutput(repoPath, env, "add", "-A")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	diff, err := gitRaw(repoPath, env, "diff", "--cached", "--binary", "HEAD")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(diff) > 0 {
+		err = os.WriteFile(filepath.Join(l.Dir, localChangesDiff), diff, 0o600)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		l.Diff = true
+	}
+	// the dir is uploaded as is
+	err = os.Remove(tmpIndex)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// Empty is whether there is nothing the instance wouldn't get from the remote
+func (l LocalChanges) Empty() bool {
+	return !l.Bundle && !l.Diff
+}
+
+func (l LocalChanges) Cleanup() {
+	_ = os.RemoveAll(l.Dir)
+}
+
+// ApplyScript waits for setup to clone the project, then fetches the bundled
+// branches over the cloned ones, checks out the local HEAD and applies the diff
+func (l LocalChanges) ApplyScript(projectFolder string) string {
+	project := filesync.RemotePath(projectFolder)
+	// the script cds into the project so the upload can't stay home relative
+	remoteDir := `"$HOME"/` + filesync.RemotePath(localChangesRemoteDir)
+	var b strings.Builder
+	b.WriteString("set -e; ")
+	fmt.Fprintf(&b, `i=0; until git -C %[1]s rev-parse -q --verify HEAD >/dev/null 2>&1 && [ ! -f %[1]s/.git/index.lock ]; do i=$((i+1)); if [ $i -gt %[2]d ]; then echo "setup didn't clone %[3]s in time" >&2; exit 1; fi; sleep 5; done; `,
+		project, int(projectCloneTimeout/(5*time.Second)), projectFolder)
+	fmt.Fprintf(&b, "cd %s; ", project)
+	if l.Bundle {
+		fmt.Fprintf(&b, "git fetch -q --update-head-ok %s/%s '+refs/heads/*:refs/heads/*'; ", remoteDir, localChangesBundle)
+	}
+	fmt.Fprintf(&b, "git checkout -q -f %[1]s; git reset -q --hard %[1]s; ", filesync.ShellQuote(l.Head))
+	if l.Diff {
+		fmt.Fprintf(&b, "git apply --whitespace=nowarn %s/%s; ", remoteDir, localChangesDiff)
+	}
+	fmt.Fprintf(&b, "rm -rf %s", remoteDir)
+	return b.String()
+}
+
+// uploadLocalChanges copies the changes to the new instance once ssh is up
+// and applies them in its project folder
+func uploadLocalChanges(t *terminal.Terminal, startStore StartStore, workspaceName string, changes LocalChanges) error {
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(startStore, workspaceName)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	remote, err := cp.GetRemote(startStore, workspace.ID, false)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	s := t.NewSpinner()
+	s.Suffix = " waiting for ssh"
+	s.Start()
+	defer s.Stop()
+	opts := wait.DefaultOptions()
+	_, err = wait.Until(startStore, workspace.ID, wait.SSHCondition{}, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	s.Suffix = " uploading local changes"
+	err = remote.PushTree(changes.Dir, path.Dir(localChangesRemoteDir), path.Base(localChangesRemoteDir))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	s.Suffix = " applying local changes"
+	var out bytes.Buffer
+	err = remote.Run(changes.ApplyScript(workspace.GetProjectFolderPath()), &out)
+	if err != nil {
+		return breverrors.Wrap(err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+func describeLocalChanges(changes LocalChanges) string {
+	parts := []string{"on " + changes.Head}
+	if changes.Bundle {
+		parts = append(parts, "unpushed commits")
+	}
+	if changes.Diff {
+		parts = append(parts, "uncommitted changes")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func gitOutput(repoPath string, env []string, args ...string) (string, error) {
+	out, err := gitRaw(repoPath, env, args...)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func gitRaw(repoPath string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...) //nolint:gosec // fixed git subcommands
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, breverrors.Wrap(err, fmt.Sprintf("git %s: %s", args[0], strings.TrimSpace(stderr.String())))
+	}
+	return out, nil
+}
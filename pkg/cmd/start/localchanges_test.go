@@ -0,0 +1,103 @@
+package start
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=brev", "-c", "user.email=brev@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestLocalChangesRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	local := filepath.Join(root, "local")
+	remote := filepath.Join(root, "remote")
+	runGit(t, root, "init", "-q", "--bare", origin)
+	runGit(t, root, "clone", "-q", origin, local)
+	writeTestFile(t, filepath.Join(local, "a.txt"), "pushed\n")
+	runGit(t, local, "add", "-A")
+	runGit(t, local, "commit", "-q", "-m", "pushed")
+	runGit(t, local, "push", "-q", "origin", "HEAD")
+
+	runGit(t, local, "checkout", "-q", "-b", "wip")
+	writeTestFile(t, filepath.Join(local, "a.txt"), "committed\n")
+	runGit(t, local, "commit", "-q", "-am", "not pushed")
+	writeTestFile(t, filepath.Join(local, "a.txt"), "dirty\n")
+	writeTestFile(t, filepath.Join(local, "new.txt"), "untracked\n")
+	writeTestFile(t, filepath.Join(local, ".gitignore"), "ignored.txt\n")
+	writeTestFile(t, filepath.Join(local, "ignored.txt"), "ignored\n")
+
+	changes, err := PackageLocalChanges(local)
+	require.NoError(t, err)
+	defer changes.Cleanup()
+	assert.Equal(t, "wip", changes.Head)
+	assert.True(t, changes.Bundle)
+	assert.True(t, changes.Diff)
+	// the repo's own index is untouched
+	status, err := gitOutput(local, nil, "status", "--porcelain")
+	require.NoError(t, err)
+	assert.Contains(t, status, "?? new.txt")
+
+	// stand in for the instance: setup's clone and the uploaded changes under HOME
+	runGit(t, root, "clone", "-q", origin, remote)
+	home := filepath.Join(root, "home")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".brev"), 0o755))
+	require.NoError(t, os.Rename(changes.Dir, filepath.Join(home, ".brev", "local-changes")))
+	cmd := exec.Command("bash", "-c", changes.ApplyScript(remote))
+	cmd.Env = append(os.Environ(), "HOME="+home)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	head, err := gitOutput(remote, nil, "symbolic-ref", "--short", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "wip", head)
+	assert.Equal(t, "dirty\n", readTestFile(t, filepath.Join(remote, "a.txt")))
+	assert.Equal(t, "untracked\n", readTestFile(t, filepath.Join(remote, "new.txt")))
+	assert.NoFileExists(t, filepath.Join(remote, "ignored.txt"))
+	assert.NoDirExists(t, filepath.Join(home, ".brev", "local-changes"))
+}
+
+func TestLocalChangesClean(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	local := filepath.Join(root, "local")
+	runGit(t, root, "init", "-q", "--bare", origin)
+	runGit(t, root, "clone", "-q", origin, local)
+	writeTestFile(t, filepath.Join(local, "a.txt"), "pushed\n")
+	runGit(t, local, "add", "-A")
+	runGit(t, local, "commit", "-q", "-m", "pushed")
+	runGit(t, local, "push", "-q", "origin", "HEAD")
+
+	changes, err := PackageLocalChanges(local)
+	require.NoError(t, err)
+	defer changes.Cleanup()
+	assert.True(t, changes.Empty())
+}
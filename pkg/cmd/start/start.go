@@ -4,20 +4,26 @@ package start
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/cp"
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/config"
+	"github.com/brevdev/brev-cli/pkg/devcontainer"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	"github.com/brevdev/brev-cli/pkg/featureflag"
+	"github.com/brevdev/brev-cli/pkg/giturl"
 	"github.com/brevdev/brev-cli/pkg/instancetypes"
+	"github.com/brevdev/brev-cli/pkg/labels"
 	"github.com/brevdev/brev-cli/pkg/mergeshells"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	allutil "github.com/brevdev/brev-cli/pkg/util"
+	"github.com/brevdev/brev-cli/pkg/wait"
 	"github.com/spf13/cobra"
 
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -29,11 +35,15 @@ var (
   brev start <existing_ws_name>
   brev start <git url>
   brev start <git url> --org myFancyOrg
+  brev start <git url> --depth 1 --sparse services/api --lfs
+  brev start ./my-repo --with-local-changes
+  brev start ./my-repo --no-devcontainer
+  brev start --selector team=ml
 	`
 )
 
 type StartStore interface {
-	util.GetWorkspaceByNameOrIDErrStore
+	cp.CpStore
 	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
 	GetActiveOrganizationOrDefault() (*entity.Organization, error)
 	GetCurrentUser() (*entity.User, error)
@@ -55,6 +65,14 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 	var setupPath string
 	var gpu string
 	var cpu string
+	var selectorFlag string
+	var depth int
+	var noSubmodules bool
+	var lfs bool
+	var sparse []string
+	var commit string
+	var withLocalChanges bool
+	var noDevcontainer bool
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -65,6 +83,20 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 		Example:               startExample,
 		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStartStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if selectorFlag != "" {
+				if len(args) > 0 {
+					return breverrors.NewValidationError("pass either an instance or --selector")
+				}
+				selector, err := labels.ParseSelector(selectorFlag)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				err = startSelectedWorkspaces(t, startStore, selector, detached)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
 			repoOrPathOrNameOrID := ""
 			if len(args) > 0 {
 				repoOrPathOrNameOrID = args[0]
@@ -88,6 +120,9 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 				WorkspaceClass:       cpu,
 				Detached:             detached,
 				InstanceType:         gpu,
+				CloneOptions:         makeCloneOptions(cmd, depth, noSubmodules, lfs, sparse, commit),
+				WithLocalChanges:     withLocalChanges,
+				NoDevcontainer:       noDevcontainer,
 			}, startStore)
 			if err != nil {
 				if strings.Contains(err.Error(), "duplicate instance with name") {
@@ -108,8 +143,16 @@ func NewCmdStart(t *terminal.Terminal, startStore StartStore, noLoginStartStore
 	cmd.Flags().StringVarP(&setupRepo, "setup-repo", "r", "", "repo that holds env setup script. you must pass in --setup-path if you use this argument")
 	cmd.Flags().StringVarP(&setupPath, "setup-path", "p", "", "path to env setup script. If you include --setup-repo we will apply this argument to that repo")
 	cmd.Flags().StringVarP(&org, "org", "o", "", "organization (will override active org if creating a workspace)")
+	cmd.Flags().StringVarP(&selectorFlag, "selector", "l", "", "start your stopped instances matching this label selector, ex: team=ml,env!=prod")
+	cmd.Flags().IntVar(&depth, "depth", 0, "shallow clone the repo with only this many commits")
+	cmd.Flags().BoolVar(&noSubmodules, "no-submodules", false, "don't clone the repo's submodules")
+	cmd.Flags().BoolVar(&lfs, "lfs", false, "pull git lfs files after cloning the repo")
+	cmd.Flags().StringSliceVar(&sparse, "sparse", nil, "only check out these directories of the repo, can be repeated")
+	cmd.Flags().StringVar(&commit, "commit", "", "check out this commit sha of the repo")
+	cmd.Flags().BoolVar(&noDevcontainer, "no-devcontainer", false, "when starting from a local repo, don't set the instance up from its devcontainer.json")
+	cmd.Flags().BoolVar(&withLocalChanges, "with-local-changes", false, "when starting from a local repo, also bring its unpushed commits and uncommitted changes")
 	// GPU options
-	cmd.Flags().StringVarP(&gpu, "gpu", "g", "n1-highmem-4:nvidia-tesla-t4:1", "GPU instance type. See https://brev.dev/docs/reference/gpu for details")
+	cmd.Flags().StringVarP(&gpu, "gpu", "g", config.GlobalConfig.GetDefaultInstanceType(), "GPU instance type. See https://brev.dev/docs/reference/gpu for details")
 	err := cmd.RegisterFlagCompletionFunc("org", completions.GetOrgsNameCompletionHandler(noLoginStartStore, t))
 	if err != nil {
 		breverrors.GetDefaultErrorReporter().ReportError(breverrors.WrapAndTrace(err))
@@ -128,9 +171,58 @@ type StartOptions struct {
 	WorkspaceClass       string
 	Detached             bool
 	InstanceType         string
+	CloneOptions         *entity.GitRepoOptions // nil clones the repo in full
+	WithLocalChanges     bool
+	NoDevcontainer       bool
+	Devcontainer         *devcontainer.Translation // from the local repo's devcontainer.json
+}
+
+// loadDevcontainer translates the repo's devcontainer.json, nil when it has none
+func loadDevcontainer(t *terminal.Terminal, repoPath string, projectDir string) (*devcontainer.Translation, error) {
+	config, path, err := devcontainer.Load(repoPath)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if config == nil {
+		return nil, nil
+	}
+	translation := devcontainer.Translate(*config, projectDir, os.LookupEnv)
+	t.Vprintf("using %s: %d execs, %d ports, %d env vars\n", path, len(translation.ExecsV1), len(translation.PortMappings), len(translation.Env))
+	for _, w := range translation.Warnings {
+		t.Vprint(t.Yellow("devcontainer: %s", w))
+	}
+	return &translation, nil
+}
+
+// makeCloneOptions is nil unless a clone flag was passed
+func makeCloneOptions(cmd *cobra.Command, depth int, noSubmodules bool, lfs bool, sparse []string, commit string) *entity.GitRepoOptions {
+	flags := cmd.Flags()
+	if !flags.Changed("depth") && !flags.Changed("no-submodules") && !flags.Changed("lfs") && !flags.Changed("sparse") && !flags.Changed("commit") {
+		return nil
+	}
+	opts := &entity.GitRepoOptions{LFS: lfs, SparsePaths: sparse}
+	if depth > 0 {
+		opts.Depth = &depth
+	}
+	if noSubmodules {
+		submodules := false
+		opts.Submodules = &submodules
+	}
+	if commit != "" {
+		opts.Commit = &commit
+	}
+	return opts
 }
 
 func runStartWorkspace(t *terminal.Terminal, options StartOptions, startStore StartStore) error {
+	if options.WithLocalChanges {
+		if !allutil.DoesPathExist(options.RepoOrPathOrNameOrID) {
+			return breverrors.NewValidationError("--with-local-changes needs the path of a local repo, ex: brev start ./repo --with-local-changes")
+		}
+		if options.Detached {
+			return breverrors.NewValidationError("--with-local-changes can't be used with --detached, the changes are applied once the instance is up")
+		}
+	}
 	user, err := startStore.GetCurrentUser()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -225,7 +317,8 @@ func maybeStartStoppedOrJoin(t *terminal.Terminal, user *entity.User, options St
 }
 
 func maybeStartFromGitURL(t *terminal.Terminal, user *entity.User, options StartOptions, startStore StartStore) (bool, error) {
-	if allutil.IsGitURL(options.RepoOrPathOrNameOrID) { // todo this is function is not complete, some cloneable urls are not identified
+	// a local path that happens to look like host/owner/repo is started from the path
+	if !allutil.DoesPathExist(options.RepoOrPathOrNameOrID) && giturl.IsGitURL(options.RepoOrPathOrNameOrID) {
 		err := createNewWorkspaceFromGit(user, t, options.SetupScript, options, startStore)
 		if err != nil {
 			return true, breverrors.WrapAndTrace(err)
@@ -277,10 +370,33 @@ func startWorkspaceFromPath(user *entity.User, t *terminal.Terminal, options Sta
 	if options.RepoOrPathOrNameOrID == "." {
 		localSetupPath = filepath.Join(".brev", "setup.sh")
 	}
+	if !options.NoDevcontainer {
+		translation, dcErr := loadDevcontainer(t, options.RepoOrPathOrNameOrID, entity.GetDefaultProjectFolderNameFromRepo(gitURL))
+		if dcErr != nil {
+			return breverrors.WrapAndTrace(dcErr)
+		}
+		options.Devcontainer = translation
+	}
 	if !allutil.DoesPathExist(localSetupPath) {
-		fmt.Println(strings.Join([]string{"Generating setup script at", localSetupPath}, "\n"))
-		mergeshells.ImportPath(t, options.RepoOrPathOrNameOrID, startStore)
-		fmt.Println("setup script generated.")
+		if options.Devcontainer != nil {
+			// the devcontainer is the environment definition, don't make a second one
+			localSetupPath = ""
+		} else {
+			fmt.Println(strings.Join([]string{"Generating setup script at", localSetupPath}, "\n"))
+			mergeshells.ImportPath(t, options.RepoOrPathOrNameOrID, startStore)
+			fmt.Println("setup script generated.")
+		}
+	}
+
+	var changes *LocalChanges
+	if options.WithLocalChanges {
+		packed, packErr := PackageLocalChanges(options.RepoOrPathOrNameOrID)
+		if packErr != nil {
+			return breverrors.WrapAndTrace(packErr)
+		}
+		changes = packed
+		defer changes.Cleanup()
+		t.Vprintf("bringing local changes: %s\n", describeLocalChanges(*changes))
 	}
 
 	// createNewWorkspaceFromGit expects this field to be a git url, but above
@@ -292,7 +408,14 @@ func startWorkspaceFromPath(user *entity.User, t *terminal.Terminal, options Sta
 		return breverrors.WrapAndTrace(err)
 	}
 
-	return err
+	if changes != nil {
+		err = uploadLocalChanges(t, startStore, options.Name, *changes)
+		if err != nil {
+			return breverrors.Wrap(err, "the instance is up but the local changes weren't applied")
+		}
+		t.Vprint(t.Green("local changes applied\n"))
+	}
+	return nil
 }
 
 func createEmptyWorkspace(user *entity.User, t *terminal.Terminal, options StartOptions, startStore StartStore) error { //nolint:funlen,gocyclo // TODO refactor
@@ -406,6 +529,42 @@ func resolveWorkspaceUserOptions(options *store.CreateWorkspacesOptions, user *e
 	return options
 }
 
+// startSelectedWorkspaces starts every stopped instance the selector matches,
+// then waits for all of them unless detached
+func startSelectedWorkspaces(t *terminal.Terminal, startStore StartStore, selector labels.Selector, detached bool) error {
+	workspaces, err := util.GetUserWorkspacesBySelector(startStore, selector)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	started := []entity.Workspace{}
+	for _, w := range workspaces {
+		if w.Status != entity.Stopped {
+			continue
+		}
+		_, err = startStore.StartWorkspace(w.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf(t.Yellow("Instance %s is starting\n", w.Name))
+		started = append(started, w)
+	}
+	if len(started) == 0 {
+		t.Vprintf(t.Yellow("no stopped instances match %s\n", selector))
+		return nil
+	}
+	if detached {
+		return nil
+	}
+	for _, w := range started {
+		err = pollUntil(t, w.ID, entity.Running, startStore, true)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf(t.Green("\n%s is ready ✓\n", w.Name))
+	}
+	return nil
+}
+
 func startStopppedWorkspace(workspace *entity.Workspace, startStore StartStore, t *terminal.Terminal, startOptions StartOptions) error {
 	if workspace.Status != entity.Stopped {
 		return breverrors.NewValidationError(fmt.Sprintf("Instance is not stopped status=%s", workspace.Status))
@@ -560,47 +719,21 @@ func createNewWorkspaceFromGit(user *entity.User, t *terminal.Terminal, setupScr
 }
 
 type NewWorkspace struct {
-	Name    string `json:"name"`
-	GitRepo string `json:"gitRepo"`
+	Name        string `json:"name"`
+	GitRepo     string `json:"gitRepo"`
+	Ref         string `json:"ref,omitempty"` // from a repo#branch or repo@sha url
+	RefIsCommit bool   `json:"refIsCommit,omitempty"`
 }
 
+// MakeNewWorkspaceFromURL names the instance after the repo and keeps the
+// repo in the host:owner/name.git form setup clones from, or as a full url
+// when it has a port
 func MakeNewWorkspaceFromURL(url string) NewWorkspace {
-	var name string
-	if strings.Contains(url, "http") {
-		split := strings.Split(url, ".com/")
-		provider := strings.Split(split[0], "://")[1]
-
-		if strings.Contains(split[1], ".git") {
-			name = strings.Split(split[1], ".git")[0]
-			if strings.Contains(name, "/") {
-				name = strings.Split(name, "/")[1]
-			}
-			return NewWorkspace{
-				GitRepo: fmt.Sprintf("%s.com:%s", provider, split[1]),
-				Name:    name,
-			}
-		} else {
-			name = split[1]
-			if strings.Contains(name, "/") {
-				name = strings.Split(name, "/")[1]
-			}
-			return NewWorkspace{
-				GitRepo: fmt.Sprintf("%s.com:%s.git", provider, split[1]),
-				Name:    name,
-			}
-		}
-	} else {
-		split := strings.Split(url, ".com:")
-		provider := strings.Split(split[0], "@")[1]
-		name = strings.Split(split[1], ".git")[0]
-		if strings.Contains(name, "/") {
-			name = strings.Split(name, "/")[1]
-		}
-		return NewWorkspace{
-			GitRepo: fmt.Sprintf("%s.com:%s", provider, split[1]),
-			Name:    name,
-		}
+	u, err := giturl.Parse(url)
+	if err != nil {
+		return NewWorkspace{Name: entity.GetDefaultProjectFolderNameFromRepo(url), GitRepo: url}
 	}
+	return NewWorkspace{Name: u.Name, GitRepo: u.Remote(), Ref: u.Ref, RefIsCommit: u.RefIsCommit()}
 }
 
 func createWorkspace(user *entity.User, t *terminal.Terminal, workspace NewWorkspace, orgID string, startStore StartStore, startOptions StartOptions) error {
@@ -612,6 +745,17 @@ func createWorkspace(user *entity.User, t *terminal.Terminal, workspace NewWorks
 		options = options.WithWorkspaceClassID(startOptions.WorkspaceClass)
 	}
 
+	if workspace.Ref != "" && !workspace.RefIsCommit {
+		options.InitBranch = workspace.Ref
+	}
+	if cloneOptions := withRef(startOptions.CloneOptions, workspace); cloneOptions != nil {
+		options = options.WithGitRepoOptions(*cloneOptions)
+	}
+
+	if startOptions.Devcontainer != nil {
+		startOptions.Devcontainer.Apply(options)
+	}
+
 	options = resolveWorkspaceUserOptions(options, user)
 
 	if startOptions.SetupRepo != "" {
@@ -658,6 +802,26 @@ func createWorkspace(user *entity.User, t *terminal.Terminal, workspace NewWorks
 	return nil
 }
 
+// withRef adds the url's ref to the clone options, a shallow clone can only
+// be pinned to a sha as a commit. a ref without clone flags still gets options
+// so the pin isn't dropped
+func withRef(cloneOptions *entity.GitRepoOptions, workspace NewWorkspace) *entity.GitRepoOptions {
+	if workspace.Ref == "" {
+		return cloneOptions
+	}
+	opts := entity.GitRepoOptions{}
+	if cloneOptions != nil {
+		opts = *cloneOptions
+	}
+	ref := workspace.Ref
+	if workspace.RefIsCommit && opts.Commit == nil {
+		opts.Commit = &ref
+	} else if !workspace.RefIsCommit && opts.Branch == nil {
+		opts.Branch = &ref
+	}
+	return &opts
+}
+
 func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace) {
 	t.Vprintf(t.Green("Connect to the instance:\n"))
 	t.Vprintf(t.Yellow(fmt.Sprintf("\tbrev open %s\t# brev open <NAME> -> open instance in VS Code\n", workspace.Name)))
@@ -667,24 +831,22 @@ func displayConnectBreadCrumb(t *terminal.Terminal, workspace *entity.Workspace)
 
 func pollUntil(t *terminal.Terminal, wsid string, state string, startStore StartStore, canSafelyExit bool) error { //nolint:unparam // TODO refactor
 	s := t.NewSpinner()
-	isReady := false
 	if canSafelyExit {
 		t.Vprintf("You can safely ctrl+c to exit\n")
 	}
 	s.Suffix = " hang tight 🤙"
 	s.Start()
-	for !isReady {
-		time.Sleep(5 * time.Second)
-		ws, err := startStore.GetWorkspace(wsid)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		s.Suffix = "  instance is " + strings.ToLower(ws.Status)
-		if ws.Status == state {
-			s.Suffix = "Instance is ready!"
-			s.Stop()
-			isReady = true
-		}
+	opts := wait.DefaultOptions()
+	opts.InitialDelay = 5 * time.Second
+	opts.OnPoll = func(status string) {
+		s.Suffix = "  instance is " + strings.ToLower(status)
 	}
+	_, err := wait.Until(startStore, wsid, wait.StatusCondition{Status: state}, opts)
+	if err != nil {
+		s.Stop()
+		return breverrors.WrapAndTrace(err)
+	}
+	s.Suffix = "Instance is ready!"
+	s.Stop()
 	return nil
 }
@@ -42,6 +42,38 @@ func TestMakeNewWorkspaceFromURL(t *testing.T) {
 	}
 }
 
+func TestMakeNewWorkspaceFromURLSelfHosted(t *testing.T) {
+	res := MakeNewWorkspaceFromURL("https://gitlab.corp.io/group/sub/my.repo#dev")
+	assert.Equal(t, NewWorkspace{Name: "my.repo", GitRepo: "gitlab.corp.io:group/sub/my.repo.git", Ref: "dev"}, res)
+
+	res = MakeNewWorkspaceFromURL("git@bitbucket.org:team/api.git@4f2a9c1")
+	assert.Equal(t, NewWorkspace{Name: "api", GitRepo: "bitbucket.org:team/api.git", Ref: "4f2a9c1", RefIsCommit: true}, res)
+}
+
+func TestWithRef(t *testing.T) {
+	assert.Nil(t, withRef(nil, NewWorkspace{}))
+
+	opts := withRef(nil, NewWorkspace{Ref: "dev"})
+	assert.Equal(t, "dev", opts.GetBranch())
+
+	depth := 1
+	opts = withRef(&entity.GitRepoOptions{Depth: &depth}, NewWorkspace{Ref: "4f2a9c1", RefIsCommit: true})
+	assert.Equal(t, "4f2a9c1", opts.GetCommit())
+	assert.Equal(t, "", opts.GetBranch())
+
+	opts = withRef(&entity.GitRepoOptions{Depth: &depth}, NewWorkspace{Ref: "dev"})
+	assert.Equal(t, "dev", opts.GetBranch())
+}
+
+func TestWithRefCommitWithoutCloneFlags(t *testing.T) {
+	workspace := MakeNewWorkspaceFromURL("https://github.com/brevdev/brev-cli@4f2a9c1")
+	opts := withRef(nil, workspace)
+	if assert.NotNil(t, opts) {
+		assert.Equal(t, "4f2a9c1", opts.GetCommit())
+		assert.Equal(t, "", opts.GetBranch())
+	}
+}
+
 func Test_DisplayBC(t *testing.T) {
 	term := terminal.New()
 	displayConnectBreadCrumb(term, &entity.Workspace{
@@ -2,7 +2,9 @@ package status
 
 import (
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/spf13/cobra"
@@ -26,6 +28,8 @@ type StatusStore interface {
 }
 
 func NewCmdStatus(t *terminal.Terminal, statusStore StatusStore) *cobra.Command {
+	var outputFlag string
+
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
 		Use:                   "status",
@@ -34,13 +38,45 @@ func NewCmdStatus(t *terminal.Terminal, statusStore StatusStore) *cobra.Command
 		Long:                  createLong,
 		Example:               createExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if output.IsStructured() {
+				err = runShowStatusStructured(t, statusStore, *output)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return nil
+			}
 			runShowStatus(t, statusStore)
 			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", config.GlobalConfig.GetDefaultOutput(), terminal.OutputFlagUsage)
 	return cmd
 }
 
+func runShowStatusStructured(t *terminal.Terminal, statusStore StatusStore, output terminal.Output) error {
+	wsID, err := statusStore.GetCurrentWorkspaceID()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	ws, err := statusStore.GetWorkspace(wsID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	user, err := statusStore.GetCurrentUser()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = t.PrintStructured(output, util.NewWorkspaceOutput(*ws, user.ID))
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func runShowStatus(t *terminal.Terminal, statusStore StatusStore) {
 	terminal.DisplayBrevLogo(t)
 	t.Vprintf("\n")
@@ -9,6 +9,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/util"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
 	"github.com/hashicorp/go-multierror"
@@ -17,7 +18,7 @@ import (
 
 var (
 	stopLong    = "Stop a Brev machine that's in a running state"
-	stopExample = "brev stop <ws_name>... \nbrev stop --all"
+	stopExample = "brev stop <ws_name>... \nbrev stop --all\nbrev stop --selector team=ml"
 )
 
 type StopStore interface {
@@ -32,6 +33,7 @@ type StopStore interface {
 
 func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore StopStore) *cobra.Command {
 	var all bool
+	var selectorFlag string
 
 	cmd := &cobra.Command{
 		Annotations:           map[string]string{"workspace": ""},
@@ -43,6 +45,16 @@ func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore
 		// Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs()),
 		ValidArgsFunction: completions.GetAllWorkspaceNameCompletionHandler(noLoginStopStore, t),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if selectorFlag != "" {
+				if all || len(args) > 0 {
+					return breverrors.NewValidationError("pass either instance names, --all or --selector")
+				}
+				selector, err := labels.ParseSelector(selectorFlag)
+				if err != nil {
+					return breverrors.WrapAndTrace(err)
+				}
+				return stopSelectedWorkspaces(t, loginStopStore, selector)
+			}
 			if all {
 				return stopAllWorkspaces(t, loginStopStore)
 			} else {
@@ -64,6 +76,7 @@ func NewCmdStop(t *terminal.Terminal, loginStopStore StopStore, noLoginStopStore
 		},
 	}
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "stop all workspaces")
+	cmd.Flags().StringVarP(&selectorFlag, "selector", "l", "", labels.SelectorFlagUsage)
 
 	return cmd
 }
@@ -95,6 +108,29 @@ func stopAllWorkspaces(t *terminal.Terminal, stopStore StopStore) error {
 	return nil
 }
 
+func stopSelectedWorkspaces(t *terminal.Terminal, stopStore StopStore, selector labels.Selector) error {
+	workspaces, err := util.GetUserWorkspacesBySelector(stopStore, selector)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	stopped := 0
+	for _, w := range workspaces {
+		if w.Status != entity.Running {
+			continue
+		}
+		_, err = stopStore.StopWorkspace(w.ID)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf(t.Green("%s stopped ✓\n", w.Name))
+		stopped++
+	}
+	if stopped == 0 {
+		t.Vprintf(t.Yellow("no running instances match %s\n", selector))
+	}
+	return nil
+}
+
 func stopWorkspace(workspaceName string, t *terminal.Terminal, stopStore StopStore) error {
 	user, err := stopStore.GetCurrentUser()
 	if err != nil {
@@ -3,6 +3,7 @@ package tasks
 import (
 	"fmt"
 
+	"github.com/brevdev/brev-cli/pkg/autostop"
 	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
@@ -26,6 +27,7 @@ type TaskStore interface {
 	GetCurrentWorkspaceID() (string, error)
 	GetWorkspace(workspaceID string) (*entity.Workspace, error)
 	GetCurrentUser() (*entity.User, error)
+	IsWorkspace() (bool, error)
 	ssh.ConfigUpaterFactoryStore
 }
 
@@ -120,5 +122,9 @@ func getTaskMap(store TaskStore) TaskMap {
 	taskmap := make(TaskMap)
 	sshcd := ssh.NewSSHConfigurerTask(store)
 	taskmap["sshcd"] = sshcd
+	// autostop stops the machine it runs on, so it only exists on an instance
+	if isWorkspace, err := store.IsWorkspace(); err == nil && isWorkspace {
+		taskmap["autostop"] = autostop.NewIdleStopTask(store)
+	}
 	return taskmap
 }
@@ -14,9 +14,9 @@ import (
 	"github.com/brevdev/brev-cli/pkg/autostartconf"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/giturl"
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/terminal"
-	"github.com/brevdev/parse/pkg/parse"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 )
@@ -137,7 +137,7 @@ func (u updateModel) RunE(_ *cobra.Command, _ []string) error { //nolint:funlen
 	urls = lo.Filter(
 		urls,
 		func(url string, _ int) bool {
-			return !parse.CMP(url, dotbrev)
+			return !giturl.Same(url, dotbrev)
 		},
 	)
 
@@ -146,7 +146,7 @@ func (u updateModel) RunE(_ *cobra.Command, _ []string) error { //nolint:funlen
 	// merge reposv0 and gitrepo field into reposv1fromBE
 
 	reposv1FromBE := make(entity.ReposV1)
-	reposv1FromBE[entity.RepoName(parse.GetRepoNameFromOrigin(workspace.GitRepo))] = entity.RepoV1{
+	reposv1FromBE[entity.RepoName(entity.GetDefaultProjectFolderNameFromRepo(workspace.GitRepo))] = entity.RepoV1{
 		GitRepo: entity.GitRepo{
 			Repository: workspace.GitRepo,
 		},
@@ -290,8 +290,12 @@ func makeReposFromRemotes(remotes []string) *entity.ReposV1 {
 	return lo.Reduce(
 		remotes,
 		func(acc *entity.ReposV1, remote string, _ int) *entity.ReposV1 {
-			name := parse.GetRepoNameFromOrigin(remote)
-			url := parse.GetSSHURLFromOrigin(remote)
+			u, err := giturl.Parse(remote)
+			if err != nil {
+				// not a remote brev can clone
+				return acc
+			}
+			name, url := u.Name, u.SSH()
 			a := *acc
 			a[entity.RepoName(name)] = entity.RepoV1{
 				Type: entity.GitRepoType,
@@ -319,7 +323,7 @@ func (r *repoMerger) MergeBE() *entity.ReposV1 {
 			_, valueInAcc := lo.Find(
 				r.accValues(),
 				func(repo *entity.RepoV1) bool {
-					return repo.GitRepo.Repository == v.GitRepo.Repository
+					return giturl.Same(repo.GitRepo.Repository, v.GitRepo.Repository)
 				},
 			)
 			if valueInAcc {
@@ -339,7 +343,7 @@ func (r *repoMerger) ReposToClone() []*entity.RepoV1 {
 			_, valueInENV := lo.Find(
 				r.reposValues(),
 				func(repo *entity.RepoV1) bool {
-					return accrepo.GitRepo.Repository == repo.GitRepo.Repository
+					return giturl.Same(accrepo.GitRepo.Repository, repo.GitRepo.Repository)
 				},
 			)
 			return !valueInENV
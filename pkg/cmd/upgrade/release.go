@@ -0,0 +1,101 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+const binaryName = "brev"
+
+// normalizeTag turns 0.6.310 into v0.6.310, the form the releases are tagged with
+func normalizeTag(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" || strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
+// archiveName is what goreleaser names the archive for a platform
+func archiveName(tag, goos, goarch string) string {
+	return fmt.Sprintf("brev-cli_%s_%s_%s.tar.gz", strings.TrimPrefix(tag, "v"), goos, goarch)
+}
+
+func checksumsName(tag string) string {
+	return fmt.Sprintf("brev-cli_%s_checksums.txt", strings.TrimPrefix(tag, "v"))
+}
+
+func findAsset(release *store.GithubReleaseMetadata, name string) (store.GithubReleaseAsset, error) {
+	for _, a := range release.Assets {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return store.GithubReleaseAsset{}, breverrors.NewValidationError(fmt.Sprintf("release %s has no %s", release.TagName, name))
+}
+
+// parseChecksums reads goreleaser's "<sha256>  <file>" lines
+func parseChecksums(data []byte) map[string]string {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+func verifyChecksum(name string, data []byte, checksums []byte) error {
+	want, ok := parseChecksums(checksums)[name]
+	if !ok {
+		return breverrors.NewValidationError(fmt.Sprintf("no checksum listed for %s", name))
+	}
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	if got != want {
+		return breverrors.NewValidationError(fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", name, want, got))
+	}
+	return nil
+}
+
+// extractBinary pulls the brev binary out of a release archive
+func extractBinary(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	defer gz.Close() //nolint:errcheck // defer
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, nextErr := tr.Next()
+		if nextErr == io.EOF {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("archive has no %s binary", binaryName))
+		}
+		if nextErr != nil {
+			return nil, breverrors.WrapAndTrace(nextErr)
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != binaryName {
+			continue
+		}
+		bin, readErr := io.ReadAll(tr)
+		if readErr != nil {
+			return nil, breverrors.WrapAndTrace(readErr)
+		}
+		return bin, nil
+	}
+}
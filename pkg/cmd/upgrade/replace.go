@@ -0,0 +1,113 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const previousSuffix = ".previous"
+
+// currentExecutable is the real path of the running binary, symlinks resolved
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return exe, nil
+}
+
+func previousPath(exe string) string {
+	return exe + previousSuffix
+}
+
+// writeSibling writes data to a temp file next to target so it can be renamed
+// over it, renames within a directory are atomic
+func writeSibling(target string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+"-*")
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	_, err = f.Write(data)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o755) //nolint:gosec // it's an executable
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return f.Name(), nil
+}
+
+// replaceBinary swaps bin in at exe and keeps what was there as exe.previous
+func replaceBinary(exe string, bin []byte) error {
+	if runtime.GOOS == "windows" {
+		return breverrors.NewValidationError("brev upgrade can't replace a running binary on windows")
+	}
+	current, err := os.ReadFile(exe) //nolint:gosec // our own binary
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	prevTmp, err := writeSibling(exe, current)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	newTmp, err := writeSibling(exe, bin)
+	if err != nil {
+		_ = os.Remove(prevTmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(prevTmp, previousPath(exe))
+	if err != nil {
+		_ = os.Remove(prevTmp)
+		_ = os.Remove(newTmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(newTmp, exe)
+	if err != nil {
+		_ = os.Remove(newTmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// rollbackBinary puts exe.previous back and keeps the replaced binary as the
+// new exe.previous, so a second rollback undoes the first
+func rollbackBinary(exe string) error {
+	prev := previousPath(exe)
+	if _, err := os.Stat(prev); err != nil {
+		if os.IsNotExist(err) {
+			return breverrors.NewValidationError("there is no previous version to roll back to")
+		}
+		return breverrors.WrapAndTrace(err)
+	}
+	current, err := os.ReadFile(exe) //nolint:gosec // our own binary
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	currentTmp, err := writeSibling(exe, current)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(prev, exe)
+	if err != nil {
+		_ = os.Remove(currentTmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(currentTmp, prev)
+	if err != nil {
+		_ = os.Remove(currentTmp)
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package upgrade replaces the running brev with another release
+package upgrade
+
+import (
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/version"
+	"github.com/brevdev/brev-cli/pkg/config"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeLong = `Download a brev release, check it against the release checksums and swap it
+in for the running binary. The binary it replaces is kept so --rollback can put
+it back. Without --version this upgrades to the latest release, or to the
+version pinned with: brev config set pinned-version <version>`
+	upgradeExample = `  brev upgrade
+  brev upgrade --version v0.6.310
+  brev upgrade --rollback`
+)
+
+type UpgradeStore interface {
+	GetLatestReleaseMetadata() (*store.GithubReleaseMetadata, error)
+	GetReleaseMetadata(tag string) (*store.GithubReleaseMetadata, error)
+	DownloadReleaseAsset(url string) ([]byte, error)
+}
+
+type Options struct {
+	Version  string
+	Rollback bool
+	Force    bool
+	// Pinned is the configured version to stay on, Version overrides it
+	Pinned string
+}
+
+func NewCmdUpgrade(t *terminal.Terminal, store UpgradeStore) *cobra.Command {
+	opts := Options{}
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"housekeeping": ""},
+		Use:                   "upgrade",
+		DisableFlagsInUseLine: true,
+		Short:                 "Upgrade brev to the latest or a given release",
+		Long:                  upgradeLong,
+		Example:               upgradeExample,
+		Args:                  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Pinned = config.GlobalConfig.GetPinnedVersion()
+			err := RunUpgrade(t, store, opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Version, "version", "", "release to install, e.g. v0.6.310")
+	cmd.Flags().BoolVar(&opts.Rollback, "rollback", false, "go back to the binary the last upgrade replaced")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "reinstall even if already on that version")
+
+	return cmd
+}
+
+func RunUpgrade(t *terminal.Terminal, store UpgradeStore, opts Options) error {
+	exe, err := currentExecutable()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if strings.Contains(exe, "/Cellar/") {
+		return breverrors.NewValidationError("brev was installed with homebrew, run: brew upgrade brev")
+	}
+	return withPermissionHint(upgrade(t, store, exe, opts))
+}
+
+func upgrade(t *terminal.Terminal, store UpgradeStore, exe string, opts Options) error {
+	if opts.Rollback {
+		err := rollbackBinary(exe)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		t.Vprintf("%s rolled back %s to the previous binary\n", t.Green("✓"), exe)
+		return nil
+	}
+
+	release, err := resolveRelease(t, store, opts)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if release.TagName == version.Version && !opts.Force {
+		t.Vprintf("brev is already on %s\n", release.TagName)
+		return nil
+	}
+
+	bin, err := downloadRelease(t, store, release)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = replaceBinary(exe, bin)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	from := version.Version
+	if from == "" {
+		from = "a dev build"
+	}
+	t.Vprintf("%s upgraded brev from %s to %s\n", t.Green("✓"), from, release.TagName)
+	t.Vprintf("run %s to go back\n", t.Yellow("brev upgrade --rollback"))
+	return nil
+}
+
+// resolveRelease picks --version, then the pinned version, then the latest release
+func resolveRelease(t *terminal.Terminal, store UpgradeStore, opts Options) (*store.GithubReleaseMetadata, error) {
+	tag := normalizeTag(opts.Version)
+	pinned := normalizeTag(opts.Pinned)
+	switch {
+	case tag != "" && pinned != "" && tag != pinned:
+		t.Vprint(t.Yellow("brev is pinned to %s, installing %s because --version was given\n", pinned, tag))
+	case tag == "" && pinned != "":
+		t.Vprintf("brev is pinned to %s\n", pinned)
+		tag = pinned
+	}
+
+	if tag == "" {
+		release, err := store.GetLatestReleaseMetadata()
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return release, nil
+	}
+	release, err := store.GetReleaseMetadata(tag)
+	if err != nil {
+		return nil, breverrors.Wrap(err, fmt.Sprintf("couldn't find release %s", tag))
+	}
+	return release, nil
+}
+
+// downloadRelease fetches this platform's archive, verifies it and returns the binary inside
+func downloadRelease(t *terminal.Terminal, store UpgradeStore, release *store.GithubReleaseMetadata) ([]byte, error) {
+	name := archiveName(release.TagName, runtime.GOOS, runtime.GOARCH)
+	archiveAsset, err := findAsset(release, name)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	sumsAsset, err := findAsset(release, checksumsName(release.TagName))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	t.Vprintf("downloading %s\n", name)
+	archive, err := store.DownloadReleaseAsset(archiveAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	sums, err := store.DownloadReleaseAsset(sumsAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = verifyChecksum(name, archive, sums)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+
+	bin, err := extractBinary(archive)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return bin, nil
+}
+
+func withPermissionHint(err error) error {
+	if breverrors.Is(err, fs.ErrPermission) {
+		return breverrors.Wrap(err, "no permission to replace the brev binary, try: sudo brev upgrade")
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+)
+
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type fakeStore struct {
+	latest   string
+	releases map[string]*store.GithubReleaseMetadata
+	assets   map[string][]byte
+}
+
+func newFakeStore(t *testing.T, latest string, tags ...string) *fakeStore {
+	f := &fakeStore{latest: latest, releases: map[string]*store.GithubReleaseMetadata{}, assets: map[string][]byte{}}
+	for _, tag := range tags {
+		name := archiveName(tag, runtime.GOOS, runtime.GOARCH)
+		archive := makeArchive(t, map[string]string{"README.md": "readme", binaryName: "brev " + tag})
+		sums := fmt.Sprintf("%s  %s\n%s  brev-cli_other.tar.gz\n", sha(archive), name, sha([]byte("x")))
+		f.assets["https://dl/"+name] = archive
+		f.assets["https://dl/"+checksumsName(tag)] = []byte(sums)
+		f.releases[tag] = &store.GithubReleaseMetadata{TagName: tag, Assets: []store.GithubReleaseAsset{
+			{Name: name, BrowserDownloadURL: "https://dl/" + name},
+			{Name: checksumsName(tag), BrowserDownloadURL: "https://dl/" + checksumsName(tag)},
+		}}
+	}
+	return f
+}
+
+func (f *fakeStore) GetLatestReleaseMetadata() (*store.GithubReleaseMetadata, error) {
+	return f.releases[f.latest], nil
+}
+
+func (f *fakeStore) GetReleaseMetadata(tag string) (*store.GithubReleaseMetadata, error) {
+	r, ok := f.releases[tag]
+	if !ok {
+		return nil, errors.New("404 not found")
+	}
+	return r, nil
+}
+
+func (f *fakeStore) DownloadReleaseAsset(url string) ([]byte, error) {
+	return f.assets[url], nil
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "v0.6.310", normalizeTag("0.6.310"))
+	assert.Equal(t, "v0.6.310", normalizeTag(" v0.6.310 "))
+	assert.Equal(t, "", normalizeTag(""))
+	assert.Equal(t, "brev-cli_0.6.310_linux_arm64.tar.gz", archiveName("v0.6.310", "linux", "arm64"))
+	assert.Equal(t, "brev-cli_0.6.310_checksums.txt", checksumsName("v0.6.310"))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("archive")
+	sums := []byte(sha(data) + "  brev-cli_1_linux_amd64.tar.gz\n\nbad line here\n")
+	assert.NoError(t, verifyChecksum("brev-cli_1_linux_amd64.tar.gz", data, sums))
+	assert.ErrorContains(t, verifyChecksum("brev-cli_1_linux_amd64.tar.gz", []byte("tampered"), sums), "checksum mismatch")
+	assert.ErrorContains(t, verifyChecksum("brev-cli_1_darwin_arm64.tar.gz", data, sums), "no checksum listed")
+}
+
+func TestExtractBinary(t *testing.T) {
+	bin, err := extractBinary(makeArchive(t, map[string]string{"LICENSE": "mit", "brev": "binary"}))
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(bin))
+
+	_, err = extractBinary(makeArchive(t, map[string]string{"LICENSE": "mit"}))
+	assert.ErrorContains(t, err, "no brev binary")
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path) //nolint:gosec // test
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestUpgradeAndRollback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("can't replace a running binary on windows")
+	}
+	exe := filepath.Join(t.TempDir(), "brev")
+	require.NoError(t, os.WriteFile(exe, []byte("brev old"), 0o755)) //nolint:gosec // test
+	s := newFakeStore(t, "v2.0.0", "v1.0.0", "v2.0.0")
+	term := terminal.New()
+
+	assert.ErrorContains(t, upgrade(term, s, exe, Options{Rollback: true}), "no previous version")
+
+	require.NoError(t, upgrade(term, s, exe, Options{}))
+	assert.Equal(t, "brev v2.0.0", readFile(t, exe))
+	assert.Equal(t, "brev old", readFile(t, previousPath(exe)))
+
+	// the pin wins over latest, --version wins over the pin
+	require.NoError(t, upgrade(term, s, exe, Options{Pinned: "1.0.0"}))
+	assert.Equal(t, "brev v1.0.0", readFile(t, exe))
+	require.NoError(t, upgrade(term, s, exe, Options{Pinned: "1.0.0", Version: "v2.0.0"}))
+	assert.Equal(t, "brev v2.0.0", readFile(t, exe))
+
+	require.NoError(t, upgrade(term, s, exe, Options{Rollback: true}))
+	assert.Equal(t, "brev v1.0.0", readFile(t, exe))
+	assert.Equal(t, "brev v2.0.0", readFile(t, previousPath(exe)))
+
+	assert.ErrorContains(t, upgrade(term, s, exe, Options{Version: "v9.9.9"}), "couldn't find release v9.9.9")
+
+	entries, err := os.ReadDir(filepath.Dir(exe))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "temp files are cleaned up")
+}
+
+func TestUpgradeRejectsBadChecksum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("can't replace a running binary on windows")
+	}
+	exe := filepath.Join(t.TempDir(), "brev")
+	require.NoError(t, os.WriteFile(exe, []byte("brev old"), 0o755)) //nolint:gosec // test
+	s := newFakeStore(t, "v2.0.0", "v2.0.0")
+	s.assets["https://dl/"+archiveName("v2.0.0", runtime.GOOS, runtime.GOARCH)] = makeArchive(t, map[string]string{"brev": "evil"})
+
+	assert.ErrorContains(t, upgrade(terminal.New(), s, exe, Options{}), "checksum mismatch")
+	assert.Equal(t, "brev old", readFile(t, exe))
+	assert.NoFileExists(t, previousPath(exe))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/labels"
 	"github.com/brevdev/brev-cli/pkg/store"
 )
 
@@ -35,6 +36,29 @@ func GetUserWorkspaceByNameOrIDErr(storeQ GetWorkspaceByNameOrIDErrStore, worksp
 	return &workspaces[0], nil
 }
 
+type GetUserWorkspacesStore interface {
+	GetActiveOrganizationOrDefault() (*entity.Organization, error)
+	GetCurrentUser() (*entity.User, error)
+	GetWorkspaces(organizationID string, options *store.GetWorkspacesOptions) ([]entity.Workspace, error)
+}
+
+// GetUserWorkspacesBySelector returns the user's instances in the active org whose labels match selector
+func GetUserWorkspacesBySelector(storeQ GetUserWorkspacesStore, selector labels.Selector) ([]entity.Workspace, error) {
+	user, err := storeQ.GetCurrentUser()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	org, err := storeQ.GetActiveOrganizationOrDefault()
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	workspaces, err := storeQ.GetWorkspaces(org.ID, &store.GetWorkspacesOptions{UserID: user.ID})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return selector.Filter(workspaces), nil
+}
+
 func GetAnyWorkspaceByIDOrNameInActiveOrgErr(storeQ GetWorkspaceByNameOrIDErrStore, workspaceNameOrID string) (*entity.Workspace, error) {
 	org, err := storeQ.GetActiveOrganizationOrDefault()
 	if err != nil {
@@ -94,3 +118,83 @@ func GetInstanceString(w entity.Workspace) string {
 	}
 	return instanceString
 }
+
+// WorkspaceOutput is the stable shape of an instance for --output json|yaml,
+// decoupled from the api entity so scripts don't break when it changes
+type WorkspaceOutput struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Status           string            `json:"status"`
+	HealthStatus     string            `json:"healthStatus"`
+	DisplayStatus    string            `json:"displayStatus"`
+	OrganizationID   string            `json:"organizationId"`
+	WorkspaceGroupID string            `json:"workspaceGroupId"`
+	WorkspaceClassID string            `json:"workspaceClassId,omitempty"`
+	InstanceType     string            `json:"instanceType,omitempty"`
+	Machine          string            `json:"machine"`
+	DNS              string            `json:"dns"`
+	SSHAlias         string            `json:"sshAlias"`
+	CreatedByUserID  string            `json:"createdByUserId"`
+	Shared           bool              `json:"shared"`
+	GitRepo          string            `json:"gitRepo,omitempty"`
+	VerbBuildStatus  string            `json:"verbBuildStatus,omitempty"`
+	StatusMessage    string            `json:"statusMessage,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+}
+
+func GetWorkspaceDisplayStatus(w entity.Workspace) string {
+	status := w.Status
+	if w.Status == entity.Running && w.HealthStatus == entity.Unhealthy {
+		status = w.HealthStatus
+	}
+	return status
+}
+
+func NewWorkspaceOutput(w entity.Workspace, userID string) WorkspaceOutput {
+	return WorkspaceOutput{
+		ID:               w.ID,
+		Name:             w.Name,
+		Status:           w.Status,
+		HealthStatus:     w.HealthStatus,
+		DisplayStatus:    GetWorkspaceDisplayStatus(w),
+		OrganizationID:   w.OrganizationID,
+		WorkspaceGroupID: w.WorkspaceGroupID,
+		WorkspaceClassID: w.WorkspaceClassID,
+		InstanceType:     w.InstanceType,
+		Machine:          GetInstanceString(w),
+		DNS:              w.DNS,
+		SSHAlias:         string(w.GetLocalIdentifier()),
+		CreatedByUserID:  w.CreatedByUserID,
+		Shared:           w.IsShared(userID),
+		GitRepo:          w.GitRepo,
+		VerbBuildStatus:  string(w.VerbBuildStatus),
+		StatusMessage:    w.StatusMessage,
+		Labels:           w.Labels,
+	}
+}
+
+func NewWorkspaceOutputs(workspaces []entity.Workspace, userID string) []WorkspaceOutput {
+	outputs := []WorkspaceOutput{}
+	for _, w := range workspaces {
+		outputs = append(outputs, NewWorkspaceOutput(w, userID))
+	}
+	return outputs
+}
+
+type OrganizationOutput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+func NewOrganizationOutputs(orgs []entity.Organization, activeOrg *entity.Organization) []OrganizationOutput {
+	outputs := []OrganizationOutput{}
+	for _, o := range orgs {
+		outputs = append(outputs, OrganizationOutput{
+			ID:     o.ID,
+			Name:   o.Name,
+			Active: activeOrg != nil && o.ID == activeOrg.ID,
+		})
+	}
+	return outputs
+}
@@ -0,0 +1,136 @@
+// Package wait blocks until an instance reaches a readiness condition
+package wait
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brevdev/brev-cli/pkg/cmd/cmderrors"
+	"github.com/brevdev/brev-cli/pkg/cmd/completions"
+	"github.com/brevdev/brev-cli/pkg/cmd/refresh"
+	"github.com/brevdev/brev-cli/pkg/cmd/util"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/terminal"
+	workspacewait "github.com/brevdev/brev-cli/pkg/wait"
+)
+
+var (
+	waitLong = `Block until an instance reaches a condition, for scripts and CI pipelines.
+
+Conditions:
+  status=STATUS   instance status, ex: RUNNING, STOPPED
+  health=STATUS   health status, ex: HEALTHY
+  verb=STATUS     verb build status, ex: COMPLETED
+  ssh             instance is running and accepts ssh connections
+
+Repeat --for to wait for several conditions in order.
+
+Exit codes:
+  0  all conditions met
+  1  error talking to brev
+  2  timed out
+  3  condition can no longer be met, ex: the instance failed`
+	waitExample = `
+  brev wait my-instance
+  brev wait my-instance --for ssh --timeout 10m
+  brev wait my-instance --for status=RUNNING --for verb=COMPLETED
+	`
+)
+
+type WaitStore interface {
+	util.GetWorkspaceByNameOrIDErrStore
+	refresh.RefreshStore
+	completions.CompletionStore
+	workspacewait.WorkspaceGetter
+}
+
+type WaitOptions struct {
+	Conditions []string
+	Timeout    time.Duration
+	Host       bool
+}
+
+func NewCmdWait(t *terminal.Terminal, store WaitStore, noLoginStore WaitStore) *cobra.Command {
+	var opts WaitOptions
+
+	cmd := &cobra.Command{
+		Annotations:           map[string]string{"workspace": ""},
+		Use:                   "wait",
+		DisableFlagsInUseLine: true,
+		Short:                 "Wait for an instance to be ready",
+		Long:                  waitLong,
+		Example:               waitExample,
+		Args:                  cmderrors.TransformToValidationError(cobra.ExactArgs(1)),
+		ValidArgsFunction:     completions.GetAllWorkspaceNameCompletionHandler(noLoginStore, t),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := RunWait(t, store, args[0], opts)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&opts.Conditions, "for", []string{"status=RUNNING"}, "condition to wait for: status=STATUS, health=STATUS, verb=STATUS or ssh")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 10*time.Minute, "give up after this long, 0 waits forever")
+	cmd.Flags().BoolVar(&opts.Host, "host", false, "with --for ssh, wait for the host machine instead of the container")
+	return cmd
+}
+
+func RunWait(t *terminal.Terminal, store WaitStore, workspaceNameOrID string, opts WaitOptions) error {
+	conditions := []workspacewait.Condition{}
+	for _, c := range opts.Conditions {
+		condition, err := workspacewait.ParseCondition(c)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		if sshCondition, ok := condition.(workspacewait.SSHCondition); ok {
+			sshCondition.Host = opts.Host
+			condition = sshCondition
+		}
+		conditions = append(conditions, condition)
+	}
+
+	workspace, err := util.GetUserWorkspaceByNameOrIDErr(store, workspaceNameOrID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+
+	s := t.NewSpinner()
+	s.Start()
+	defer s.Stop()
+	for _, condition := range conditions {
+		if _, ok := condition.(workspacewait.SSHCondition); ok {
+			// the alias has to be in the ssh config before we can probe it
+			err = refresh.RunRefresh(store)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+
+		waitOpts := workspacewait.DefaultOptions()
+		if !deadline.IsZero() {
+			waitOpts.Timeout = time.Until(deadline)
+			if waitOpts.Timeout <= 0 {
+				return workspacewait.TimeoutError{Condition: condition.String(), LastState: "not checked", Timeout: opts.Timeout}
+			}
+		}
+		conditionName := condition.String()
+		waitOpts.OnPoll = func(state string) {
+			s.Suffix = " waiting for " + conditionName + ", instance is " + strings.ToLower(state)
+		}
+		_, err = workspacewait.Until(store, workspace.ID, condition, waitOpts)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	s.Stop()
+	t.Vprintf(t.Green("%s is ready: %s\n", workspace.Name, strings.Join(opts.Conditions, ", ")))
+	return nil
+}
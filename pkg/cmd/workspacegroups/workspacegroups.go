@@ -6,6 +6,7 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/terminal"
@@ -17,6 +18,8 @@ type WorkspaceGroupsStore interface {
 }
 
 func NewCmdWorkspaceGroups(t *terminal.Terminal, store WorkspaceGroupsStore) *cobra.Command {
+	var outputFlag string
+
 	cmd := &cobra.Command{
 		Use:                   "workspacegroups",
 		DisableFlagsInUseLine: true,
@@ -24,17 +27,22 @@ func NewCmdWorkspaceGroups(t *terminal.Terminal, store WorkspaceGroupsStore) *co
 		Long:                  "TODO",
 		Example:               "TODO",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := RunWorkspaceGroups(t, args, store)
+			output, err := terminal.ParseOutput(outputFlag)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			err = RunWorkspaceGroups(t, args, store, *output)
 			if err != nil {
 				return breverrors.WrapAndTrace(err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", config.GlobalConfig.GetDefaultOutput(), terminal.OutputFlagUsage)
 	return cmd
 }
 
-func RunWorkspaceGroups(_ *terminal.Terminal, _ []string, store WorkspaceGroupsStore) error {
+func RunWorkspaceGroups(t *terminal.Terminal, _ []string, store WorkspaceGroupsStore, output terminal.Output) error {
 	org, err := store.GetActiveOrganizationOrDefault()
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -43,16 +51,31 @@ func RunWorkspaceGroups(_ *terminal.Terminal, _ []string, store WorkspaceGroupsS
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	if output.IsStructured() {
+		err = t.PrintStructured(output, wsgs)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	}
 
 	ta := table.NewWriter()
 	ta.SetOutputMirror(os.Stdout)
 	ta.Style().Options = getBrevTableOptions()
 	header := table.Row{"NAME", "PLATFORM ID", "PLATFORM TYPE"}
+	if output.IsWide() {
+		header = table.Row{"NAME", "ID", "PLATFORM ID", "PLATFORM TYPE", "REGION", "STATUS", "VERSION"}
+	}
 	ta.AppendHeader(header)
 	for _, w := range wsgs {
 		workspaceRow := []table.Row{{
 			w.Name, w.PlatformID, w.Platform,
 		}}
+		if output.IsWide() {
+			workspaceRow = []table.Row{{
+				w.Name, w.ID, w.PlatformID, w.Platform, w.PlatformRegion, w.Status, w.Version,
+			}}
+		}
 		ta.AppendRows(workspaceRow)
 	}
 	ta.Render()
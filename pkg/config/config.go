@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"sync"
 )
 
 type EnvVarName string // should be caps with underscore
@@ -16,6 +19,15 @@ const (
 	sentryURL                EnvVarName = "DEFAULT_SENTRY_URL"
 	debugHTTP                EnvVarName = "DEBUG_HTTP"
 	ollamaAPIURL             EnvVarName = "OLLAMA_API_URL"
+	pinnedVersion            EnvVarName = "BREV_PINNED_VERSION"
+	cloudflaredMirror        EnvVarName = "BREV_CLOUDFLARED_MIRROR"
+	cloudflaredSHA256        EnvVarName = "BREV_CLOUDFLARED_SHA256"
+	defaultOrg               EnvVarName = "BREV_ORG"
+	defaultInstanceType      EnvVarName = "BREV_INSTANCE_TYPE"
+	defaultEditor            EnvVarName = "BREV_EDITOR"
+	defaultEditorCommand     EnvVarName = "BREV_EDITOR_COMMAND"
+	defaultOutput            EnvVarName = "BREV_OUTPUT"
+	analyticsOptOut          EnvVarName = "BREV_ANALYTICS_OPT_OUT"
 )
 
 var ConsoleBaseURL = "https://console.brev.dev"
@@ -27,7 +39,7 @@ func NewConstants() *ConstantsConfig {
 }
 
 func (c ConstantsConfig) GetBrevAPIURl() string {
-	return getEnvOrDefault(brevAPIURL, "https://brevapi.us-west-2-prod.control-plane.brev.dev")
+	return getSettingOrDefault("api-url", "https://brevapi.us-west-2-prod.control-plane.brev.dev")
 }
 
 func (c ConstantsConfig) GetOllamaAPIURL() string {
@@ -39,7 +51,30 @@ func (c ConstantsConfig) GetDefaultClusterID() string {
 }
 
 func (c ConstantsConfig) GetDefaultWorkspaceClass() string {
-	return getEnvOrDefault(defaultWorkspaceClass, "")
+	return getSettingOrDefault("workspace-class", "")
+}
+
+// GetDefaultOrg is the org name or id to use when brev set hasn't picked one
+func (c ConstantsConfig) GetDefaultOrg() string {
+	return getSettingOrDefault("org", "")
+}
+
+// GetOrgOverride is the org BREV_ORG picks, it wins over brev set
+func (c ConstantsConfig) GetOrgOverride() string {
+	return getEnvOrDefault(defaultOrg, "")
+}
+
+func (c ConstantsConfig) GetDefaultInstanceType() string {
+	return getSettingOrDefault("instance-type", "n1-highmem-4:nvidia-tesla-t4:1")
+}
+
+func (c ConstantsConfig) GetDefaultOutput() string {
+	return getSettingOrDefault("output", "")
+}
+
+func (c ConstantsConfig) GetAnalyticsOptOut() bool {
+	optOut, _ := strconv.ParseBool(getSettingOrDefault("analytics-opt-out", "false"))
+	return optOut
 }
 
 func (c ConstantsConfig) GetDefaultWorkspaceTemplate() string {
@@ -47,6 +82,21 @@ func (c ConstantsConfig) GetDefaultWorkspaceTemplate() string {
 	return getEnvOrDefault(defaultWorkspaceTemplate, "")
 }
 
+// GetPinnedVersion is the release brev upgrade sticks to, empty means latest
+func (c ConstantsConfig) GetPinnedVersion() string {
+	return getSettingOrDefault("pinned-version", "")
+}
+
+// GetCloudflaredMirror replaces github as the cloudflared download source
+func (c ConstantsConfig) GetCloudflaredMirror() string {
+	return getSettingOrDefault("cloudflared-mirror", "")
+}
+
+// GetCloudflaredSHA256 pins the checksum of the cloudflared download
+func (c ConstantsConfig) GetCloudflaredSHA256() string {
+	return getSettingOrDefault("cloudflared-sha256", "")
+}
+
 func (c ConstantsConfig) GetDebugHTTP() bool {
 	return getEnvOrDefault(debugHTTP, "") != ""
 }
@@ -59,6 +109,27 @@ func getEnvOrDefault(envVarName EnvVarName, defaultVal string) string {
 	return val
 }
 
+var (
+	loadSettingsOnce sync.Once
+	settings         Loaded
+)
+
+// getSettingOrDefault resolves a setting from its env var and the config
+// files, the files are read and their problems reported once per process
+func getSettingOrDefault(key string, defaultVal string) string {
+	loadSettingsOnce.Do(func() {
+		settings = DefaultLayers().Load()
+		for _, w := range settings.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+	})
+	val := settings.Get(key)
+	if val == "" {
+		return defaultVal
+	}
+	return val
+}
+
 var GlobalConfig = NewConstants()
 
 type EnvVarConfig struct {
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+// FileName is the config file in the brev home and in a repo's .brev directory
+const FileName = "config.yaml"
+
+const repoConfigDir = ".brev"
+
+// Setting is a key the config files can set, its env var overrides them
+type Setting struct {
+	Key   string
+	Env   EnvVarName
+	Usage string
+	Bool  bool
+	// Repo is whether a repo's .brev/config.yaml may set it. Anything that
+	// picks where brev sends credentials or what it runs is left to the user
+	// since a cloned repo can't be trusted with it.
+	Repo bool
+}
+
+var Settings = []Setting{
+	{Key: "org", Env: defaultOrg, Usage: "org to use when brev set hasn't picked one", Repo: true},
+	{Key: "instance-type", Env: defaultInstanceType, Usage: "instance type brev create and brev start use", Repo: true},
+	{Key: "workspace-class", Env: defaultWorkspaceClass, Usage: "workspace class for new instances", Repo: true},
+	{Key: "editor", Env: defaultEditor, Usage: "editor brev open launches"},
+	{Key: "editor-command", Env: defaultEditorCommand, Usage: "command for the custom editor"},
+	{Key: "output", Env: defaultOutput, Usage: "default --output format", Repo: true},
+	{Key: "api-url", Env: brevAPIURL, Usage: "brev api to talk to"},
+	{Key: "analytics-opt-out", Env: analyticsOptOut, Usage: "true to stop sending usage analytics", Bool: true},
+	{Key: "pinned-version", Env: pinnedVersion, Usage: "release brev upgrade sticks to"},
+	{Key: "cloudflared-mirror", Env: cloudflaredMirror, Usage: "where to download cloudflared and its checksums.txt from instead of github"},
+	{Key: "cloudflared-sha256", Env: cloudflaredSHA256, Usage: "checksum of the cloudflared download"},
+}
+
+func LookupSetting(key string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	return Setting{}, breverrors.NewValidationError(fmt.Sprintf("unknown config key %q, one of: %s", key, strings.Join(SettingKeys(), ", ")))
+}
+
+func SettingKeys() []string {
+	keys := []string{}
+	for _, s := range Settings {
+		keys = append(keys, s.Key)
+	}
+	return keys
+}
+
+// RepoSettingKeys are the keys a repo's config file may set
+func RepoSettingKeys() []string {
+	keys := []string{}
+	for _, s := range Settings {
+		if s.Repo {
+			keys = append(keys, s.Key)
+		}
+	}
+	return keys
+}
+
+type Source string
+
+const (
+	EnvSource     Source = "env"
+	RepoSource    Source = "repo"
+	UserSource    Source = "user"
+	DefaultSource Source = "default"
+)
+
+// Value is a resolved setting, Origin is the env var or file it came from
+type Value struct {
+	Key    string
+	Value  string
+	Source Source
+	Origin string
+}
+
+// Layers are where settings come from, env over the repo file over the user
+// file. Flags go above all of them and are up to each command.
+type Layers struct {
+	UserFile string
+	RepoFile string
+	getenv   func(string) string
+}
+
+// DefaultLayers reads ~/.brev/config.yaml and .brev/config.yaml at the root of
+// the git repo brev runs in
+func DefaultLayers() Layers {
+	layers := Layers{getenv: os.Getenv}
+	if home, err := os.UserHomeDir(); err == nil {
+		layers.UserFile = UserFilePath(files.GetBrevHome(home))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		layers.RepoFile = RepoFilePath(wd)
+	}
+	if layers.RepoFile == layers.UserFile {
+		// a dotfiles repo in $HOME, it's still the user file
+		layers.RepoFile = ""
+	}
+	return layers
+}
+
+func UserFilePath(brevHome string) string {
+	return filepath.Join(brevHome, FileName)
+}
+
+// RepoFilePath is where the config file of the git repo containing dir goes,
+// empty outside a repo
+func RepoFilePath(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return filepath.Join(dir, repoConfigDir, FileName)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Resolve is the value brev uses for key and where it came from, a config
+// file that can't be read is an error
+func (l Layers) Resolve(key string) (Value, error) {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return Value{}, breverrors.WrapAndTrace(err)
+	}
+	repo, err := readLayer(l.RepoFile)
+	if err != nil {
+		return Value{}, breverrors.WrapAndTrace(err)
+	}
+	user, err := readLayer(l.UserFile)
+	if err != nil {
+		return Value{}, breverrors.WrapAndTrace(err)
+	}
+	return l.resolve(setting, repo, user), nil
+}
+
+func (l Layers) resolve(setting Setting, repo, user map[string]string) Value {
+	getenv := l.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if v := getenv(string(setting.Env)); v != "" {
+		return Value{Key: setting.Key, Value: v, Source: EnvSource, Origin: string(setting.Env)}
+	}
+	if v, ok := repo[setting.Key]; ok && setting.Repo {
+		return Value{Key: setting.Key, Value: v, Source: RepoSource, Origin: l.RepoFile}
+	}
+	if v, ok := user[setting.Key]; ok {
+		return Value{Key: setting.Key, Value: v, Source: UserSource, Origin: l.UserFile}
+	}
+	return Value{Key: setting.Key, Source: DefaultSource}
+}
+
+func readLayer(path string) (map[string]string, error) {
+	if path == "" {
+		return map[string]string{}, nil
+	}
+	return ReadFile(path)
+}
+
+// Loaded is Layers with the files read once, env vars are still read on
+// every Get
+type Loaded struct {
+	Layers
+	repo map[string]string
+	user map[string]string
+	// Warnings are the files that couldn't be read, they count as empty, and
+	// the repo file settings that are ignored
+	Warnings []string
+}
+
+// Load reads the config files for the settings brev looks up on every command
+func (l Layers) Load() Loaded {
+	loaded := Loaded{Layers: l}
+	var err error
+	loaded.repo, err = readLayer(l.RepoFile)
+	if err != nil {
+		loaded.repo = map[string]string{}
+		loaded.Warnings = append(loaded.Warnings, fmt.Sprintf("ignoring %s, run brev config list to see why", l.RepoFile))
+	}
+	loaded.user, err = readLayer(l.UserFile)
+	if err != nil {
+		loaded.user = map[string]string{}
+		loaded.Warnings = append(loaded.Warnings, fmt.Sprintf("ignoring %s, run brev config list to see why", l.UserFile))
+	}
+	for _, k := range IgnoredRepoKeys(loaded.repo) {
+		loaded.Warnings = append(loaded.Warnings, ignoredRepoKeyWarning(l.RepoFile, k))
+	}
+	return loaded
+}
+
+// Get is the value brev uses for key, empty for an unknown key
+func (l Loaded) Get(key string) string {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return ""
+	}
+	return l.resolve(setting, l.repo, l.user).Value
+}
+
+// IgnoredRepoKeys are the settings in a repo file's values that only the
+// user's config or env may set
+func IgnoredRepoKeys(values map[string]string) []string {
+	ignored := []string{}
+	for k := range values {
+		if setting, err := LookupSetting(k); err == nil && !setting.Repo {
+			ignored = append(ignored, k)
+		}
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+func ignoredRepoKeyWarning(path, key string) string {
+	return fmt.Sprintf("%s: %s is ignored, a repo can only set %s", path, key, strings.Join(RepoSettingKeys(), ", "))
+}
+
+// ReadFile reads a config file's settings, a missing file has none
+func ReadFile(path string) (map[string]string, error) {
+	raw, err := readRaw(path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	values := map[string]string{}
+	for k, v := range raw {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, breverrors.NewValidationError(fmt.Sprintf("%s: %s should be a plain value", path, k))
+		case nil:
+			continue
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	return values, nil
+}
+
+// SetInFile sets key in the config file at path, creating it if needed
+func SetInFile(path, key, value string) error {
+	setting, err := LookupSetting(key)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	raw, err := readRaw(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if setting.Bool {
+		b, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			return breverrors.NewValidationError(fmt.Sprintf("%s should be true or false, not %q", key, value))
+		}
+		raw[key] = b
+	} else {
+		raw[key] = value
+	}
+	return writeRaw(path, raw)
+}
+
+// UnsetInFile removes key from the config file at path
+func UnsetInFile(path, key string) error {
+	_, err := LookupSetting(key)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	raw, err := readRaw(path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if _, ok := raw[key]; !ok {
+		return nil
+	}
+	delete(raw, key)
+	return writeRaw(path, raw)
+}
+
+func readRaw(path string) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	data, err := os.ReadFile(path) //nolint:gosec // config file path
+	if os.IsNotExist(err) {
+		return raw, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(fmt.Errorf("%s: %w", path, err))
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return raw, nil
+}
+
+func writeRaw(path string, raw map[string]interface{}) error {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(raw) == 0 {
+		data = nil
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.WriteFile(path, data, 0o644) //nolint:gosec // not a secret
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// UnknownKeys are keys in the file at path that brev doesn't read
+func UnknownKeys(path string) ([]string, error) {
+	raw, err := readRaw(path)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	unknown := []string{}
+	for k := range raw {
+		if _, lookupErr := LookupSetting(k); lookupErr != nil {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
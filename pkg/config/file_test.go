@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayersPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{}
+	layers := Layers{
+		UserFile: filepath.Join(dir, "home", ".brev", FileName),
+		RepoFile: filepath.Join(dir, "repo", ".brev", FileName),
+		getenv:   func(k string) string { return env[k] },
+	}
+
+	v, err := layers.Resolve("org")
+	require.NoError(t, err)
+	assert.Equal(t, Value{Key: "org", Source: DefaultSource}, v)
+
+	require.NoError(t, SetInFile(layers.UserFile, "org", "user-org"))
+	assert.Equal(t, "user-org", layers.Load().Get("org"))
+
+	require.NoError(t, SetInFile(layers.RepoFile, "org", "repo-org"))
+	v, err = layers.Resolve("org")
+	require.NoError(t, err)
+	assert.Equal(t, Value{Key: "org", Value: "repo-org", Source: RepoSource, Origin: layers.RepoFile}, v)
+
+	env["BREV_ORG"] = "env-org"
+	v, err = layers.Resolve("org")
+	require.NoError(t, err)
+	assert.Equal(t, Value{Key: "org", Value: "env-org", Source: EnvSource, Origin: "BREV_ORG"}, v)
+
+	delete(env, "BREV_ORG")
+	require.NoError(t, UnsetInFile(layers.RepoFile, "org"))
+	assert.Equal(t, "user-org", layers.Load().Get("org"))
+
+	_, err = layers.Resolve("color")
+	assert.ErrorContains(t, err, `unknown config key "color"`)
+}
+
+func TestRepoFileCantSetTrustedKeys(t *testing.T) {
+	dir := t.TempDir()
+	layers := Layers{
+		UserFile: filepath.Join(dir, "home", ".brev", FileName),
+		RepoFile: filepath.Join(dir, "repo", ".brev", FileName),
+		getenv:   func(string) string { return "" },
+	}
+	require.NoError(t, SetInFile(layers.UserFile, "api-url", "https://api.brev.dev"))
+	for _, key := range []string{"api-url", "cloudflared-mirror", "cloudflared-sha256", "editor", "editor-command", "pinned-version"} {
+		require.NoError(t, SetInFile(layers.RepoFile, key, "attacker"))
+	}
+	require.NoError(t, SetInFile(layers.RepoFile, "instance-type", "g5.xlarge"))
+
+	v, err := layers.Resolve("api-url")
+	require.NoError(t, err)
+	assert.Equal(t, Value{Key: "api-url", Value: "https://api.brev.dev", Source: UserSource, Origin: layers.UserFile}, v)
+	v, err = layers.Resolve("editor-command")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSource, v.Source)
+
+	loaded := layers.Load()
+	assert.Equal(t, "https://api.brev.dev", loaded.Get("api-url"))
+	assert.Equal(t, "", loaded.Get("cloudflared-mirror"))
+	assert.Equal(t, "g5.xlarge", loaded.Get("instance-type"))
+	assert.Len(t, loaded.Warnings, 6)
+	assert.Contains(t, loaded.Warnings[0], "api-url is ignored, a repo can only set org, instance-type, workspace-class, output")
+}
+
+func TestLoadReportsBrokenFiles(t *testing.T) {
+	dir := t.TempDir()
+	env := map[string]string{}
+	layers := Layers{
+		UserFile: filepath.Join(dir, FileName),
+		getenv:   func(k string) string { return env[k] },
+	}
+	require.NoError(t, os.WriteFile(layers.UserFile, []byte("org: [broken\n"), 0o600))
+
+	_, err := layers.Resolve("org")
+	assert.Error(t, err)
+
+	loaded := layers.Load()
+	assert.Equal(t, []string{"ignoring " + layers.UserFile + ", run brev config list to see why"}, loaded.Warnings)
+	assert.Equal(t, "", loaded.Get("org"))
+	// env vars are still read on every Get
+	env["BREV_ORG"] = "env-org"
+	assert.Equal(t, "env-org", loaded.Get("org"))
+}
+
+func TestSetInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte("# mine\nfavorite-color: blue\n"), 0o600))
+
+	assert.ErrorContains(t, SetInFile(path, "analytics-opt-out", "nope"), "should be true or false")
+	require.NoError(t, SetInFile(path, "analytics-opt-out", "1"))
+	require.NoError(t, SetInFile(path, "instance-type", "g5.xlarge"))
+
+	data, err := os.ReadFile(path) //nolint:gosec // test
+	require.NoError(t, err)
+	assert.Equal(t, "analytics-opt-out: true\nfavorite-color: blue\ninstance-type: g5.xlarge\n", string(data))
+
+	values, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "true", values["analytics-opt-out"])
+
+	unknown, err := UnknownKeys(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"favorite-color"}, unknown)
+
+	require.NoError(t, os.WriteFile(path, []byte("org:\n  name: nested\n"), 0o600))
+	_, err = ReadFile(path)
+	assert.ErrorContains(t, err, "org should be a plain value")
+}
+
+func TestRepoFilePath(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	assert.Equal(t, "", RepoFilePath(nested))
+
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0o755))
+	assert.Equal(t, filepath.Join(root, ".brev", FileName), RepoFilePath(nested))
+}
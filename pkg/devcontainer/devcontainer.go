@@ -0,0 +1,196 @@
+// Package devcontainer translates a repo's devcontainer.json into the execs,
+// ports, extensions and env vars of a brev instance
+package devcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Paths are where a repo can keep its devcontainer.json, in the order they're looked for
+var Paths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// Config is the part of devcontainer.json brev reads, see
+// https://containers.dev/implementors/json_reference/
+type Config struct {
+	Name              string                     `json:"name"`
+	Image             string                     `json:"image"`
+	DockerFile        string                     `json:"dockerFile"`
+	Build             json.RawMessage            `json:"build"`
+	DockerComposeFile json.RawMessage            `json:"dockerComposeFile"`
+	Mounts            json.RawMessage            `json:"mounts"`
+	RunArgs           json.RawMessage            `json:"runArgs"`
+	Features          map[string]json.RawMessage `json:"features"`
+	ForwardPorts      []json.RawMessage          `json:"forwardPorts"`
+	PortsAttributes   map[string]PortAttributes  `json:"portsAttributes"`
+	ContainerEnv      map[string]string          `json:"containerEnv"`
+	RemoteEnv         map[string]*string         `json:"remoteEnv"`
+
+	OnCreateCommand      *Command `json:"onCreateCommand"`
+	UpdateContentCommand *Command `json:"updateContentCommand"`
+	PostCreateCommand    *Command `json:"postCreateCommand"`
+	PostStartCommand     *Command `json:"postStartCommand"`
+	PostAttachCommand    *Command `json:"postAttachCommand"`
+
+	Customizations struct {
+		VSCode struct {
+			Extensions []string `json:"extensions"`
+		} `json:"vscode"`
+	} `json:"customizations"`
+}
+
+type PortAttributes struct {
+	Label string `json:"label"`
+}
+
+// Command is a lifecycle command, written as a shell line, an argv array or
+// an object of named commands that run in parallel. Single commands have the
+// name "".
+type Command map[string]string
+
+func (c *Command) UnmarshalJSON(b []byte) error {
+	cmd, err := parseCommand(b)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if len(cmd) > 0 {
+		*c = cmd
+	}
+	return nil
+}
+
+func parseCommand(b []byte) (Command, error) {
+	var line string
+	if json.Unmarshal(b, &line) == nil {
+		if strings.TrimSpace(line) == "" {
+			return Command{}, nil
+		}
+		return Command{"": line}, nil
+	}
+	var argv []string
+	if json.Unmarshal(b, &argv) == nil {
+		if len(argv) == 0 {
+			return Command{}, nil
+		}
+		return Command{"": joinArgv(argv)}, nil
+	}
+	var named map[string]json.RawMessage
+	err := json.Unmarshal(b, &named)
+	if err != nil {
+		return nil, breverrors.Errorf("a command must be a string, an array or an object of either")
+	}
+	cmd := Command{}
+	for name, raw := range named {
+		sub, subErr := parseCommand(raw)
+		if subErr != nil {
+			return nil, breverrors.WrapAndTrace(subErr)
+		}
+		if line, ok := sub[""]; ok {
+			cmd[name] = line
+		}
+	}
+	return cmd, nil
+}
+
+// Names are the command names in a stable order
+func (c Command) Names() []string {
+	names := []string{}
+	for n := range c {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load reads the repo's devcontainer.json, the config is nil when it has none
+func Load(repoPath string) (*Config, string, error) {
+	for _, p := range Paths {
+		full := filepath.Join(repoPath, p)
+		b, err := os.ReadFile(full) //nolint:gosec // a file in the repo being started
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", breverrors.WrapAndTrace(err)
+		}
+		config, err := Parse(b)
+		if err != nil {
+			return nil, "", breverrors.Wrap(err, full)
+		}
+		return config, full, nil
+	}
+	return nil, "", nil
+}
+
+// Parse reads devcontainer.json, which is json with comments and trailing commas
+func Parse(contents []byte) (*Config, error) {
+	var config Config
+	err := json.Unmarshal(StripJSONC(contents), &config)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid devcontainer.json: %v", err))
+	}
+	return &config, nil
+}
+
+// StripJSONC removes comments and trailing commas so encoding/json can read it
+func StripJSONC(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	inString := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(b) {
+				i++
+				out = append(out, b[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			i += 2
+			for i+1 < len(b) && !(b[i] == '*' && b[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			out = dropTrailingComma(out)
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func dropTrailingComma(out []byte) []byte {
+	j := len(out) - 1
+	for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+		j--
+	}
+	if j >= 0 && out[j] == ',' {
+		return append(out[:j], out[j+1:]...)
+	}
+	return out
+}
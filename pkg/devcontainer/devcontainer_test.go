@@ -0,0 +1,69 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripJSONC(t *testing.T) {
+	in := `{
+	// a comment
+	"name": "a // not a comment", /* block
+	comment */
+	"forwardPorts": [3000, 8080,],
+	"containerEnv": {"A": "\"quoted\" /* kept */",},
+}`
+	config, err := Parse([]byte(in))
+	require.NoError(t, err)
+	assert.Equal(t, "a // not a comment", config.Name)
+	assert.Len(t, config.ForwardPorts, 2)
+	assert.Equal(t, `"quoted" /* kept */`, config.ContainerEnv["A"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte(`{"name": }`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`{"postCreateCommand": 1}`))
+	assert.Error(t, err)
+}
+
+func TestCommandForms(t *testing.T) {
+	config, err := Parse([]byte(`{
+	"onCreateCommand": "npm install",
+	"updateContentCommand": ["echo", "hello world", ""],
+	"postCreateCommand": {"server": "npm start", "db": ["docker", "compose", "up"]},
+	"postStartCommand": ""
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, Command{"": "npm install"}, *config.OnCreateCommand)
+	assert.Equal(t, Command{"": "echo 'hello world' ''"}, *config.UpdateContentCommand)
+	assert.Equal(t, Command{"server": "npm start", "db": "docker compose up"}, *config.PostCreateCommand)
+	assert.Equal(t, []string{"db", "server"}, config.PostCreateCommand.Names())
+	assert.Empty(t, config.PostStartCommand)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	config, path, err := Load(dir)
+	require.NoError(t, err)
+	assert.Nil(t, config)
+	assert.Empty(t, path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".devcontainer.json"), []byte(`{"name": "root"}`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"), []byte(`{"name": "nested"}`), 0o600))
+	config, path, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "nested", config.Name)
+	assert.Equal(t, filepath.Join(dir, ".devcontainer", "devcontainer.json"), path)
+}
+
+func TestFeatureName(t *testing.T) {
+	assert.Equal(t, "node", FeatureName("ghcr.io/devcontainers/features/node:1"))
+	assert.Equal(t, "go", FeatureName("ghcr.io/devcontainers/features/go"))
+	assert.Equal(t, "python", FeatureName("python"))
+}
@@ -0,0 +1,76 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// the features brev knows how to install, by name without registry or version.
+// An empty script means every brev instance already has it.
+var featureScripts = map[string]func(version string) string{
+	"common-utils":             func(string) string { return "" },
+	"docker-in-docker":         func(string) string { return "" },
+	"docker-outside-of-docker": func(string) string { return "" },
+	"git": func(string) string {
+		return "sudo apt-get update -y && sudo apt-get install -y git"
+	},
+	"github-cli": func(string) string {
+		return "sudo apt-get update -y && sudo apt-get install -y gh"
+	},
+	"node": func(version string) string {
+		if version == "" || version == "lts" {
+			version = "--lts"
+		}
+		return "curl -fsSL https://raw.githubusercontent.com/nvm-sh/nvm/v0.39.7/install.sh | bash\n" +
+			`export NVM_DIR="$HOME/.nvm"; . "$NVM_DIR/nvm.sh"` + "\n" +
+			fmt.Sprintf("nvm install %s", singleQuoteIfNeeded(version))
+	},
+	"python": func(version string) string {
+		if version == "" || version == "latest" || version == "os-provided" {
+			return "sudo apt-get update -y && sudo apt-get install -y python3 python3-pip python3-venv"
+		}
+		return "curl -LsSf https://astral.sh/uv/install.sh | sh\n" +
+			fmt.Sprintf(`"$HOME"/.local/bin/uv python install %s`, singleQuoteIfNeeded(version))
+	},
+	"go": func(version string) string {
+		if version == "" || version == "latest" {
+			version = "$(curl -fsSL 'https://go.dev/VERSION?m=text' | head -n 1 | sed 's/^go//')"
+		} else {
+			version = singleQuoteIfNeeded(version)
+		}
+		return fmt.Sprintf("v=%s\n", version) +
+			`curl -fsSL "https://go.dev/dl/go${v}.linux-$(dpkg --print-architecture).tar.gz" | sudo tar -C /usr/local -xz` + "\n" +
+			`grep -qs /usr/local/go/bin "$HOME"/.bashrc || echo 'export PATH=$PATH:/usr/local/go/bin' >> "$HOME"/.bashrc`
+	},
+}
+
+// FeatureName is a feature id without its registry and version, ex:
+// ghcr.io/devcontainers/features/node:1 is node
+func FeatureName(id string) string {
+	name := id[strings.LastIndex(id, "/")+1:]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// featureVersion reads the version option, features are written as "1.2" or
+// {"version": "1.2"}
+func featureVersion(raw json.RawMessage) string {
+	var version string
+	if json.Unmarshal(raw, &version) == nil {
+		return version
+	}
+	var options struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(raw, &options) == nil {
+		return options.Version
+	}
+	return ""
+}
+
+func singleQuoteIfNeeded(s string) string {
+	return joinArgv([]string{s})
+}
@@ -0,0 +1,308 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+// where the env exec writes containerEnv and remoteEnv, every shell and exec sources it
+const envFile = "~/.brev/devcontainer.env"
+
+// workspaceDir is where setup clones projects, the repo is both the local and
+// the container workspace folder on brev
+const workspaceDir = "/home/brev/workspace"
+
+const (
+	envExec           entity.ExecName = "devcontainer-env"
+	featureExecPrefix                 = "devcontainer-feature-"
+)
+
+var (
+	varRe = regexp.MustCompile(`\$\{([A-Za-z]+)(?::([^}:]*))?(?::([^}]*))?\}`)
+	refRe = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+)
+
+// Translation is what a devcontainer.json becomes on a brev instance
+type Translation struct {
+	ExecsV1      entity.ExecsV1
+	PortMappings map[string]string
+	IDEConfig    *entity.IDEConfig
+	Env          map[string]string
+	// what couldn't be carried over, to tell the user
+	Warnings []string
+}
+
+// lifecycle commands in the order the spec runs them, all but postStart run once
+var lifecycle = []struct {
+	name  string
+	stage entity.ExecStage
+	get   func(Config) *Command
+}{
+	{"on-create", entity.BuildStage, func(c Config) *Command { return c.OnCreateCommand }},
+	{"update-content", entity.BuildStage, func(c Config) *Command { return c.UpdateContentCommand }},
+	{"post-create", entity.BuildStage, func(c Config) *Command { return c.PostCreateCommand }},
+	{"post-start", entity.StartStage, func(c Config) *Command { return c.PostStartCommand }},
+}
+
+// Translate maps config onto brev, projectDir is the repo's folder in the
+// workspace dir and lookupEnv resolves ${localEnv:...} on this machine
+func Translate(config Config, projectDir string, lookupEnv func(string) (string, bool)) Translation {
+	t := Translation{ExecsV1: entity.ExecsV1{}, PortMappings: map[string]string{}, Env: map[string]string{}}
+	sub := substituter{projectDir: projectDir, lookupEnv: lookupEnv}
+
+	t.warnUnsupported(config)
+	for k, v := range config.ContainerEnv {
+		t.Env[k] = sub.replace(v)
+	}
+	for k, v := range config.RemoteEnv {
+		if v != nil {
+			t.Env[k] = sub.replace(*v)
+		}
+	}
+	t.addPorts(config)
+	if len(config.Customizations.VSCode.Extensions) > 0 {
+		t.IDEConfig = &entity.IDEConfig{VSCode: entity.VSCodeConfig{Extensions: extensionMetadata(config.Customizations.VSCode.Extensions)}}
+	}
+
+	prev := []entity.ExecName{}
+	if len(t.Env) > 0 {
+		t.addExec(envExec, entity.BuildStage, "", envScript(t.Env), nil)
+		prev = append(prev, envExec)
+	}
+	features := t.addFeatures(config.Features, prev)
+	prev = append(prev, features...)
+	for _, l := range lifecycle {
+		cmd := l.get(config)
+		if cmd == nil || len(*cmd) == 0 {
+			continue
+		}
+		names := []entity.ExecName{}
+		for _, n := range cmd.Names() {
+			name := entity.ExecName("devcontainer-" + l.name)
+			if n != "" {
+				name = entity.ExecName(fmt.Sprintf("devcontainer-%s-%s", l.name, n))
+			}
+			t.addExec(name, l.stage, projectDir, sub.replace((*cmd)[n]), prev)
+			names = append(names, name)
+		}
+		prev = names
+	}
+	return t
+}
+
+func (t *Translation) addExec(name entity.ExecName, stage entity.ExecStage, workDir string, script string, dependsOn []entity.ExecName) {
+	if len(t.Env) > 0 && name != envExec {
+		script = fmt.Sprintf(". %s\n%s", envFileShell(), script)
+	}
+	execStage := stage
+	exec := entity.ExecV1{
+		Type:       entity.StringExecType,
+		Stage:      &execStage,
+		StringExec: entity.StringExec{ExecStr: script},
+	}
+	if workDir != "" {
+		exec.ExecWorkDir = &workDir
+	}
+	exec.DependsOn = append([]entity.ExecName{}, dependsOn...)
+	t.ExecsV1[name] = exec
+}
+
+func (t *Translation) warnUnsupported(config Config) {
+	if config.Image != "" || config.DockerFile != "" || len(config.Build) > 0 {
+		t.Warnings = append(t.Warnings, "image and build are ignored, brev instances are VMs; install what the image provides with features or postCreateCommand")
+	}
+	if len(config.DockerComposeFile) > 0 {
+		t.Warnings = append(t.Warnings, "dockerComposeFile is ignored, run docker compose from postStartCommand instead")
+	}
+	if len(config.Mounts) > 0 || len(config.RunArgs) > 0 {
+		t.Warnings = append(t.Warnings, "mounts and runArgs are ignored")
+	}
+	if config.PostAttachCommand != nil && len(*config.PostAttachCommand) > 0 {
+		t.Warnings = append(t.Warnings, "postAttachCommand is ignored, there is no attach on brev")
+	}
+}
+
+func (t *Translation) addPorts(config Config) {
+	for _, raw := range config.ForwardPorts {
+		var port string
+		var n int
+		if json.Unmarshal(raw, &n) == nil {
+			port = strconv.Itoa(n)
+		} else if json.Unmarshal(raw, &port) != nil {
+			continue
+		}
+		// host:port forwards another container's port, only localhost is on the instance
+		if host, p, ok := strings.Cut(port, ":"); ok {
+			if host != "localhost" && host != "127.0.0.1" {
+				t.Warnings = append(t.Warnings, fmt.Sprintf("forwardPorts %s is ignored, only ports on the instance can be forwarded", port))
+				continue
+			}
+			port = p
+		}
+		name := "port-" + port
+		if attrs, ok := config.PortsAttributes[port]; ok && attrs.Label != "" {
+			name = attrs.Label
+		}
+		t.PortMappings[name] = port
+	}
+}
+
+func extensionMetadata(ids []string) []entity.VscodeExtensionMetadata {
+	extensions := []entity.VscodeExtensionMetadata{}
+	for _, id := range ids {
+		publisher, name, ok := strings.Cut(id, ".")
+		if !ok {
+			continue
+		}
+		extensions = append(extensions, entity.VscodeExtensionMetadata{Publisher: publisher, Name: name})
+	}
+	return extensions
+}
+
+// addFeatures installs the features there's a script for, each after prev
+func (t *Translation) addFeatures(features map[string]json.RawMessage, prev []entity.ExecName) []entity.ExecName {
+	ids := []string{}
+	for id := range features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	names := []entity.ExecName{}
+	for _, id := range ids {
+		short := FeatureName(id)
+		install, ok := featureScripts[short]
+		if !ok {
+			t.Warnings = append(t.Warnings, fmt.Sprintf("feature %s isn't supported, install it in postCreateCommand", id))
+			continue
+		}
+		script := install(featureVersion(features[id]))
+		if script == "" {
+			// already on every brev instance
+			continue
+		}
+		name := entity.ExecName(featureExecPrefix + short)
+		t.addExec(name, entity.BuildStage, "", script, prev)
+		names = append(names, name)
+	}
+	return names
+}
+
+// Apply puts the translation on options, execs and ports already there win
+func (t Translation) Apply(options *store.CreateWorkspacesOptions) {
+	if options.ExecsV1 == nil {
+		options.ExecsV1 = &entity.ExecsV1{}
+	}
+	for name, exec := range t.ExecsV1 {
+		if _, ok := (*options.ExecsV1)[name]; !ok {
+			(*options.ExecsV1)[name] = exec
+		}
+	}
+	if len(t.PortMappings) > 0 && options.PortMappings == nil {
+		options.PortMappings = map[string]string{}
+	}
+	for name, port := range t.PortMappings {
+		if _, ok := options.PortMappings[name]; !ok {
+			options.PortMappings[name] = port
+		}
+	}
+	if t.IDEConfig != nil {
+		if options.IDEConfig == nil {
+			options.IDEConfig = &entity.IDEConfig{}
+		}
+		options.IDEConfig.VSCode.Extensions = append(options.IDEConfig.VSCode.Extensions, t.IDEConfig.VSCode.Extensions...)
+	}
+}
+
+// substituter resolves the devcontainer variables that mean something on brev
+type substituter struct {
+	projectDir string
+	lookupEnv  func(string) (string, bool)
+}
+
+func (s substituter) replace(v string) string {
+	return varRe.ReplaceAllStringFunc(v, func(m string) string {
+		parts := varRe.FindStringSubmatch(m)
+		switch parts[1] {
+		case "localEnv":
+			if val, ok := s.lookupEnv(parts[2]); ok {
+				return val
+			}
+			return parts[3]
+		case "containerEnv", "env":
+			return "${" + parts[2] + "}"
+		case "localWorkspaceFolderBasename", "containerWorkspaceFolderBasename":
+			return s.projectDir
+		case "localWorkspaceFolder", "containerWorkspaceFolder":
+			return path.Join(workspaceDir, s.projectDir)
+		default:
+			return m
+		}
+	})
+}
+
+func envFileShell() string {
+	return `"$HOME"/` + strings.TrimPrefix(envFile, "~/")
+}
+
+// envScript writes the env file and sources it from bash and zsh
+func envScript(env map[string]string) string {
+	keys := []string{}
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "mkdir -p \"$HOME\"/.brev\ncat > %s <<'BREV_DEVCONTAINER_ENV'\n", envFileShell())
+	for _, k := range keys {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellValue(env[k]))
+	}
+	b.WriteString("BREV_DEVCONTAINER_ENV\n")
+	fmt.Fprintf(&b, `for rc in "$HOME"/.bashrc "$HOME"/.zshrc; do grep -qs devcontainer.env "$rc" || echo '[ -f %[1]s ] && . %[1]s' >> "$rc"; done`+"\n", envFile)
+	return b.String()
+}
+
+// shellValue quotes v, leaving the ${VAR} references to other env vars expandable
+func shellValue(v string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range refRe.FindAllStringIndex(v, -1) {
+		b.WriteString(singleQuote(v[last:loc[0]]))
+		b.WriteString(`"` + v[loc[0]:loc[1]] + `"`)
+		last = loc[1]
+	}
+	b.WriteString(singleQuote(v[last:]))
+	if b.Len() == 0 {
+		return "''"
+	}
+	return b.String()
+}
+
+func singleQuote(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// joinArgv turns an argv array into a shell line
+func joinArgv(argv []string) string {
+	quoted := []string{}
+	for _, a := range argv {
+		switch {
+		case a == "":
+			quoted = append(quoted, "''")
+		case strings.Trim(a, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "":
+			quoted = append(quoted, a)
+		default:
+			quoted = append(quoted, singleQuote(a))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,112 @@
+package devcontainer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/brevdev/brev-cli/pkg/store"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	config, err := Parse([]byte(`{
+	"image": "mcr.microsoft.com/devcontainers/base",
+	"features": {
+		"ghcr.io/devcontainers/features/node:1": {"version": "20"},
+		"ghcr.io/devcontainers/features/common-utils:2": {},
+		"ghcr.io/someone/features/unknown:1": {}
+	},
+	"containerEnv": {"TOKEN": "${localEnv:TOKEN}", "MISSING": "${localEnv:NOPE:fallback}"},
+	"remoteEnv": {"PATH": "${containerEnv:PATH}:/opt/bin", "UNSET": null, "SRC": "${containerWorkspaceFolder}/src"},
+	"onCreateCommand": "npm ci",
+	"postCreateCommand": {"a": "make a", "b": "make b"},
+	"postStartCommand": "cd ${containerWorkspaceFolderBasename} && npm start",
+	"postAttachCommand": "echo attached",
+	"updateContentCommand": "${localWorkspaceFolder}/scripts/update.sh",
+	"forwardPorts": [3000, "localhost:5432", "db:5432"],
+	"portsAttributes": {"3000": {"label": "web"}},
+	"customizations": {"vscode": {"extensions": ["golang.go", "bad"]}}
+}`))
+	require.NoError(t, err)
+
+	tr := Translate(*config, "myrepo", lookup(map[string]string{"TOKEN": "secret"}))
+
+	assert.Equal(t, map[string]string{"TOKEN": "secret", "MISSING": "fallback", "PATH": "${PATH}:/opt/bin", "SRC": "/home/brev/workspace/myrepo/src"}, tr.Env)
+	assert.Equal(t, map[string]string{"web": "3000", "port-5432": "5432"}, tr.PortMappings)
+	require.NotNil(t, tr.IDEConfig)
+	assert.Equal(t, []entity.VscodeExtensionMetadata{{Publisher: "golang", Name: "go"}}, tr.IDEConfig.VSCode.Extensions)
+	assert.Len(t, tr.Warnings, 4) // image, unknown feature, postAttach and db:5432
+	assert.NotContains(t, tr.ExecsV1, entity.ExecName("devcontainer-feature-common-utils"))
+
+	env := tr.ExecsV1[envExec]
+	assert.Equal(t, entity.BuildStage, *env.Stage)
+	assert.Contains(t, env.StringExec.ExecStr, `export PATH="${PATH}"':/opt/bin'`)
+	assert.Contains(t, env.StringExec.ExecStr, `export TOKEN='secret'`)
+
+	node := tr.ExecsV1["devcontainer-feature-node"]
+	assert.Equal(t, []entity.ExecName{envExec}, node.DependsOn)
+	assert.Contains(t, node.StringExec.ExecStr, "nvm install 20")
+
+	onCreate := tr.ExecsV1["devcontainer-on-create"]
+	assert.Equal(t, []entity.ExecName{envExec, "devcontainer-feature-node"}, onCreate.DependsOn)
+	assert.Equal(t, "myrepo", *onCreate.ExecWorkDir)
+	assert.Contains(t, onCreate.StringExec.ExecStr, "npm ci")
+
+	update := tr.ExecsV1["devcontainer-update-content"]
+	assert.Contains(t, update.StringExec.ExecStr, "/home/brev/workspace/myrepo/scripts/update.sh")
+
+	assert.Equal(t, []entity.ExecName{"devcontainer-update-content"}, tr.ExecsV1["devcontainer-post-create-a"].DependsOn)
+	assert.Equal(t, []entity.ExecName{"devcontainer-update-content"}, tr.ExecsV1["devcontainer-post-create-b"].DependsOn)
+
+	postStart := tr.ExecsV1["devcontainer-post-start"]
+	assert.Equal(t, entity.StartStage, *postStart.Stage)
+	assert.Equal(t, []entity.ExecName{"devcontainer-post-create-a", "devcontainer-post-create-b"}, postStart.DependsOn)
+	assert.Contains(t, postStart.StringExec.ExecStr, "cd myrepo && npm start")
+	assert.Contains(t, postStart.StringExec.ExecStr, ". \"$HOME\"/.brev/devcontainer.env")
+}
+
+func TestTranslateNoEnv(t *testing.T) {
+	config, err := Parse([]byte(`{"postCreateCommand": "make"}`))
+	require.NoError(t, err)
+
+	tr := Translate(*config, "repo", lookup(nil))
+
+	assert.Empty(t, tr.Warnings)
+	assert.Nil(t, tr.IDEConfig)
+	assert.NotContains(t, tr.ExecsV1, envExec)
+	exec := tr.ExecsV1["devcontainer-post-create"]
+	assert.Equal(t, "make", exec.StringExec.ExecStr)
+	assert.Empty(t, exec.DependsOn)
+}
+
+func TestApply(t *testing.T) {
+	existing := entity.ExecV1{StringExec: entity.StringExec{ExecStr: "mine"}}
+	options := &store.CreateWorkspacesOptions{
+		ExecsV1:      &entity.ExecsV1{"devcontainer-post-create": existing},
+		PortMappings: map[string]string{"web": "8000"},
+	}
+	tr := Translation{
+		ExecsV1: entity.ExecsV1{
+			"devcontainer-post-create": {StringExec: entity.StringExec{ExecStr: "theirs"}},
+			"devcontainer-post-start":  {StringExec: entity.StringExec{ExecStr: "start"}},
+		},
+		PortMappings: map[string]string{"web": "3000", "api": "4000"},
+		IDEConfig:    &entity.IDEConfig{VSCode: entity.VSCodeConfig{Extensions: []entity.VscodeExtensionMetadata{{Publisher: "golang", Name: "go"}}}},
+	}
+
+	tr.Apply(options)
+
+	assert.Equal(t, "mine", (*options.ExecsV1)["devcontainer-post-create"].StringExec.ExecStr)
+	assert.Equal(t, "start", (*options.ExecsV1)["devcontainer-post-start"].StringExec.ExecStr)
+	assert.Equal(t, map[string]string{"web": "8000", "api": "4000"}, options.PortMappings)
+	assert.Len(t, options.IDEConfig.VSCode.Extensions, 1)
+}
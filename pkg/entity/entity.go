@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/brevdev/brev-cli/pkg/collections"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/giturl"
 )
 
 // CredentialProvider describes which authentication system is resposnible for auth tokens.
@@ -92,15 +94,45 @@ func (g GitRepo) GetDir() string {
 	if g.GitDirectory != nil && *g.GitDirectory != "" {
 		return *g.GitDirectory
 	} else {
-		repo := g.Repository
-		return strings.Split(repo[strings.LastIndex(repo, "/")+1:], ".")[0]
+		return GetDefaultProjectFolderNameFromRepo(g.Repository)
 	}
 }
 
 type GitRepoOptions struct {
-	Branch       *string `json:"branch,omitempty"`           // branch, tag, commit
-	GitDirectory *string `json:"gitRepoDirectory,omitempty"` // need to be different names than emptyrepo
+	Branch       *string  `json:"branch,omitempty"`           // branch, tag, commit
+	GitDirectory *string  `json:"gitRepoDirectory,omitempty"` // need to be different names than emptyrepo
+	Depth        *int     `json:"depth,omitempty"`            // only fetch this many commits // default=full history
+	Submodules   *bool    `json:"submodules,omitempty"`       // clone submodules recursively // default=true
+	LFS          bool     `json:"lfs,omitempty"`              // pull git lfs files after cloning
+	SparsePaths  []string `json:"sparsePaths,omitempty"`      // only check out these directories
+	Commit       *string  `json:"commit,omitempty"`           // sha to check out, for pinning to a commit not on a branch tip
 }
+
+func (g GitRepoOptions) GetSubmodules() bool {
+	return g.Submodules == nil || *g.Submodules
+}
+
+func (g GitRepoOptions) GetDepth() int {
+	if g.Depth == nil {
+		return 0
+	}
+	return *g.Depth
+}
+
+func (g GitRepoOptions) GetCommit() string {
+	if g.Commit == nil {
+		return ""
+	}
+	return *g.Commit
+}
+
+func (g GitRepoOptions) GetBranch() string {
+	if g.Branch == nil {
+		return ""
+	}
+	return *g.Branch
+}
+
 type EmptyRepo struct {
 	EmptyDirectory *string `json:"emptyRepoDirectory,omitempty"` // need to be different names than gitrepo
 }
@@ -111,6 +143,7 @@ type (
 		Exec        string   `json:"exec"`
 		ExecWorkDir string   `json:"execWorkDir"`
 		DependsOn   []string `json:"dependsOn"`
+		ExecPolicy
 	}
 	ExecsV0 map[ExecName]ExecV0
 	ExecV1  struct {
@@ -126,10 +159,68 @@ type (
 		LogPath        *string    `json:"logPath"`
 		LogArchivePath *string    `json:"logArchivePath"`
 		DependsOn      []ExecName `json:"dependsOn"`
+		ExecPolicy
 	}
 	ExecsV1 map[ExecName]ExecV1
+	// ExecPolicy is how setup treats an exec that hangs or fails
+	ExecPolicy struct {
+		Timeout           string `json:"timeout,omitempty"`      // go duration, ex: 10m, the exec's process group is killed after this
+		Retries           int    `json:"retries,omitempty"`      // extra attempts after the first fails
+		RetryBackoff      string `json:"retryBackoff,omitempty"` // go duration, wait before the first retry, doubles each time // default=10s
+		ContinueOnFailure bool   `json:"continueOnFailure,omitempty"`
+	}
 )
 
+const DefaultRetryBackoff = 10 * time.Second
+
+// Validate rejects a timeout or retryBackoff that isn't a go duration
+func (p ExecPolicy) Validate() error {
+	_, err := parsePolicyDuration("timeout", p.Timeout)
+	if err != nil {
+		return err
+	}
+	_, err = parsePolicyDuration("retryBackoff", p.RetryBackoff)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func parsePolicyDuration(field, value string) (*time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid %s %q: %v", field, value, err))
+	}
+	if d < 0 {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid %s %q: must not be negative", field, value))
+	}
+	return &d, nil
+}
+
+// GetTimeout is 0 when the exec may run forever, run Validate first to catch a bad value
+func (p ExecPolicy) GetTimeout() time.Duration {
+	timeout, err := parsePolicyDuration("timeout", p.Timeout)
+	if err != nil || timeout == nil {
+		return 0
+	}
+	return *timeout
+}
+
+// GetRetryBackoff is how long to wait before retry n, counting from 1
+func (p ExecPolicy) GetRetryBackoff(n int) time.Duration {
+	backoff := DefaultRetryBackoff
+	if d, err := parsePolicyDuration("retryBackoff", p.RetryBackoff); err == nil && d != nil {
+		backoff = *d
+	}
+	for i := 1; i < n; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
 type ExecType string
 
 const StringExecType ExecType = "string"
@@ -151,6 +242,14 @@ const (
 	BuildStage ExecStage = "build"
 )
 
+// GetStage defaults to start, build execs run once and start execs on every boot
+func (e ExecV1) GetStage() ExecStage {
+	if e.Stage == nil || *e.Stage == "" {
+		return StartStage
+	}
+	return *e.Stage
+}
+
 type UpdateUser struct {
 	Username          string                 `json:"username,omitempty"`
 	Name              string                 `json:"name,omitempty"`
@@ -297,12 +396,13 @@ type Workspace struct {
 	// LastOnlineAt         string `json:"lastOnlineAt,omitempty"`
 	// CreatedAt         string `json:"createdAt,omitempty"`
 	// UpdatedAt         string `json:"updatedAt,omitempty"`
-	HealthStatus    string        `json:"healthStatus"`
-	IsStoppable     bool          `json:"isStoppable"` // used for autopstop only
-	StatusMessage   string        `json:"statusMessage"`
-	StopTimeout     time.Duration `json:"stopTimeout"`
-	AdditionalUsers []string      `json:"additionalUsers"`
-	Tunnel          Tunnel        `json:"tunnel"`
+	HealthStatus    string            `json:"healthStatus"`
+	IsStoppable     bool              `json:"isStoppable"` // used for autopstop only
+	StatusMessage   string            `json:"statusMessage"`
+	StopTimeout     time.Duration     `json:"stopTimeout"`
+	AdditionalUsers []string          `json:"additionalUsers"`
+	Tunnel          Tunnel            `json:"tunnel"`
+	Labels          map[string]string `json:"labels,omitempty"`
 }
 
 type APIKey struct {
@@ -468,6 +568,10 @@ func (w Workspace) GetProjectFolderPath() string {
 }
 
 func GetDefaultProjectFolderNameFromRepo(repo string) string {
+	u, err := giturl.Parse(repo)
+	if err == nil {
+		return u.Name
+	}
 	return strings.Split(repo[strings.LastIndex(repo, "/")+1:], ".")[0]
 }
 
@@ -123,6 +123,20 @@ func (v ValidationError) Error() string {
 	return v.Message
 }
 
+// ExitCoder lets an error choose the process exit code, anything else exits 1
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+func GetExitCode(err error) int {
+	var exitCoder ExitCoder
+	if As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	return 1
+}
+
 type DeclineToLoginError struct{}
 
 func (d *DeclineToLoginError) Error() string     { return "declined to login" }
@@ -0,0 +1,153 @@
+package filesync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.Nil(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestScanLocalFilters(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.py":              "print(1)",
+		"data/big.bin":         "xx",
+		"sub/model.ckpt":       "ckpt",
+		"sub/keep.py":          "ok",
+		".git/HEAD":            "ref",
+		".gitignore":           "data/\n",
+		"sub/.gitignore":       "*.log\n",
+		"sub/debug.log":        "log",
+		"notes/debug.log":      "not ignored by sub/.gitignore",
+		"notes/__pycache__/x":  "cache",
+		"notes/readme.md":      "hi",
+		"notes/__pycache__/y":  "cache",
+		"notes/deep/also.ckpt": "ckpt",
+	})
+
+	filter := NewFilter(nil, []string{"*.ckpt", "__pycache__/"}, true)
+	manifest, err := ScanLocal(root, filter, nil)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{".gitignore", "main.py", "sub/.gitignore", "sub/keep.py", "notes/debug.log", "notes/readme.md"}, keys(manifest))
+
+	filter = NewFilter([]string{"*.py"}, nil, false)
+	manifest, err = ScanLocal(root, filter, nil)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"main.py", "sub/keep.py"}, keys(manifest))
+}
+
+func TestDiffAndRemoteManifest(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.txt": "a", "b.txt": "b"})
+	filter := NewFilter(nil, []string{"*.log"}, true)
+	local, err := ScanLocal(root, filter, nil)
+	assert.Nil(t, err)
+
+	out := local["a.txt"].Hash + "  ./a.txt\n" + "0000  ./b.txt\n" + "1111  ./gone.txt\n" + "2222  ./run.log\n"
+	remote, err := ParseRemoteManifest(strings.NewReader(out))
+	assert.Nil(t, err)
+
+	changed, deleted := Diff(local, remote, filter)
+	assert.Equal(t, []string{"b.txt"}, changed)
+	assert.Equal(t, []string{"gone.txt"}, deleted)
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"dir/a.txt": "a", "dir/sub/b.txt": "b"})
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteTree(&buf, filepath.Join(src, "dir"), "renamed"))
+	dst := t.TempDir()
+	assert.Nil(t, ExtractTar(&buf, dst, nil))
+	b, err := os.ReadFile(filepath.Join(dst, "renamed", "sub", "b.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "b", string(b))
+
+	buf.Reset()
+	assert.Nil(t, WriteTar(&buf, filepath.Join(src, "dir"), []string{"a.txt"}))
+	err = ExtractTar(&buf, dst, func(name string) string { return "../" + name })
+	assert.NotNil(t, err)
+}
+
+type fakeTransport struct {
+	remote  Manifest
+	pushed  [][]string
+	deleted [][]string
+	fetches int
+}
+
+func (f *fakeTransport) Manifest(_ string) (Manifest, error) {
+	f.fetches++
+	return f.remote, nil
+}
+
+func (f *fakeTransport) Push(_ string, _ string, files []string) error {
+	f.pushed = append(f.pushed, files)
+	return nil
+}
+
+func (f *fakeTransport) Delete(_ string, files []string) error {
+	f.deleted = append(f.deleted, files)
+	return nil
+}
+
+func TestSyncer(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.txt": "a", "b.txt": "b"})
+	transport := &fakeTransport{remote: Manifest{"old.txt": {Hash: "x"}}}
+	syncer := &Syncer{Transport: transport, LocalRoot: root, RemoteDir: "app", Filter: NewFilter(nil, nil, true), Delete: true}
+
+	result, err := syncer.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, result.Changed)
+	assert.Equal(t, []string{"old.txt"}, result.Deleted)
+
+	result, err = syncer.Sync()
+	assert.Nil(t, err)
+	assert.True(t, result.Empty())
+
+	writeTree(t, root, map[string]string{"b.txt": "changed"})
+	assert.Nil(t, os.Remove(filepath.Join(root, "a.txt")))
+	result, err = syncer.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b.txt"}, result.Changed)
+	assert.Equal(t, []string{"a.txt"}, result.Deleted)
+	assert.Equal(t, 1, transport.fetches)
+	assert.Len(t, transport.pushed, 2)
+}
+
+func TestParseTarget(t *testing.T) {
+	instance, path := ParseTarget("my-instance:app/src")
+	assert.Equal(t, "my-instance", instance)
+	assert.Equal(t, "app/src", path)
+
+	instance, path = ParseTarget("./local:file")
+	assert.Equal(t, "", instance)
+	assert.Equal(t, "./local:file", path)
+
+	instance, _ = ParseTarget(`C:\Users\me`)
+	assert.Equal(t, "", instance)
+
+	assert.Equal(t, ".", RemotePath("~"))
+	assert.Equal(t, "'my dir'", RemotePath("~/my dir"))
+}
+
+func keys(m Manifest) []string {
+	out := []string{}
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+// Package filesync copies and syncs files between the local machine and
+// instances over the brev ssh config
+package filesync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// always skipped, syncing them over would clobber the remote checkout
+var defaultExcludes = []string{".git"}
+
+// Filter decides which files under a root get synced. Include and exclude
+// use .gitignore syntax, ex: *.ckpt, data/, **/__pycache__
+type Filter struct {
+	Include   []string
+	Exclude   []string
+	GitIgnore bool // also skip whatever .gitignore files under the root ignore
+
+	include  gitignore.Matcher
+	base     []gitignore.Pattern // defaults and --exclude
+	exclude  []gitignore.Pattern // base plus the .gitignore files seen so far
+	excluder gitignore.Matcher
+}
+
+func NewFilter(include, exclude []string, gitIgnore bool) *Filter {
+	f := &Filter{Include: include, Exclude: exclude, GitIgnore: gitIgnore}
+	if len(include) > 0 {
+		f.include = gitignore.NewMatcher(parsePatterns(include, nil))
+	}
+	f.base = parsePatterns(append(append([]string{}, defaultExcludes...), exclude...), nil)
+	f.reset()
+	return f
+}
+
+// reset forgets the .gitignore patterns from a previous walk
+func (f *Filter) reset() {
+	f.exclude = append([]gitignore.Pattern{}, f.base...)
+	f.excluder = gitignore.NewMatcher(f.exclude)
+}
+
+func parsePatterns(lines []string, domain []string) []gitignore.Pattern {
+	patterns := []gitignore.Pattern{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
+
+// LoadGitIgnore adds the patterns of the .gitignore in dir, relative to the
+// filter root. Called by the walker as it enters each directory.
+func (f *Filter) LoadGitIgnore(root string, relDir string) error {
+	if !f.GitIgnore {
+		return nil
+	}
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(relDir), ".gitignore")) //nolint:gosec // reading the user's own tree
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer file.Close() //nolint:errcheck // read only
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	f.exclude = append(f.exclude, parsePatterns(lines, splitPath(relDir))...)
+	f.excluder = gitignore.NewMatcher(f.exclude)
+	return nil
+}
+
+// Skip reports whether the slash separated path relative to the root is excluded
+func (f *Filter) Skip(relPath string, isDir bool) bool {
+	parts := splitPath(relPath)
+	if f.excluder.Match(parts, isDir) {
+		return true
+	}
+	// directories are always walked so includes like *.py can match inside them
+	if f.include != nil && !isDir {
+		return !f.include.Match(parts, isDir)
+	}
+	return false
+}
+
+func splitPath(relPath string) []string {
+	if relPath == "" || relPath == "." {
+		return nil
+	}
+	return strings.Split(relPath, "/")
+}
@@ -0,0 +1,132 @@
+package filesync
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+type FileState struct {
+	Hash    string
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+}
+
+// Manifest maps slash separated paths relative to the sync root to their state
+type Manifest map[string]FileState
+
+// ScanLocal hashes every file under root that the filter keeps. Files whose
+// size and mod time match the previous scan reuse its hash, so rescans in
+// watch mode only read what changed.
+func ScanLocal(root string, filter *Filter, previous Manifest) (Manifest, error) {
+	manifest := Manifest{}
+	filter.reset()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && filter.Skip(rel, true) {
+				return filepath.SkipDir
+			}
+			err = filter.LoadGitIgnore(root, rel)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || filter.Skip(rel, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		state := FileState{Size: info.Size(), Mode: info.Mode().Perm(), ModTime: info.ModTime()}
+		if prev, ok := previous[rel]; ok && prev.Size == state.Size && prev.ModTime.Equal(state.ModTime) {
+			state.Hash = prev.Hash
+		} else {
+			state.Hash, err = hashFile(path)
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+		}
+		manifest[rel] = state
+		return nil
+	})
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return manifest, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // reading the user's own tree
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RemoteManifestScript prints sha256sum lines for every file under the
+// current directory, used to build the remote side of the manifest
+const RemoteManifestScript = "find . -type f -exec sha256sum {} +"
+
+// ParseRemoteManifest reads sha256sum output, ex: "<hash>  ./src/main.py"
+func ParseRemoteManifest(r io.Reader) (Manifest, error) {
+	manifest := Manifest{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		path := strings.TrimPrefix(parts[1], "./")
+		manifest[path] = FileState{Hash: parts[0]}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return manifest, nil
+}
+
+// Diff returns the local files the remote is missing or has different
+// content for, and the remote files that no longer exist locally. Remote
+// files the filter excludes are never reported as deleted.
+func Diff(local, remote Manifest, filter *Filter) (changed []string, deleted []string) {
+	changed, deleted = []string{}, []string{}
+	for path, state := range local {
+		if r, ok := remote[path]; !ok || r.Hash != state.Hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range remote {
+		if _, ok := local[path]; !ok && !filter.Skip(path, false) {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
@@ -0,0 +1,208 @@
+package filesync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Transport is how a sync reaches the instance, faked in tests
+type Transport interface {
+	Manifest(dir string) (Manifest, error)
+	Push(root string, dir string, files []string) error
+	Delete(dir string, files []string) error
+}
+
+// Remote runs tar and sha256sum on an instance through its alias in the
+// ssh config written by SSHConfigurerV2
+type Remote struct {
+	SSHConfigPath string
+	Alias         string
+}
+
+var _ Transport = Remote{}
+
+func (r Remote) command(script string) *exec.Cmd {
+	return exec.Command("ssh", "-F", r.SSHConfigPath, "-o", "BatchMode=yes", r.Alias, script) //nolint:gosec // script is built from quoted paths
+}
+
+func (r Remote) run(script string, stdin io.Reader, stdout io.Writer) error {
+	cmd := r.command(script)
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return breverrors.Wrap(err, fmt.Sprintf("%s: %s", r.Alias, strings.TrimSpace(stderr.String())))
+	}
+	return nil
+}
+
+// Manifest hashes every file under dir on the instance, a missing dir is empty
+func (r Remote) Manifest(dir string) (Manifest, error) {
+	var out bytes.Buffer
+	err := r.run(fmt.Sprintf("cd %s 2>/dev/null || exit 0; %s", RemotePath(dir), RemoteManifestScript), nil, &out)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	manifest, err := ParseRemoteManifest(&out)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return manifest, nil
+}
+
+// Push copies files, relative to the local root, into dir on the instance
+func (r Remote) Push(root string, dir string, files []string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(WriteTar(pw, root, files))
+	}()
+	err := r.run(fmt.Sprintf("mkdir -p %[1]s && tar -xf - -C %[1]s", RemotePath(dir)), pr, io.Discard)
+	if err != nil {
+		_ = pr.CloseWithError(err)
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// PushTree copies src, a local file or directory, to dir/name on the instance
+func (r Remote) PushTree(src string, dir string, name string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(WriteTree(pw, src, name))
+	}()
+	err := r.run(fmt.Sprintf("mkdir -p %[1]s && tar -xf - -C %[1]s", RemotePath(dir)), pr, io.Discard)
+	if err != nil {
+		_ = pr.CloseWithError(err)
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// PullTree copies path on the instance, a file or directory, into the local
+// dir with its top level entry renamed to name
+func (r Remote) PullTree(remotePath string, dir string, name string) error {
+	parent, base := splitRemotePath(remotePath)
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ExtractTar(pr, dir, func(entry string) string {
+			return name + strings.TrimPrefix(entry, base)
+		})
+		_ = pr.Close()
+	}()
+	err := r.run(fmt.Sprintf("tar -cf - -C %s %s", RemotePath(parent), ShellQuote(base)), nil, pw)
+	_ = pw.Close()
+	extractErr := <-errc
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if extractErr != nil {
+		return breverrors.WrapAndTrace(extractErr)
+	}
+	return nil
+}
+
+// Delete removes files, relative to dir, on the instance
+func (r Remote) Delete(dir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	stdin := strings.NewReader(strings.Join(files, "\x00"))
+	err := r.run(fmt.Sprintf("cd %s && xargs -0 rm -f --", RemotePath(dir)), stdin, io.Discard)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// RemotePath quotes a path for the remote shell. Relative paths and paths
+// starting with ~ are relative to the remote home, which is where ssh starts.
+func RemotePath(p string) string {
+	switch {
+	case p == "" || p == "~" || p == "~/":
+		return "."
+	case strings.HasPrefix(p, "~/"):
+		return ShellQuote(strings.TrimPrefix(p, "~/"))
+	default:
+		return ShellQuote(p)
+	}
+}
+
+func splitRemotePath(p string) (string, string) {
+	p = strings.TrimSuffix(p, "/")
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return "", p
+	}
+	if i == 0 {
+		return "/", p[1:]
+	}
+	return p[:i], p[i+1:]
+}
+
+// ShellQuote quotes s for the remote shell unless it is plain enough not to need it
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// IsDir reports whether path is an existing directory on the instance
+func (r Remote) IsDir(remotePath string) (bool, error) {
+	var out bytes.Buffer
+	err := r.run(fmt.Sprintf("test -d %s && echo dir || true", RemotePath(remotePath)), nil, &out)
+	if err != nil {
+		return false, breverrors.WrapAndTrace(err)
+	}
+	return strings.TrimSpace(out.String()) == "dir", nil
+}
+
+// ParseTarget splits instance:path, anything without an instance is local
+func ParseTarget(arg string) (instance string, path string) {
+	i := strings.Index(arg, ":")
+	// a single letter before the colon is a windows drive
+	if i <= 1 || strings.ContainsAny(arg[:i], `/\`) {
+		return "", arg
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// CopyTree streams path on one instance into dir on another through this
+// machine, nothing is written locally
+func CopyTree(src Remote, srcPath string, dst Remote, dir string) error {
+	parent, base := splitRemotePath(srcPath)
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- dst.run(fmt.Sprintf("mkdir -p %[1]s && tar -xf - -C %[1]s", RemotePath(dir)), pr, io.Discard)
+		_ = pr.Close()
+	}()
+	err := src.run(fmt.Sprintf("tar -cf - -C %s %s", RemotePath(parent), ShellQuote(base)), nil, pw)
+	_ = pw.Close()
+	dstErr := <-errc
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	if dstErr != nil {
+		return breverrors.WrapAndTrace(dstErr)
+	}
+	return nil
+}
+
+// Run runs script on the instance with its output going to stdout, it
+// returns once the script exits or the connection drops
+func (r Remote) Run(script string, stdout io.Writer) error {
+	err := r.run(script, nil, stdout)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
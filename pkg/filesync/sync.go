@@ -0,0 +1,95 @@
+package filesync
+
+import (
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// Syncer makes a remote directory match a local one, one way
+type Syncer struct {
+	Transport Transport
+	LocalRoot string
+	RemoteDir string
+	Filter    *Filter
+	Delete    bool // remove remote files that are gone locally
+	DryRun    bool
+
+	local  Manifest
+	remote Manifest // what we believe is on the instance
+}
+
+type SyncResult struct {
+	Changed []string
+	Deleted []string
+}
+
+func (r SyncResult) Empty() bool {
+	return len(r.Changed) == 0 && len(r.Deleted) == 0
+}
+
+// Sync pushes the files that differ. The first call asks the instance for
+// its checksums, later calls diff against what the previous sync pushed.
+func (s *Syncer) Sync() (SyncResult, error) {
+	local, err := ScanLocal(s.LocalRoot, s.Filter, s.local)
+	if err != nil {
+		return SyncResult{}, breverrors.WrapAndTrace(err)
+	}
+	if s.remote == nil {
+		s.remote, err = s.Transport.Manifest(s.RemoteDir)
+		if err != nil {
+			return SyncResult{}, breverrors.WrapAndTrace(err)
+		}
+	}
+	changed, deleted := Diff(local, s.remote, s.Filter)
+	if !s.Delete {
+		deleted = []string{}
+	}
+	result := SyncResult{Changed: changed, Deleted: deleted}
+	if s.DryRun || result.Empty() {
+		s.local = local
+		return result, nil
+	}
+
+	if len(changed) > 0 {
+		err = s.Transport.Push(s.LocalRoot, s.RemoteDir, changed)
+		if err != nil {
+			return result, breverrors.WrapAndTrace(err)
+		}
+	}
+	err = s.Transport.Delete(s.RemoteDir, deleted)
+	if err != nil {
+		return result, breverrors.WrapAndTrace(err)
+	}
+
+	for _, path := range changed {
+		s.remote[path] = local[path]
+	}
+	for _, path := range deleted {
+		delete(s.remote, path)
+	}
+	s.local = local
+	return result, nil
+}
+
+// Watch syncs every interval until stop is closed. The local tree is polled
+// instead of watched with inotify so it also works on network and WSL mounts;
+// unchanged files are not rehashed so a poll is cheap.
+func (s *Syncer) Watch(interval time.Duration, stop <-chan struct{}, onSync func(SyncResult)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			result, err := s.Sync()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			if !result.Empty() && onSync != nil {
+				onSync(result)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package filesync
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// WriteTar writes the given files, slash separated and relative to root, as a tar stream
+func WriteTar(w io.Writer, root string, files []string) error {
+	tw := tar.NewWriter(w)
+	for _, rel := range files {
+		err := addFile(tw, filepath.Join(root, filepath.FromSlash(rel)), rel)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// WriteTree writes src, a file or a directory, as a tar stream with its top
+// level entry renamed to name
+func WriteTree(w io.Writer, src string, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		entry := path.Join(name, filepath.ToSlash(rel))
+		if d.IsDir() {
+			info, infoErr := d.Info()
+			if infoErr != nil {
+				return breverrors.WrapAndTrace(infoErr)
+			}
+			err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: entry + "/", Mode: int64(info.Mode().Perm()), ModTime: info.ModTime()})
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		err = addFile(tw, p, entry)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = tw.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func addFile(tw *tar.Writer, src string, name string) error {
+	f, err := os.Open(src) //nolint:gosec // reading the user's own tree
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer f.Close() //nolint:errcheck // read only
+	info, err := f.Stat()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: info.Size(), Mode: int64(info.Mode().Perm()), ModTime: info.ModTime()})
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = io.Copy(tw, f)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// ExtractTar unpacks a tar stream into dest. rename, if set, maps each entry
+// name before it is written, ex: to give the top level entry a new name.
+// Entries that would land outside dest are rejected.
+func ExtractTar(r io.Reader, dest string, rename func(string) string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		name := hdr.Name
+		if rename != nil {
+			name = rename(name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if target != filepath.Clean(dest) && !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return breverrors.New(fmt.Sprintf("refusing to write %s outside of %s", hdr.Name, dest))
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = writeFile(target, tr, fs.FileMode(hdr.Mode).Perm())
+		default:
+			// links and devices aren't synced
+		}
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+}
+
+func writeFile(target string, r io.Reader, mode fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode) //nolint:gosec // target is checked to be under dest
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	_, err = io.Copy(f, r) //nolint:gosec // the archive comes from the user's own instance
+	if err != nil {
+		_ = f.Close()
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package giturl parses the ways a git repo can be written: https, ssh,
+// scp-like (git@host:owner/repo), git:// and a bare host/owner/repo, each
+// optionally ending in #ref or @ref
+package giturl
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+var (
+	hostRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*(:[0-9]+)?$`)
+	shaRe  = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+)
+
+type URL struct {
+	Scheme string // https, http, ssh or git, empty when scp-like or bare
+	User   string
+	Host   string // with the port when one was given
+	Owner  string // everything between the host and the name, ex: group/subgroup
+	Name   string // without .git
+	Ref    string // branch, tag or sha from a #ref or @ref suffix
+}
+
+// Parse reads a git url, see the package doc for the forms it takes
+func Parse(raw string) (URL, error) {
+	s, ref := splitRef(strings.TrimSpace(raw))
+	u, p, err := splitHost(s)
+	if err != nil {
+		return URL{}, breverrors.NewValidationError(fmt.Sprintf("%s is not a git url: %s", raw, err.Error()))
+	}
+	u.Ref = ref
+
+	p = strings.Trim(p, "/")
+	p = strings.Trim(strings.TrimSuffix(p, ".git"), "/")
+	segments := strings.Split(p, "/")
+	for _, seg := range segments {
+		if seg == "" || seg == "." || seg == ".." {
+			return URL{}, breverrors.NewValidationError(fmt.Sprintf("%s is not a git url: bad repo path %q", raw, p))
+		}
+	}
+	// a bare host/name would be too easy to mistake for a local path
+	if u.Scheme == "" && u.User == "" && len(segments) < 2 {
+		return URL{}, breverrors.NewValidationError(fmt.Sprintf("%s is not a git url: expected host/owner/repo", raw))
+	}
+	u.Name = segments[len(segments)-1]
+	u.Owner = strings.Join(segments[:len(segments)-1], "/")
+	return u, nil
+}
+
+// splitRef takes off a #ref, or an @ref in the last path segment so the
+// user in git@host isn't mistaken for one
+func splitRef(s string) (string, string) {
+	if i := strings.LastIndex(s, "#"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	sep := strings.LastIndexAny(s, "/:")
+	if i := strings.LastIndex(s, "@"); sep >= 0 && i > sep {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// splitHost reads everything before the repo path, errors are only why
+// the url is rejected
+func splitHost(s string) (URL, string, error) {
+	if strings.Contains(s, "://") {
+		parsed, err := url.Parse(s)
+		if err != nil {
+			return URL{}, "", fmt.Errorf("can't parse it: %w", err)
+		}
+		u := URL{Scheme: strings.ToLower(parsed.Scheme), Host: parsed.Host}
+		switch u.Scheme {
+		case "https", "http", "ssh", "git":
+		case "git+ssh", "ssh+git":
+			u.Scheme = "ssh"
+		default:
+			return URL{}, "", fmt.Errorf("scheme %s isn't supported", parsed.Scheme)
+		}
+		if parsed.User != nil {
+			u.User = parsed.User.Username()
+		}
+		if u.Host == "" {
+			return URL{}, "", fmt.Errorf("no host")
+		}
+		return u, parsed.Path, nil
+	}
+
+	i := strings.IndexAny(s, ":/")
+	if i < 0 {
+		return URL{}, "", fmt.Errorf("no repo path")
+	}
+	u := URL{Host: s[:i]}
+	if at := strings.LastIndex(u.Host, "@"); at >= 0 {
+		u.User, u.Host = u.Host[:at], u.Host[at+1:]
+	}
+	if !hostRe.MatchString(u.Host) {
+		return URL{}, "", fmt.Errorf("bad host %q", u.Host)
+	}
+	// without a scheme or user only a dotted host tells a url from a path
+	if u.User == "" && !strings.Contains(u.Host, ".") {
+		return URL{}, "", fmt.Errorf("%s doesn't look like a host", u.Host)
+	}
+	return u, s[i+1:], nil
+}
+
+// IsGitURL is whether s parses as a git url
+func IsGitURL(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Same is whether a and b are the same repo however they are written,
+// anything that doesn't parse is only the same as itself
+func Same(a string, b string) bool {
+	ua, errA := Parse(a)
+	ub, errB := Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return strings.EqualFold(ua.Hostname(), ub.Hostname()) && strings.EqualFold(ua.Path(), ub.Path())
+}
+
+// Path is owner/name
+func (u URL) Path() string {
+	if u.Owner == "" {
+		return u.Name
+	}
+	return u.Owner + "/" + u.Name
+}
+
+// Hostname is the host without a port
+func (u URL) Hostname() string {
+	return strings.Split(u.Host, ":")[0]
+}
+
+func (u URL) port() string {
+	parts := strings.SplitN(u.Host, ":", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// RefIsCommit is whether Ref looks like a sha rather than a branch or tag
+func (u URL) RefIsCommit() bool {
+	return shaRe.MatchString(u.Ref)
+}
+
+// SSH is the repo over ssh, scp-like unless it needs a port
+func (u URL) SSH() string {
+	user := u.User
+	if user == "" || u.Scheme == "https" || u.Scheme == "http" {
+		user = "git"
+	}
+	if u.Scheme == "ssh" && u.port() != "" {
+		return fmt.Sprintf("ssh://%s@%s/%s.git", user, u.Host, u.Path())
+	}
+	return fmt.Sprintf("%s@%s:%s.git", user, u.Hostname(), u.Path())
+}
+
+// HTTPS is the repo over https, the port is only kept from an http(s) url
+func (u URL) HTTPS() string {
+	return fmt.Sprintf("https://%s/%s.git", u.webHost(), u.Path())
+}
+
+// HTTP is the repo over http, the port is only kept from an http(s) url
+func (u URL) HTTP() string {
+	return fmt.Sprintf("http://%s/%s.git", u.webHost(), u.Path())
+}
+
+func (u URL) webHost() string {
+	if u.Scheme == "https" || u.Scheme == "http" {
+		return u.Host
+	}
+	return u.Hostname()
+}
+
+// Remote is the host:owner/name.git form brev keeps project repos in, setup
+// adds the git@ when cloning. That form can't hold a port so a url with one
+// is kept as a full url in its own scheme.
+func (u URL) Remote() string {
+	if u.port() != "" {
+		switch u.Scheme {
+		case "ssh":
+			return u.SSH()
+		case "https":
+			return u.HTTPS()
+		case "http":
+			return u.HTTP()
+		case "git":
+			return fmt.Sprintf("git://%s/%s.git", u.Host, u.Path())
+		}
+	}
+	return fmt.Sprintf("%s:%s.git", u.Hostname(), u.Path())
+}
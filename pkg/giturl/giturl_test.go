@@ -0,0 +1,90 @@
+package giturl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want URL
+	}{
+		{"https://github.com/brevdev/brev-cli", URL{Scheme: "https", Host: "github.com", Owner: "brevdev", Name: "brev-cli"}},
+		{"https://github.com/brevdev/brev-cli.git/", URL{Scheme: "https", Host: "github.com", Owner: "brevdev", Name: "brev-cli"}},
+		{"git@github.com:brevdev/brev-cli.git", URL{User: "git", Host: "github.com", Owner: "brevdev", Name: "brev-cli"}},
+		{"github.com:brevdev/brev-cli.git", URL{Host: "github.com", Owner: "brevdev", Name: "brev-cli"}},
+		{"github.com/brevdev/brev-cli", URL{Host: "github.com", Owner: "brevdev", Name: "brev-cli"}},
+		{"ssh://git@git.corp.io:2222/infra/tools.git", URL{Scheme: "ssh", User: "git", Host: "git.corp.io:2222", Owner: "infra", Name: "tools"}},
+		{"git://git.kernel.org/pub/scm/git/git.git", URL{Scheme: "git", Host: "git.kernel.org", Owner: "pub/scm/git", Name: "git"}},
+		{"https://gitlab.com/group/sub/deeper/repo.git", URL{Scheme: "https", Host: "gitlab.com", Owner: "group/sub/deeper", Name: "repo"}},
+		{"git@bitbucket.org:team/my.repo.git", URL{User: "git", Host: "bitbucket.org", Owner: "team", Name: "my.repo"}},
+		{"git@myserver:project.git", URL{User: "git", Host: "myserver", Name: "project"}},
+		{"https://github.com/brevdev/brev-cli#feature/x", URL{Scheme: "https", Host: "github.com", Owner: "brevdev", Name: "brev-cli", Ref: "feature/x"}},
+		{"git@github.com:brevdev/brev-cli.git@4f2a9c1", URL{User: "git", Host: "github.com", Owner: "brevdev", Name: "brev-cli", Ref: "4f2a9c1"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, in := range []string{"", "my-instance", "./some/dir", "../a/b", "foo/bar", "github.com/brevdev", "ftp://host/a/b", "https:///a/b"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+		assert.False(t, IsGitURL(in), in)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	u, err := Parse("https://gitlab.com/group/sub/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "git@gitlab.com:group/sub/repo.git", u.SSH())
+	assert.Equal(t, "https://gitlab.com/group/sub/repo.git", u.HTTPS())
+	assert.Equal(t, "http://gitlab.com/group/sub/repo.git", u.HTTP())
+	assert.Equal(t, "gitlab.com:group/sub/repo.git", u.Remote())
+
+	u, err = Parse("ssh://deploy@git.corp.io:2222/infra/tools")
+	assert.NoError(t, err)
+	assert.Equal(t, "ssh://deploy@git.corp.io:2222/infra/tools.git", u.SSH())
+	assert.Equal(t, "https://git.corp.io/infra/tools.git", u.HTTPS())
+}
+
+func TestRemote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://github.com/brevdev/brev-cli", "github.com:brevdev/brev-cli.git"},
+		{"git@github.com:brevdev/brev-cli.git", "github.com:brevdev/brev-cli.git"},
+		{"ssh://git@git.corp.io/infra/tools", "git.corp.io:infra/tools.git"},
+		{"ssh://git@git.corp.io:2222/infra/tools", "ssh://git@git.corp.io:2222/infra/tools.git"},
+		{"https://git.corp.io:8443/infra/tools", "https://git.corp.io:8443/infra/tools.git"},
+		{"http://git.corp.io:8080/infra/tools", "http://git.corp.io:8080/infra/tools.git"},
+		{"git://git.corp.io:9418/infra/tools", "git://git.corp.io:9418/infra/tools.git"},
+	}
+	for _, tt := range tests {
+		u, err := Parse(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, u.Remote(), tt.in)
+		}
+	}
+}
+
+func TestRefIsCommit(t *testing.T) {
+	assert.True(t, URL{Ref: "4f2a9c1"}.RefIsCommit())
+	assert.False(t, URL{Ref: "main"}.RefIsCommit())
+	assert.False(t, URL{}.RefIsCommit())
+}
+
+func TestSame(t *testing.T) {
+	assert.True(t, Same("git@github.com:brevdev/brev-cli.git", "https://github.com/BrevDev/brev-cli"))
+	assert.True(t, Same("github.com:brevdev/brev-cli.git", "git@github.com/brevdev/brev-cli.git"))
+	assert.False(t, Same("git@github.com:brevdev/brev-cli.git", "git@github.com:brevdev/brev-deploy.git"))
+	assert.True(t, Same("foo", "foo"))
+	assert.False(t, Same("foo", ""))
+}
@@ -0,0 +1,249 @@
+// Package labels parses instance labels and the selectors used to filter
+// instances by them, ex: brev ls --selector team=ml,env!=prod
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const maxLength = 63
+
+var (
+	keyRegex   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`)
+	valueRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?)?$`)
+)
+
+func validateKey(key string) error {
+	if len(key) > maxLength || !keyRegex.MatchString(key) {
+		return breverrors.NewValidationError(fmt.Sprintf("invalid label key %q, keys are up to %d letters, digits, '.', '_', '-' or '/' and start and end with a letter or digit", key, maxLength))
+	}
+	return nil
+}
+
+func validateValue(value string) error {
+	if len(value) > maxLength || !valueRegex.MatchString(value) {
+		return breverrors.NewValidationError(fmt.Sprintf("invalid label value %q, values are up to %d letters, digits, '.', '_' or '-' and start and end with a letter or digit", value, maxLength))
+	}
+	return nil
+}
+
+// Parse turns key=value pairs into labels
+func Parse(pairs []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid label %q, expected key=value", pair))
+		}
+		err := validateKey(key)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		err = validateValue(value)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// Validate checks labels that didn't come from Parse, ex: from brev.yaml
+func Validate(l map[string]string) error {
+	for k, v := range l {
+		err := validateKey(k)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		err = validateValue(v)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	}
+	return nil
+}
+
+// ParseChanges parses key=value to set a label and key- to remove one
+func ParseChanges(args []string) (map[string]string, []string, error) {
+	set := []string{}
+	remove := []string{}
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") && strings.HasSuffix(arg, "-") {
+			key := strings.TrimSuffix(arg, "-")
+			err := validateKey(key)
+			if err != nil {
+				return nil, nil, breverrors.WrapAndTrace(err)
+			}
+			remove = append(remove, key)
+			continue
+		}
+		set = append(set, arg)
+	}
+	parsed, err := Parse(set)
+	if err != nil {
+		return nil, nil, breverrors.WrapAndTrace(err)
+	}
+	for _, key := range remove {
+		if _, ok := parsed[key]; ok {
+			return nil, nil, breverrors.NewValidationError(fmt.Sprintf("label %s is both set and removed", key))
+		}
+	}
+	return parsed, remove, nil
+}
+
+// Merge returns current with set applied and remove deleted, current is left untouched
+func Merge(current map[string]string, set map[string]string, remove []string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range set {
+		merged[k] = v
+	}
+	for _, k := range remove {
+		delete(merged, k)
+	}
+	return merged
+}
+
+// Format writes labels as sorted key=value pairs, ex: env=dev,team=ml
+func Format(l map[string]string) string {
+	pairs := []string{}
+	for k, v := range l {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Value    string
+}
+
+func (r Requirement) Matches(l map[string]string) bool {
+	value, ok := l[r.Key]
+	switch r.Operator {
+	case Equals:
+		return ok && value == r.Value
+	case NotEquals:
+		return !ok || value != r.Value
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	}
+	return r.Key + string(r.Operator) + r.Value
+}
+
+// Selector matches instances whose labels meet every requirement, an empty
+// selector matches everything
+type Selector []Requirement
+
+// ParseSelector parses comma separated requirements: key=value, key==value,
+// key!=value, key (has the label) and !key (doesn't have it)
+func ParseSelector(s string) (Selector, error) {
+	selector := Selector{}
+	if strings.TrimSpace(s) == "" {
+		return selector, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		r, err := parseRequirement(strings.TrimSpace(part))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+func parseRequirement(s string) (Requirement, error) {
+	var r Requirement
+	switch {
+	case strings.Contains(s, "!="):
+		key, value, _ := strings.Cut(s, "!=")
+		r = Requirement{Key: key, Operator: NotEquals, Value: value}
+	case strings.Contains(s, "=="):
+		key, value, _ := strings.Cut(s, "==")
+		r = Requirement{Key: key, Operator: Equals, Value: value}
+	case strings.Contains(s, "="):
+		key, value, _ := strings.Cut(s, "=")
+		r = Requirement{Key: key, Operator: Equals, Value: value}
+	case strings.HasPrefix(s, "!"):
+		r = Requirement{Key: strings.TrimPrefix(s, "!"), Operator: DoesNotExist}
+	default:
+		r = Requirement{Key: s, Operator: Exists}
+	}
+	r.Key = strings.TrimSpace(r.Key)
+	r.Value = strings.TrimSpace(r.Value)
+	err := validateKey(r.Key)
+	if err != nil {
+		return Requirement{}, breverrors.WrapAndTrace(err)
+	}
+	err = validateValue(r.Value)
+	if err != nil {
+		return Requirement{}, breverrors.WrapAndTrace(err)
+	}
+	return r, nil
+}
+
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s Selector) Matches(l map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(l) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Filter returns the workspaces the selector matches
+func (s Selector) Filter(workspaces []entity.Workspace) []entity.Workspace {
+	matched := []entity.Workspace{}
+	for _, w := range workspaces {
+		if s.Matches(w.Labels) {
+			matched = append(matched, w)
+		}
+	}
+	return matched
+}
+
+// SelectorFlagUsage is the help text for --selector on commands that filter instances
+const SelectorFlagUsage = "filter instances by label, ex: team=ml,env!=prod (key, !key, key=value and key!=value are supported)"
@@ -0,0 +1,76 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestParse(t *testing.T) {
+	parsed, err := Parse([]string{"team=ml", "env=", "brev.dev/owner=alec"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "ml", "env": "", "brev.dev/owner": "alec"}, parsed)
+
+	_, err = Parse([]string{"team"})
+	assert.NotNil(t, err)
+	_, err = Parse([]string{"=ml"})
+	assert.NotNil(t, err)
+	_, err = Parse([]string{"team=a,b"})
+	assert.NotNil(t, err)
+}
+
+func TestParseChanges(t *testing.T) {
+	set, remove, err := ParseChanges([]string{"team=ml", "env-"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "ml"}, set)
+	assert.Equal(t, []string{"env"}, remove)
+
+	merged := Merge(map[string]string{"env": "dev", "owner": "me"}, set, remove)
+	assert.Equal(t, "owner=me,team=ml", Format(merged))
+
+	_, _, err = ParseChanges([]string{"team=ml", "team-"})
+	assert.NotNil(t, err)
+}
+
+func TestSelector(t *testing.T) {
+	selector, err := ParseSelector("team=ml, env!=prod,gpu,!spot")
+	assert.Nil(t, err)
+	assert.Equal(t, "team=ml,env!=prod,gpu,!spot", selector.String())
+
+	assert.True(t, selector.Matches(map[string]string{"team": "ml", "env": "dev", "gpu": ""}))
+	assert.True(t, selector.Matches(map[string]string{"team": "ml", "gpu": "a100"}))
+	assert.False(t, selector.Matches(map[string]string{"team": "ml", "env": "prod", "gpu": ""}))
+	assert.False(t, selector.Matches(map[string]string{"team": "ml", "gpu": "", "spot": "true"}))
+	assert.False(t, selector.Matches(nil))
+
+	selector, err = ParseSelector("team==ml")
+	assert.Nil(t, err)
+	assert.Equal(t, Selector{{Key: "team", Operator: Equals, Value: "ml"}}, selector)
+
+	empty, err := ParseSelector(" ")
+	assert.Nil(t, err)
+	assert.True(t, empty.Empty())
+	assert.True(t, empty.Matches(nil))
+
+	_, err = ParseSelector("team=ml,")
+	assert.NotNil(t, err)
+	_, err = ParseSelector("te am=ml")
+	assert.NotNil(t, err)
+}
+
+func TestFilter(t *testing.T) {
+	workspaces := []entity.Workspace{
+		{Name: "a", Labels: map[string]string{"team": "ml"}},
+		{Name: "b", Labels: map[string]string{"team": "web"}},
+		{Name: "c"},
+	}
+	selector, err := ParseSelector("team=ml")
+	assert.Nil(t, err)
+	matched := selector.Filter(workspaces)
+	if assert.Len(t, matched, 1) {
+		assert.Equal(t, "a", matched[0].Name)
+	}
+	assert.Len(t, Selector{}.Filter(workspaces), 3)
+}
@@ -0,0 +1,202 @@
+package mergeshells
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/files"
+)
+
+var (
+	versionNumberRe = regexp.MustCompile(`[0-9]+(\.[0-9]+)*`)
+
+	requiresPythonRe  = regexp.MustCompile(`(?m)^\s*requires-python\s*=\s*["']([^"']+)["']`)
+	poetryPythonRe    = regexp.MustCompile(`(?m)^\s*python\s*=\s*["']([^"']+)["']`)
+	poetryToolRe      = regexp.MustCompile(`(?m)^\s*\[tool\.poetry\]`)
+	pipfilePythonRe   = regexp.MustCompile(`(?m)^\s*python_(?:full_)?version\s*=\s*["']([^"']+)["']`)
+	condaPythonRe     = regexp.MustCompile(`(?m)^\s*-\s*python\s*([=<>~!]*\s*[0-9][0-9.*]*)`)
+	pomJavaRe         = regexp.MustCompile(`<(?:maven\.compiler\.(?:release|source|target)|java\.version|release)>\s*([0-9.]+)\s*<`)
+	gradleJavaRe      = regexp.MustCompile(`(?:sourceCompatibility|targetCompatibility)\s*=?\s*(?:JavaVersion\.VERSION_)?['"]?([0-9._]+)`)
+	gradleToolchainRe = regexp.MustCompile(`JavaLanguageVersion\.of\(\s*([0-9]+)\s*\)`)
+	gemfileRubyRe     = regexp.MustCompile(`(?m)^\s*ruby\s+['"]([^'"]+)['"]`)
+)
+
+// the python files, a version from an earlier one wins
+var pythonFiles = []string{
+	`^\.python-version$`, `^runtime\.txt$`, `^pyproject\.toml$`, `^Pipfile$`,
+	`^environment\.ya?ml$`, `^requirements.*\.txt$`, `^setup\.py$`,
+}
+
+func pythonVersion(path string) *string {
+	return versionFromFiles(path, pythonFiles, func(name string, contents string) string {
+		switch {
+		case name == ".python-version":
+			return versionFromSpec(firstLine(contents))
+		case name == "runtime.txt":
+			return versionFromSpec(strings.TrimPrefix(firstLine(contents), "python-"))
+		case name == "pyproject.toml":
+			if m := requiresPythonRe.FindStringSubmatch(contents); m != nil {
+				return versionFromSpec(m[1])
+			}
+			if m := poetryPythonRe.FindStringSubmatch(contents); m != nil {
+				return versionFromSpec(m[1])
+			}
+		case name == "Pipfile":
+			if m := pipfilePythonRe.FindStringSubmatch(contents); m != nil {
+				return versionFromSpec(m[1])
+			}
+		case strings.HasPrefix(name, "environment."):
+			if m := condaPythonRe.FindStringSubmatch(contents); m != nil {
+				return versionFromSpec(m[1])
+			}
+		}
+		return ""
+	})
+}
+
+func poetryVersion(path string) *string {
+	return detectFile(path, []string{`^pyproject\.toml$`}, poetryToolRe.MatchString)
+}
+
+func pipenvVersion(path string) *string {
+	return detectFile(path, []string{`^Pipfile$`}, nil)
+}
+
+func condaVersion(path string) *string {
+	return detectFile(path, []string{`^environment\.ya?ml$`}, nil)
+}
+
+func javaVersion(path string) *string {
+	return versionFromFiles(path, []string{`^\.java-version$`, `^\.sdkmanrc$`, `^pom\.xml$`, `^build\.gradle(\.kts)?$`}, func(name string, contents string) string {
+		switch name {
+		case ".java-version":
+			return versionFromSpec(firstLine(contents))
+		case ".sdkmanrc":
+			for _, line := range strings.Split(contents, "\n") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(line), "java="); ok {
+					return versionFromSpec(v)
+				}
+			}
+		case "pom.xml":
+			if m := pomJavaRe.FindStringSubmatch(contents); m != nil {
+				return m[1]
+			}
+		default:
+			if m := gradleToolchainRe.FindStringSubmatch(contents); m != nil {
+				return m[1]
+			}
+			if m := gradleJavaRe.FindStringSubmatch(contents); m != nil {
+				return strings.ReplaceAll(m[1], "_", ".")
+			}
+		}
+		return ""
+	})
+}
+
+func mavenVersion(path string) *string {
+	return detectFile(path, []string{`^pom\.xml$`}, nil)
+}
+
+func rubyVersion(path string) *string {
+	return versionFromFiles(path, []string{`^\.ruby-version$`, `^\.tool-versions$`, `^Gemfile$`}, func(name string, contents string) string {
+		switch name {
+		case ".ruby-version":
+			return versionFromSpec(strings.TrimPrefix(firstLine(contents), "ruby-"))
+		case ".tool-versions":
+			for _, line := range strings.Split(contents, "\n") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(line), "ruby "); ok {
+					return versionFromSpec(v)
+				}
+			}
+		default:
+			if m := gemfileRubyRe.FindStringSubmatch(contents); m != nil {
+				return versionFromSpec(m[1])
+			}
+		}
+		return ""
+	})
+}
+
+// transformPythonVersion keeps major.minor, python packages are named python3.11
+func transformPythonVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > 2 {
+		return strings.Join(parts[:2], ".")
+	}
+	return version
+}
+
+// transformJavaVersion keeps the major version, 1.8 is 8
+func transformJavaVersion(version string) string {
+	version = strings.TrimPrefix(version, "1.")
+	return strings.Split(version, ".")[0]
+}
+
+// versionFromFiles finds the files matching patterns under path, shallowest
+// first, and returns the first version read from one. Finding a file with
+// no version returns "" so the default is used; finding none returns nil.
+func versionFromFiles(path string, patterns []string, read func(name string, contents string) string) *string {
+	paths := shallowestFirst(recursivelyFindFile(patterns, path))
+	if len(paths) == 0 {
+		return nil
+	}
+	retval := ""
+	for _, p := range paths {
+		contents, err := files.CatFile(p)
+		if err != nil {
+			continue
+		}
+		retval = read(p[strings.LastIndex(p, "/")+1:], contents)
+		if retval != "" {
+			break
+		}
+	}
+	return &retval
+}
+
+// detectFile is "" when a file matching patterns passes match, nil otherwise
+func detectFile(path string, patterns []string, match func(contents string) bool) *string {
+	for _, p := range recursivelyFindFile(patterns, path) {
+		if match != nil {
+			contents, err := files.CatFile(p)
+			if err != nil || !match(contents) {
+				continue
+			}
+		}
+		retval := ""
+		return &retval
+	}
+	return nil
+}
+
+// versionFromSpec reads the version a constraint like ">=3.9,<4" or "~> 3.2"
+// asks for, upper bounds are skipped
+func versionFromSpec(spec string) string {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "<") || strings.HasPrefix(part, "!=") {
+			continue
+		}
+		if v := versionNumberRe.FindString(part); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+func shallowestFirst(paths []string) []string {
+	sorted := append([]string{}, paths...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		di, dj := strings.Count(sorted[i], "/"), strings.Count(sorted[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
@@ -0,0 +1,163 @@
+package mergeshells
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRepo(t *testing.T, repoFiles map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range repoFiles {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(contents), 0o600))
+	}
+	return dir
+}
+
+func TestPythonVersion(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		want  *string
+	}{
+		"none":             {map[string]string{"main.go": ""}, nil},
+		"requirements":     {map[string]string{"requirements.txt": "flask\n"}, ptr("")},
+		"python-version":   {map[string]string{".python-version": "3.11.4\n", "pyproject.toml": "requires-python = \">=3.9\"\n"}, ptr("3.11.4")},
+		"requires-python":  {map[string]string{"pyproject.toml": "[project]\nrequires-python = \"<4,>=3.10\"\n"}, ptr("3.10")},
+		"poetry":           {map[string]string{"pyproject.toml": "[tool.poetry.dependencies]\npython = \"^3.12\"\n"}, ptr("3.12")},
+		"pipfile":          {map[string]string{"Pipfile": "[requires]\npython_version = \"3.8\"\n"}, ptr("3.8")},
+		"conda":            {map[string]string{"environment.yml": "dependencies:\n  - python=3.10.2\n  - numpy\n"}, ptr("3.10.2")},
+		"runtime":          {map[string]string{"runtime.txt": "python-3.9.18"}, ptr("3.9.18")},
+		"shallowest wins":  {map[string]string{"a/b/.python-version": "3.7", "Pipfile": "python_version = '3.12'"}, ptr("3.12")},
+		"nested unversion": {map[string]string{"svc/requirements-dev.txt": "pytest"}, ptr("")},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, pythonVersion(writeRepo(t, tc.files)))
+		})
+	}
+}
+
+func TestPythonTools(t *testing.T) {
+	dir := writeRepo(t, map[string]string{"pyproject.toml": "[project]\nname = \"x\"\n"})
+	assert.Nil(t, poetryVersion(dir))
+	assert.Nil(t, pipenvVersion(dir))
+	assert.Nil(t, condaVersion(dir))
+
+	dir = writeRepo(t, map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"x\"\n", "Pipfile": "", "environment.yaml": ""})
+	assert.Equal(t, ptr(""), poetryVersion(dir))
+	assert.Equal(t, ptr(""), pipenvVersion(dir))
+	assert.Equal(t, ptr(""), condaVersion(dir))
+}
+
+func TestJavaVersion(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		want  *string
+	}{
+		"none":        {map[string]string{"Gemfile": ""}, nil},
+		"pom":         {map[string]string{"pom.xml": "<properties><java.version>17</java.version></properties>"}, ptr("17")},
+		"pom source":  {map[string]string{"pom.xml": "<maven.compiler.source>1.8</maven.compiler.source>"}, ptr("1.8")},
+		"gradle":      {map[string]string{"build.gradle": "sourceCompatibility = '11'\n"}, ptr("11")},
+		"gradle enum": {map[string]string{"build.gradle.kts": "java { sourceCompatibility = JavaVersion.VERSION_1_8 }"}, ptr("1.8")},
+		"toolchain":   {map[string]string{"build.gradle.kts": "toolchain { languageVersion.set(JavaLanguageVersion.of(21)) }"}, ptr("21")},
+		"sdkmanrc":    {map[string]string{".sdkmanrc": "java=17.0.2-tem\n", "pom.xml": "<java.version>11</java.version>"}, ptr("17.0.2")},
+		"unversioned": {map[string]string{"pom.xml": "<project></project>"}, ptr("")},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, javaVersion(writeRepo(t, tc.files)))
+		})
+	}
+	assert.Equal(t, "8", transformJavaVersion("1.8"))
+	assert.Equal(t, "17", transformJavaVersion("17.0.2"))
+	assert.Equal(t, "21", transformJavaVersion("21"))
+}
+
+func TestRubyVersion(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		want  *string
+	}{
+		"none":          {map[string]string{"package.json": "{}"}, nil},
+		"ruby-version":  {map[string]string{".ruby-version": "ruby-3.2.2\n"}, ptr("3.2.2")},
+		"tool-versions": {map[string]string{".tool-versions": "nodejs 20.1.0\nruby 3.1.4\n"}, ptr("3.1.4")},
+		"gemfile":       {map[string]string{"Gemfile": "source 'https://rubygems.org'\nruby '~> 3.2'\ngem 'rails'\n"}, ptr("3.2")},
+		"unversioned":   {map[string]string{"Gemfile": "gem 'rails'\n"}, ptr("")},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, rubyVersion(writeRepo(t, tc.files)))
+		})
+	}
+}
+
+func TestGetDependenciesNewLanguages(t *testing.T) {
+	dir := writeRepo(t, map[string]string{
+		"api/pyproject.toml": "[tool.poetry]\n[tool.poetry.dependencies]\npython = \"^3.11.2\"\n",
+		"svc/pom.xml":        "<maven.compiler.release>1.8</maven.compiler.release>",
+		"web/Gemfile":        "ruby '3.3.0'\n",
+	})
+	deps := GetDependencies(dir)
+	sort.Strings(deps)
+	assert.Equal(t, []string{"java-8", "maven", "poetry", "python-3.11", "ruby-3.3.0"}, deps)
+}
+
+func TestMergeShellsNewLanguages(t *testing.T) {
+	script := MergeShells("poetry", "python-3.11", "maven", "java-21", "ruby")
+
+	assert.Contains(t, script, "python${python_version}-venv")
+	assert.Contains(t, script, "openjdk-${java_version}-jdk-headless")
+	assert.Contains(t, script, "pipx install poetry")
+	assert.Contains(t, script, "sudo apt-get install -y maven")
+	assert.Contains(t, script, `ruby_version=""`) // ruby without a version uses the latest
+	assert.Less(t, strings.Index(script, `python_version="3.11"`), strings.Index(script, "##### pipx"))
+	assert.Less(t, strings.Index(script, "##### pipx"), strings.Index(script, "##### Poetry"))
+	assert.Less(t, strings.Index(script, `java_version="21"`), strings.Index(script, "##### Maven"))
+}
+
+// TestMergedVersionsDontLeak runs the version assignments of the merged
+// script to check a template only ever sees its own version
+func TestMergedVersionsDontLeak(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("needs bash")
+	}
+	// the lines that set a version, not the ones asking rbenv or apt
+	assignment := regexp.MustCompile(`^\w+_version="[^"]*"$`)
+	cases := []struct {
+		deps []string
+		want string
+	}{
+		{[]string{"java-21", "ruby", "python"}, "python= java=21 ruby="},
+		{[]string{"python-3.11", "ruby", "java"}, "python=3.11 java=17 ruby="},
+		{[]string{"ruby-3.3.0", "python", "java-11"}, "python= java=11 ruby=3.3.0"},
+	}
+	for _, tc := range cases {
+		lines := []string{}
+		for _, line := range strings.Split(MergeShells(tc.deps...), "\n") {
+			if assignment.MatchString(line) {
+				lines = append(lines, line)
+			}
+		}
+		lines = append(lines, `echo -n "python=$python_version java=$java_version ruby=$ruby_version"`)
+		cmd := exec.Command(bash, "-c", strings.Join(lines, "\n")) //nolint:gosec // test
+		cmd.Env = append(os.Environ(), "version=99")
+		out, err := cmd.Output()
+		require.NoError(t, err, tc.deps)
+		assert.Equal(t, tc.want, string(out), tc.deps)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
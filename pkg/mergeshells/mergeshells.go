@@ -57,12 +57,21 @@ func GetDependencies(path string) []string {
 		"gatsby": {gatsbyVersion},
 		"rust":   {rustVersion},
 		"golang": {goVersion},
+		"python": {pythonVersion},
+		"poetry": {poetryVersion},
+		"pipenv": {pipenvVersion},
+		"conda":  {condaVersion},
+		"java":   {javaVersion},
+		"maven":  {mavenVersion},
+		"ruby":   {rubyVersion},
 	}
 
 	// these will be applied, in left-to-right order, to the version string returned by your version function
 	// before passing it to the finder / splicer of the install shell script for your dependency
 	processVersionMap := map[string][]func(string) string{
 		"golang":  {transformGoVersion},
+		"python":  {transformPythonVersion},
+		"java":    {transformJavaVersion},
 		"default": {transformVersion},
 	}
 
@@ -338,9 +347,13 @@ func importFile(nameVersion string) ([]ShellFragment, error) {
 	}
 	out, err := ioutil.ReadAll(script)
 	stringScript := string(out)
+	// without a version the template gets an empty one, not whatever $version
+	// is in the shell running the merged script
+	version := ""
 	if !noversion {
-		stringScript = strings.ReplaceAll(stringScript, "${version}", subPaths[1])
+		version = subPaths[1]
 	}
+	stringScript = strings.ReplaceAll(stringScript, "${version}", version)
 	// fmt.Println(stringScript)
 	if err != nil {
 		return []ShellFragment{}, breverrors.WrapAndTrace(err)
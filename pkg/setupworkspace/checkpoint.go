@@ -0,0 +1,191 @@
+package setupworkspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+const (
+	StepDone   = "done"
+	StepFailed = "failed"
+)
+
+// StepRecord is the outcome of the last run of a setup step, Hash is of the
+// step's inputs so a changed repo or exec runs again on resume
+type StepRecord struct {
+	Hash       string    `json:"hash"`
+	Status     string    `json:"status"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Checkpoint records which setup steps completed, it is saved after every
+// step so a setup that fails late can pick up where it stopped
+type Checkpoint struct {
+	Steps map[string]StepRecord `json:"steps"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// LoadCheckpoint reads the checkpoint at path, a missing file is an empty checkpoint
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{Steps: map[string]StepRecord{}, path: path}
+	b, err := os.ReadFile(path) //nolint:gosec // fixed path in the brev home dir
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if c.Steps == nil {
+		c.Steps = map[string]StepRecord{}
+	}
+	return c, nil
+}
+
+// IsDone is whether step completed last time with the same inputs
+func (c *Checkpoint) IsDone(step string, hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.Steps[step]
+	return ok && r.Status == StepDone && r.Hash == hash
+}
+
+// Record saves the outcome of step, err nil means it completed
+func (c *Checkpoint) Record(step string, hash string, stepErr error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := StepRecord{Hash: hash, Status: StepDone, FinishedAt: time.Now().UTC()}
+	if stepErr != nil {
+		r.Status = StepFailed
+		r.Error = stepErr.Error()
+	}
+	c.Steps[step] = r
+	return c.save()
+}
+
+// Failed lists the steps that failed in name order
+func (c *Checkpoint) Failed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failed := []string{}
+	for name, r := range c.Steps {
+		if r.Status == StepFailed {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+func (c *Checkpoint) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0o755) //nolint:gosec // brev home dir
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	tmp := c.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0o644) //nolint:gosec // no secrets, only hashes
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.Rename(tmp, c.path)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// HashInputs is a short stable hash of anything json can encode
+func HashInputs(inputs interface{}) string {
+	b, err := json.Marshal(inputs)
+	if err != nil {
+		// unhashable inputs never match so the step always runs
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// MatchesStep is whether a --force value selects step, a group like execs
+// selects every execs/<name>
+func MatchesStep(force string, step string) bool {
+	force = strings.TrimSuffix(force, "/")
+	return force == step || strings.HasPrefix(step, force+"/")
+}
+
+// SetupOptions controls what a setup run skips
+type SetupOptions struct {
+	// Resume skips steps that completed with unchanged inputs
+	Resume bool
+	// Force runs these steps or groups even when resuming
+	Force []string
+}
+
+func (o SetupOptions) isForced(step string) bool {
+	for _, f := range o.Force {
+		if MatchesStep(f, step) {
+			return true
+		}
+	}
+	return false
+}
+
+// runStep runs fn unless resuming and the checkpoint has it done with the
+// same inputs, the outcome is recorded either way
+func (w WorkspaceIniter) runStep(step string, inputs interface{}, fn func() error) error {
+	if w.Checkpoint == nil {
+		return fn()
+	}
+	hash := HashInputs(inputs)
+	if w.Options.Resume && !w.Options.isForced(step) && w.Checkpoint.IsDone(step, hash) {
+		fmt.Printf("step %s done and unchanged, skipping\n", step)
+		return nil
+	}
+	err := fn()
+	recordErr := w.Checkpoint.Record(step, hash, err)
+	if recordErr != nil {
+		fmt.Printf("could not save setup checkpoint: %v\n", recordErr)
+	}
+	if err != nil {
+		return breverrors.Wrap(err, fmt.Sprintf("step %s", step))
+	}
+	return nil
+}
+
+// validateForce checks that every --force value names a step this setup has
+func validateForce(force []string, steps []string) error {
+	for _, f := range force {
+		found := false
+		for _, s := range steps {
+			if MatchesStep(f, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return breverrors.NewValidationError(fmt.Sprintf("no setup step %s, steps are: %s", f, strings.Join(steps, ", ")))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package setupworkspace
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".brev", "setup-checkpoint.json")
+	c, err := LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.Empty(t, c.Steps)
+
+	assert.Nil(t, c.Record("git", "h1", nil))
+	assert.Nil(t, c.Record("execs/build", "h2", errors.New("exit status 1")))
+
+	c, err = LoadCheckpoint(path)
+	assert.Nil(t, err)
+	assert.True(t, c.IsDone("git", "h1"))
+	assert.False(t, c.IsDone("git", "changed"))
+	assert.False(t, c.IsDone("execs/build", "h2"))
+	assert.Equal(t, []string{"execs/build"}, c.Failed())
+	assert.Equal(t, "exit status 1", c.Steps["execs/build"].Error)
+}
+
+func TestMatchesStep(t *testing.T) {
+	assert.True(t, MatchesStep("git", "git"))
+	assert.True(t, MatchesStep("execs", "execs/build"))
+	assert.True(t, MatchesStep("execs/", "execs/build"))
+	assert.False(t, MatchesStep("execs", "execs-v0/setup.sh"))
+	assert.False(t, MatchesStep("exec", "execs/build"))
+	assert.NotEqual(t, HashInputs(map[string]string{"a": "1"}), HashInputs(map[string]string{"a": "2"}))
+}
+
+func TestRunStep(t *testing.T) {
+	w := WorkspaceIniter{
+		ExecsV1: entity.ExecsV1{"build": {}, "serve": {}},
+	}
+	var err error
+	w.Checkpoint, err = LoadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	assert.Nil(t, err)
+
+	runs := 0
+	step := func() error { runs++; return nil }
+	assert.Nil(t, w.runStep("execs/build", "v1", step))
+	assert.Nil(t, w.runStep("execs/build", "v1", step))
+	assert.Equal(t, 2, runs, "without resume everything runs")
+
+	w.Options.Resume = true
+	assert.Nil(t, w.runStep("execs/build", "v1", step))
+	assert.Equal(t, 2, runs)
+	assert.Nil(t, w.runStep("execs/build", "v2", step))
+	assert.Equal(t, 3, runs, "changed inputs run again")
+
+	w.Options.Force = []string{"execs"}
+	assert.Nil(t, w.runStep("execs/build", "v2", step))
+	assert.Equal(t, 4, runs)
+
+	err = w.runStep("git", nil, func() error { return errors.New("no network") })
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "step git")
+	}
+
+	assert.Nil(t, validateForce([]string{"execs", "git"}, w.StepNames()))
+	assert.Error(t, validateForce([]string{"execs/nope"}, w.StepNames()))
+}
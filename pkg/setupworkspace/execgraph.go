@@ -0,0 +1,237 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// MaxParallelExecs bounds how many independent execs run at once during setup
+const MaxParallelExecs = 4
+
+// ExecGraph orders execs by their DependsOn so an exec only starts once
+// everything it depends on has succeeded
+type ExecGraph struct {
+	deps       map[entity.ExecName][]entity.ExecName
+	dependents map[entity.ExecName][]entity.ExecName
+}
+
+// NewExecGraph checks that every dependency exists and that there are no
+// cycles before anything runs
+func NewExecGraph(deps map[entity.ExecName][]entity.ExecName) (*ExecGraph, error) {
+	g := &ExecGraph{
+		deps:       map[entity.ExecName][]entity.ExecName{},
+		dependents: map[entity.ExecName][]entity.ExecName{},
+	}
+	var graphErr error
+	for _, name := range sortedExecNames(deps) {
+		g.deps[name] = nil
+		seen := map[entity.ExecName]bool{}
+		for _, dep := range deps[name] {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			if _, ok := deps[dep]; !ok {
+				graphErr = multierror.Append(graphErr, breverrors.Errorf("exec %s depends on %s which doesn't exist", name, dep))
+				continue
+			}
+			g.deps[name] = append(g.deps[name], dep)
+			g.dependents[dep] = append(g.dependents[dep], name)
+		}
+	}
+	if graphErr != nil {
+		return nil, breverrors.WrapAndTrace(graphErr)
+	}
+	cycle := g.findCycle()
+	if cycle != nil {
+		return nil, breverrors.Errorf("execs depend on each other in a cycle: %s", joinExecNames(cycle, " -> "))
+	}
+	return g, nil
+}
+
+// NewExecGraphV1 builds the graph from the dependsOn of v1 execs
+func NewExecGraphV1(execs entity.ExecsV1) (*ExecGraph, error) {
+	deps := map[entity.ExecName][]entity.ExecName{}
+	for name, e := range execs {
+		deps[name] = e.DependsOn
+	}
+	return NewExecGraph(deps)
+}
+
+// NewExecGraphV0 builds the graph from the dependsOn of v0 execs
+func NewExecGraphV0(execs entity.ExecsV0) (*ExecGraph, error) {
+	deps := map[entity.ExecName][]entity.ExecName{}
+	for name, e := range execs {
+		deps[name] = []entity.ExecName{}
+		for _, d := range e.DependsOn {
+			deps[name] = append(deps[name], entity.ExecName(d))
+		}
+	}
+	return NewExecGraph(deps)
+}
+
+// findCycle returns the names along a cycle with the first name repeated at
+// the end, nil if there isn't one
+func (g *ExecGraph) findCycle() []entity.ExecName {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[entity.ExecName]int{}
+	stack := []entity.ExecName{}
+	var visit func(name entity.ExecName) []entity.ExecName
+	visit = func(name entity.ExecName) []entity.ExecName {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range g.deps[name] {
+			switch state[dep] {
+			case visiting:
+				for i, n := range stack {
+					if n == dep {
+						return append(append([]entity.ExecName{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+	for _, name := range sortedExecNames(g.deps) {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// ExecReport is what happened to each exec in a run
+type ExecReport struct {
+	Succeeded []entity.ExecName
+	Failed    map[entity.ExecName]error
+	// Skipped maps an exec that didn't run to the failed exec it depended on
+	Skipped map[entity.ExecName]entity.ExecName
+}
+
+func (r ExecReport) Err() error {
+	var err error
+	for _, name := range sortedExecNames(r.Failed) {
+		err = multierror.Append(err, breverrors.Wrap(r.Failed[name], fmt.Sprintf("exec failed %s", name)))
+	}
+	for _, name := range sortedExecNames(r.Skipped) {
+		err = multierror.Append(err, breverrors.Errorf("exec skipped %s: depends on %s which failed", name, r.Skipped[name]))
+	}
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (r ExecReport) String() string {
+	lines := []string{fmt.Sprintf("execs: %d succeeded, %d failed, %d skipped", len(r.Succeeded), len(r.Failed), len(r.Skipped))}
+	for _, name := range sortedExecNames(r.Failed) {
+		lines = append(lines, fmt.Sprintf("  failed  %s: %v", name, r.Failed[name]))
+	}
+	for _, name := range sortedExecNames(r.Skipped) {
+		lines = append(lines, fmt.Sprintf("  skipped %s: %s failed", name, r.Skipped[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type execResult struct {
+	name entity.ExecName
+	err  error
+}
+
+// Run calls run for every exec with at most workers at a time, an exec
+// starts once its dependencies succeeded and is skipped if any of them fail.
+// Ready execs start in name order so runs are repeatable.
+func (g *ExecGraph) Run(workers int, run func(name entity.ExecName) error) ExecReport {
+	if workers < 1 {
+		workers = 1
+	}
+	report := ExecReport{
+		Failed:  map[entity.ExecName]error{},
+		Skipped: map[entity.ExecName]entity.ExecName{},
+	}
+	pending := map[entity.ExecName]int{}
+	ready := []entity.ExecName{}
+	for _, name := range sortedExecNames(g.deps) {
+		pending[name] = len(g.deps[name])
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	results := make(chan execResult)
+	running := 0
+	for len(ready) > 0 || running > 0 {
+		for len(ready) > 0 && running < workers {
+			name := ready[0]
+			ready = ready[1:]
+			running++
+			go func() {
+				results <- execResult{name: name, err: run(name)}
+			}()
+		}
+		res := <-results
+		running--
+		if res.err != nil {
+			report.Failed[res.name] = res.err
+			g.skipDependents(res.name, res.name, report.Skipped)
+			continue
+		}
+		report.Succeeded = append(report.Succeeded, res.name)
+		newlyReady := []entity.ExecName{}
+		for _, d := range g.dependents[res.name] {
+			pending[d]--
+			if pending[d] == 0 {
+				if _, skipped := report.Skipped[d]; !skipped {
+					newlyReady = append(newlyReady, d)
+				}
+			}
+		}
+		ready = append(ready, newlyReady...)
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+	}
+	return report
+}
+
+func (g *ExecGraph) skipDependents(name entity.ExecName, failed entity.ExecName, skipped map[entity.ExecName]entity.ExecName) {
+	for _, d := range g.dependents[name] {
+		if _, ok := skipped[d]; ok {
+			continue
+		}
+		skipped[d] = failed
+		g.skipDependents(d, failed, skipped)
+	}
+}
+
+func sortedExecNames[V any](m map[entity.ExecName]V) []entity.ExecName {
+	names := make([]entity.ExecName, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func joinExecNames(names []entity.ExecName, sep string) string {
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = string(n)
+	}
+	return strings.Join(s, sep)
+}
@@ -0,0 +1,133 @@
+package setupworkspace
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestNewExecGraph(t *testing.T) {
+	_, err := NewExecGraph(map[entity.ExecName][]entity.ExecName{
+		"build": {"deps"},
+		"deps":  {"nope"},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exec deps depends on nope which doesn't exist")
+	}
+
+	_, err = NewExecGraph(map[entity.ExecName][]entity.ExecName{
+		"a": {"c"},
+		"b": {"a"},
+		"c": {"b"},
+		"d": nil,
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "a -> c -> b -> a")
+	}
+
+	_, err = NewExecGraph(map[entity.ExecName][]entity.ExecName{"a": {"a"}})
+	assert.Error(t, err)
+
+	_, err = NewExecGraphV0(entity.ExecsV0{"a": {}, "b": {DependsOn: []string{"a", "a"}}})
+	assert.Nil(t, err)
+}
+
+func TestExecGraphRun(t *testing.T) {
+	g, err := NewExecGraphV1(entity.ExecsV1{
+		"deps":     {},
+		"build":    {ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"deps"}}},
+		"services": {ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"build"}}},
+		"lint":     {ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"deps"}}},
+		"docs":     {},
+	})
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	order := []entity.ExecName{}
+	report := g.Run(1, func(name entity.ExecName) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+		return nil
+	})
+	assert.Nil(t, report.Err())
+	// ready execs go in name order, docs waits behind build once deps is done
+	assert.Equal(t, []entity.ExecName{"deps", "build", "docs", "lint", "services"}, order)
+
+	report = g.Run(MaxParallelExecs, func(name entity.ExecName) error {
+		if name == "build" {
+			return errors.New("make: *** [all] Error 2")
+		}
+		return nil
+	})
+	assert.ElementsMatch(t, []entity.ExecName{"deps", "docs", "lint"}, report.Succeeded)
+	assert.Len(t, report.Failed, 1)
+	assert.Equal(t, map[entity.ExecName]entity.ExecName{"services": "build"}, report.Skipped)
+	if assert.Error(t, report.Err()) {
+		assert.Contains(t, report.Err().Error(), "exec skipped services: depends on build which failed")
+	}
+	assert.Contains(t, report.String(), "3 succeeded, 1 failed, 1 skipped")
+}
+
+func TestExecGraphRunBounded(t *testing.T) {
+	deps := map[entity.ExecName][]entity.ExecName{}
+	for _, n := range []entity.ExecName{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		deps[n] = nil
+	}
+	g, err := NewExecGraph(deps)
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	running, most := 0, 0
+	report := g.Run(3, func(_ entity.ExecName) error {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	assert.Len(t, report.Succeeded, 8)
+	assert.Equal(t, 3, most)
+}
+
+func TestExecStages(t *testing.T) {
+	build := entity.BuildStage
+	start := entity.StartStage
+	execs := entity.ExecsV1{
+		"deps":    {Stage: &build},
+		"compile": {Stage: &build, ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"deps"}}},
+		"server":  {Stage: &start, ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"compile"}}},
+		"worker":  {},
+	}
+	assert.Equal(t, entity.StartStage, execs["worker"].GetStage())
+	assert.True(t, HasStartExecs(execs))
+	assert.Nil(t, ValidateExecStages(execs))
+	assert.Len(t, execsInStage(execs, entity.BuildStage), 2)
+
+	graph, err := buildExecGraphV1(execs)
+	assert.Nil(t, err)
+	report := graph.Run(1, func(_ entity.ExecName) error { return nil })
+	assert.Equal(t, []entity.ExecName{"deps", "compile"}, report.Succeeded)
+
+	execs["deps"] = entity.ExecV1{Stage: &build, ExecOptions: entity.ExecOptions{DependsOn: []entity.ExecName{"worker"}}}
+	err = ValidateExecStages(execs)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "build exec deps can't depend on start exec worker")
+	}
+
+	w := WorkspaceIniter{WorkspaceDir: t.TempDir()}
+	assert.False(t, w.IsBuildDone("deps"))
+	assert.Nil(t, w.recordBuildDone("deps"))
+	assert.True(t, w.IsBuildDone("deps"))
+}
@@ -0,0 +1,111 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// how long a timed out exec gets to exit after SIGTERM before it is killed
+const killGrace = 10 * time.Second
+
+type ExecTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e ExecTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+// runAttempts runs cmd and, while it fails and retries are left, a fresh
+// command from newCmd writing to the same log
+func runAttempts(cmd *exec.Cmd, newCmd func() (*exec.Cmd, error), policy entity.ExecPolicy) error {
+	err := policy.Validate()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	attempts := policy.Retries + 1
+	log := cmd.Stdout
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			next, err := newCmd()
+			if err != nil {
+				return breverrors.WrapAndTrace(err)
+			}
+			next.Stdout, next.Stderr = cmd.Stdout, cmd.Stderr
+			cmd = next
+		}
+		start := time.Now()
+		err := RunWithTimeout(cmd, policy.GetTimeout())
+		if err == nil {
+			if attempt > 1 {
+				_, _ = fmt.Fprintf(log, "brev: attempt %d/%d succeeded\n", attempt, attempts)
+			}
+			return nil
+		}
+		_, _ = fmt.Fprintf(log, "brev: attempt %d/%d failed after %s: %v\n", attempt, attempts, time.Since(start).Round(time.Second), breverrors.Root(err))
+		if attempt >= attempts {
+			return breverrors.WrapAndTrace(err)
+		}
+		backoff := policy.GetRetryBackoff(attempt)
+		_, _ = fmt.Fprintf(log, "brev: retrying in %s\n", backoff)
+		time.Sleep(backoff)
+	}
+}
+
+// RunWithTimeout runs cmd in its own process group and kills the whole group
+// once timeout passes, so nothing the exec started is left holding locks.
+// A timeout of 0 waits forever.
+func RunWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	err := cmd.Start()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	select {
+	case err = <-done:
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		return nil
+	case <-timeoutC:
+	}
+
+	pgid := -cmd.Process.Pid
+	_ = syscall.Kill(pgid, syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(killGrace):
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+		<-done
+	}
+	return ExecTimeoutError{Timeout: timeout}
+}
+
+// allowFailure lets setup carry on past an exec marked continueOnFailure, its
+// dependents still run
+func allowFailure(name entity.ExecName, policy entity.ExecPolicy, err error) error {
+	if err == nil || !policy.ContinueOnFailure {
+		return err
+	}
+	fmt.Printf("exec %s failed, continuing since it is marked continueOnFailure: %v\n", name, breverrors.Root(err))
+	return nil
+}
@@ -0,0 +1,69 @@
+package setupworkspace
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	assert.Nil(t, RunWithTimeout(CmdStringBuilder("true"), time.Second))
+
+	// the background sleep is in the same process group and gets killed too
+	cmd := CmdStringBuilder("sleep 30 & sleep 30")
+	var out strings.Builder
+	cmd.Stdout = &out
+	start := time.Now()
+	err := RunWithTimeout(cmd, 200*time.Millisecond)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	var timeoutErr ExecTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+}
+
+func TestRunSetupScriptWithPolicy(t *testing.T) {
+	u, err := user.Current()
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "attempts")
+	script := filepath.Join(dir, "flaky.sh")
+	// fails the first time it runs
+	assert.Nil(t, os.WriteFile(script, []byte("#!/bin/bash\necho run >> "+counter+"\n[ $(wc -l < "+counter+") -ge 2 ]\n"), 0o700))
+
+	noBackoff := "0s"
+	logs := filepath.Join(dir, "logs")
+	err = RunSetupScriptWithPolicy(logs, dir, script, u, "", entity.ExecPolicy{Retries: 2, RetryBackoff: noBackoff})
+	assert.Nil(t, err)
+	log, err := os.ReadFile(filepath.Join(logs, "flaky.log"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(log), "brev: attempt 1/3 failed")
+	assert.Contains(t, string(log), "brev: attempt 2/3 succeeded")
+
+	assert.Nil(t, os.WriteFile(script, []byte("#!/bin/bash\nexit 3\n"), 0o700))
+	err = RunSetupScriptWithPolicy(logs, dir, script, u, "", entity.ExecPolicy{Retries: 1, RetryBackoff: noBackoff})
+	assert.Error(t, err)
+	assert.Nil(t, allowFailure("flaky", entity.ExecPolicy{ContinueOnFailure: true}, err))
+	assert.Error(t, allowFailure("flaky", entity.ExecPolicy{}, err))
+}
+
+func TestExecPolicyBackoff(t *testing.T) {
+	p := entity.ExecPolicy{}
+	assert.Equal(t, time.Duration(0), p.GetTimeout())
+	assert.Equal(t, entity.DefaultRetryBackoff, p.GetRetryBackoff(1))
+	assert.Equal(t, 4*entity.DefaultRetryBackoff, p.GetRetryBackoff(3))
+
+	p = entity.ExecPolicy{Timeout: "10m", RetryBackoff: "30s"}
+	assert.Nil(t, p.Validate())
+	assert.Equal(t, 10*time.Minute, p.GetTimeout())
+	assert.Equal(t, time.Minute, p.GetRetryBackoff(2))
+
+	assert.Error(t, entity.ExecPolicy{Timeout: "forever"}.Validate())
+	assert.Error(t, entity.ExecPolicy{RetryBackoff: "-1s"}.Validate())
+}
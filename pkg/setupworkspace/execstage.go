@@ -0,0 +1,159 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+	"github.com/brevdev/brev-cli/pkg/store"
+	"github.com/hashicorp/go-multierror"
+)
+
+// RunStartExecs runs the start stage execs, it is what the boot unit runs so
+// dev servers come back after the instance is stopped and started
+func RunStartExecs(params *store.SetupParamsV0) error {
+	user, err := GetUserFromUserStr("brev")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	wi := NewWorkspaceIniter(DefaultWorkspaceDir, user, params)
+	done, err := mirrorPipesToFile(SetupLogPath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	defer done()
+
+	fmt.Println("------ Start Execs Begin ------")
+	err = wi.RunStartExecs()
+	fmt.Println("------ Start Execs End ------")
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// HasStartExecs is whether there is anything for the boot unit to run
+func HasStartExecs(execs entity.ExecsV1) bool {
+	for _, e := range execs {
+		if e.GetStage() == entity.StartStage && !e.IsDisabled {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateExecStages checks that build execs only depend on other build
+// execs, they run at setup before any start exec has
+func ValidateExecStages(execs entity.ExecsV1) error {
+	var stageErr error
+	for _, name := range sortedExecNames(execs) {
+		if execs[name].GetStage() != entity.BuildStage {
+			continue
+		}
+		for _, dep := range execs[name].DependsOn {
+			d, ok := execs[dep]
+			if ok && d.GetStage() != entity.BuildStage {
+				stageErr = multierror.Append(stageErr, breverrors.Errorf("build exec %s can't depend on %s exec %s", name, d.GetStage(), dep))
+			}
+		}
+	}
+	if stageErr != nil {
+		return breverrors.WrapAndTrace(stageErr)
+	}
+	return nil
+}
+
+func execsInStage(execs entity.ExecsV1, stage entity.ExecStage) entity.ExecsV1 {
+	staged := entity.ExecsV1{}
+	for n, e := range execs {
+		if e.GetStage() == stage {
+			staged[n] = e
+		}
+	}
+	return staged
+}
+
+// buildExecGraphV1 checks the whole v1 graph and returns the part that runs
+// at setup
+func buildExecGraphV1(execs entity.ExecsV1) (*ExecGraph, error) {
+	_, err := NewExecGraphV1(execs)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	err = ValidateExecStages(execs)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	graph, err := NewExecGraphV1(execsInStage(execs, entity.BuildStage))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	return graph, nil
+}
+
+// runBuildExec runs a build exec unless a previous setup already did
+func (w WorkspaceIniter) runBuildExec(name entity.ExecName) error {
+	if w.IsBuildDone(name) && !w.Options.isForced("execs/"+string(name)) {
+		fmt.Printf("build exec %s already done, not running\n", name)
+		return nil
+	}
+	err := w.runExecV1(name, w.ExecsV1[name])
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = w.recordBuildDone(name)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// RunStartExecs runs every start exec once the build execs it depends on are
+// done. Start execs are usually servers that don't exit, so they all get a
+// worker and anything depending on one only starts if it exits.
+func (w WorkspaceIniter) RunStartExecs() error {
+	graph, err := NewExecGraphV1(w.ExecsV1)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	report := graph.Run(len(w.ExecsV1), func(n entity.ExecName) error {
+		if w.ExecsV1[n].GetStage() == entity.BuildStage {
+			if !w.IsBuildDone(n) {
+				return breverrors.Errorf("build exec %s hasn't completed, check the setup logs", n)
+			}
+			return nil
+		}
+		return allowFailure(n, w.ExecsV1[n].ExecPolicy, logExecResult(n, w.runExecV1(n, w.ExecsV1[n])))
+	})
+	fmt.Println(report)
+	err = report.Err()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (w WorkspaceIniter) buildDonePath(name entity.ExecName) string {
+	return w.BuildWorkspacePath(".brev", "done", string(name))
+}
+
+// IsBuildDone is whether the build exec completed in an earlier setup
+func (w WorkspaceIniter) IsBuildDone(name entity.ExecName) bool {
+	return PathExists(w.buildDonePath(name))
+}
+
+func (w WorkspaceIniter) recordBuildDone(name entity.ExecName) error {
+	donePath := w.buildDonePath(name)
+	err := os.MkdirAll(filepath.Dir(donePath), 0o775) //nolint:gosec // occurs in safe area
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.WriteFile(donePath, []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0o644) //nolint:gosec // occurs in safe area
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
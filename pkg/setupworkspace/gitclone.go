@@ -0,0 +1,115 @@
+package setupworkspace
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// GitCommand is one git invocation of a clone, every command after the clone
+// itself runs inside the cloned repo
+type GitCommand struct {
+	Args []string
+	Env  []string
+}
+
+// GitCloneCommands is what cloning url to dirPath with opts runs. LFS files
+// are skipped during the clone and pulled at the end so a sparse clone only
+// downloads the ones it checks out.
+func GitCloneCommands(url string, dirPath string, opts entity.GitRepoOptions) []GitCommand {
+	depth := opts.GetDepth()
+	branch := opts.GetBranch()
+	commit := opts.GetCommit()
+
+	clone := GitCommand{Args: []string{"clone"}}
+	if depth > 0 {
+		clone.Args = append(clone.Args, "--depth", strconv.Itoa(depth))
+		if branch != "" {
+			// a shallow clone only has the default branch unless told otherwise
+			clone.Args = append(clone.Args, "--branch", branch)
+		}
+	}
+	if len(opts.SparsePaths) > 0 {
+		clone.Args = append(clone.Args, "--filter=blob:none", "--sparse")
+	}
+	// with a pinned commit submodules are updated after it is checked out
+	if opts.GetSubmodules() && commit == "" {
+		clone.Args = append(clone.Args, "--recurse-submodules")
+		if depth > 0 {
+			clone.Args = append(clone.Args, "--shallow-submodules")
+		}
+	}
+	if opts.LFS {
+		clone.Env = append(clone.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
+	clone.Args = append(clone.Args, url, dirPath)
+	cmds := []GitCommand{clone}
+
+	if len(opts.SparsePaths) > 0 {
+		cmds = append(cmds, GitCommand{Args: append([]string{"sparse-checkout", "set"}, opts.SparsePaths...)})
+	}
+	if commit != "" {
+		if depth > 0 {
+			cmds = append(cmds, GitCommand{Args: []string{"fetch", "--depth", strconv.Itoa(depth), "origin", commit}})
+		}
+		cmds = append(cmds, GitCommand{Args: []string{"checkout", commit}})
+		if opts.GetSubmodules() {
+			update := []string{"submodule", "update", "--init", "--recursive"}
+			if depth > 0 {
+				update = append(update, "--depth", "1")
+			}
+			cmds = append(cmds, GitCommand{Args: update})
+		}
+	} else if branch != "" && depth == 0 {
+		cmds = append(cmds, GitCommand{Args: []string{"checkout", branch}})
+	}
+	if opts.LFS {
+		cmds = append(cmds,
+			GitCommand{Args: []string{"lfs", "install", "--local"}},
+			GitCommand{Args: []string{"lfs", "pull"}},
+		)
+	}
+	return cmds
+}
+
+// GitCloneWithOptions clones url to dirPath as the user unless something is
+// already there. A clone that fails part way is removed so the next attempt
+// doesn't mistake it for a finished one.
+func (w WorkspaceIniter) GitCloneWithOptions(url string, dirPath string, opts entity.GitRepoOptions) error {
+	if PathExists(dirPath) {
+		fmt.Printf("path already exists, did not clone %s to %s\n", url, dirPath)
+		return nil
+	}
+	fmt.Printf("cloning %s to dir '%s'\n", url, dirPath)
+	err := w.runGitCloneCommands(url, dirPath, opts)
+	if err != nil {
+		rmErr := os.RemoveAll(dirPath)
+		if rmErr != nil {
+			fmt.Printf("could not remove partial clone %s: %v\n", dirPath, rmErr)
+		}
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func (w WorkspaceIniter) runGitCloneCommands(url string, dirPath string, opts entity.GitRepoOptions) error {
+	for i, c := range GitCloneCommands(url, dirPath, opts) {
+		cmd := CmdBuilder("git", c.Args...)
+		if i > 0 {
+			cmd.Dir = dirPath
+		}
+		err := w.CmdAsUser(cmd)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		cmd.Env = append(cmd.Env, c.Env...)
+		err = cmd.Run()
+		if err != nil {
+			return breverrors.Wrap(err, fmt.Sprintf("git %s", c.Args[0]))
+		}
+	}
+	return nil
+}
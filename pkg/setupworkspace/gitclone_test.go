@@ -0,0 +1,62 @@
+package setupworkspace
+
+import (
+	"testing"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func cloneArgs(cmds []GitCommand) [][]string {
+	a := [][]string{}
+	for _, c := range cmds {
+		a = append(a, c.Args)
+	}
+	return a
+}
+
+func TestGitCloneCommandsDefault(t *testing.T) {
+	branch := "dev"
+	cmds := GitCloneCommands("git@github.com:brevdev/brev-cli.git", "/w/brev-cli", entity.GitRepoOptions{Branch: &branch})
+	assert.Equal(t, [][]string{
+		{"clone", "--recurse-submodules", "git@github.com:brevdev/brev-cli.git", "/w/brev-cli"},
+		{"checkout", "dev"},
+	}, cloneArgs(cmds))
+	assert.Empty(t, cmds[0].Env)
+}
+
+func TestGitCloneCommandsShallowSparseLFS(t *testing.T) {
+	branch := "dev"
+	depth := 1
+	cmds := GitCloneCommands("url", "dir", entity.GitRepoOptions{
+		Branch:      &branch,
+		Depth:       &depth,
+		LFS:         true,
+		SparsePaths: []string{"services/api", "libs"},
+	})
+	assert.Equal(t, [][]string{
+		{"clone", "--depth", "1", "--branch", "dev", "--filter=blob:none", "--sparse", "--recurse-submodules", "--shallow-submodules", "url", "dir"},
+		{"sparse-checkout", "set", "services/api", "libs"},
+		{"lfs", "install", "--local"},
+		{"lfs", "pull"},
+	}, cloneArgs(cmds))
+	assert.Equal(t, []string{"GIT_LFS_SKIP_SMUDGE=1"}, cmds[0].Env)
+}
+
+func TestGitCloneCommandsPinnedCommit(t *testing.T) {
+	commit := "0123abc"
+	depth := 5
+	cmds := GitCloneCommands("url", "dir", entity.GitRepoOptions{Commit: &commit, Depth: &depth})
+	assert.Equal(t, [][]string{
+		{"clone", "--depth", "5", "url", "dir"},
+		{"fetch", "--depth", "5", "origin", "0123abc"},
+		{"checkout", "0123abc"},
+		{"submodule", "update", "--init", "--recursive", "--depth", "1"},
+	}, cloneArgs(cmds))
+}
+
+func TestGitCloneCommandsNoSubmodules(t *testing.T) {
+	submodules := false
+	cmds := GitCloneCommands("url", "dir", entity.GitRepoOptions{Submodules: &submodules})
+	assert.Equal(t, [][]string{{"clone", "url", "dir"}}, cloneArgs(cmds))
+}
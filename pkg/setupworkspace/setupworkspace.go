@@ -12,6 +12,7 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -20,7 +21,7 @@ import (
 	"github.com/brevdev/brev-cli/pkg/cmd/version"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
-	"github.com/brevdev/parse/pkg/parse"
+	"github.com/brevdev/brev-cli/pkg/giturl"
 
 	"github.com/brevdev/brev-cli/pkg/store"
 	"github.com/brevdev/brev-cli/pkg/uri"
@@ -28,15 +29,25 @@ import (
 	"github.com/hashicorp/go-multierror"
 )
 
-func SetupWorkspace(params *store.SetupParamsV0) error {
+const (
+	DefaultWorkspaceDir = "/home/brev/workspace"
+	// SetupLogPath collects the output of every setup and start execs run
+	SetupLogPath = "/var/log/brev-workspace.log"
+)
+
+func SetupWorkspace(params *store.SetupParamsV0, opts SetupOptions) error {
 	user, err := GetUserFromUserStr("brev")
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	workspaceDir := "/home/brev/workspace"
-	wi := NewWorkspaceIniter(workspaceDir, user, params)
-	logFilePath := "/var/log/brev-workspace.log"
+	wi := NewWorkspaceIniter(DefaultWorkspaceDir, user, params)
+	wi.Options = opts
+	wi.Checkpoint, err = LoadCheckpoint(wi.CheckpointPath())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	logFilePath := SetupLogPath
 	done, err := mirrorPipesToFile(logFilePath)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
@@ -47,8 +58,16 @@ func SetupWorkspace(params *store.SetupParamsV0) error {
 	fmt.Println("------ Setup Begin ------")
 	err = wi.Setup()
 	fmt.Println("------ Setup End ------")
+	chownErr := ChownFilePathToUser(wi.CheckpointPath(), user)
+	if chownErr != nil {
+		fmt.Printf("could not chown setup checkpoint: %v\n", chownErr)
+	}
 	if err != nil {
 		fmt.Println("------ Failure ------")
+		if failed := wi.Checkpoint.Failed(); len(failed) > 0 {
+			fmt.Printf("failed steps: %s\n", strings.Join(failed, ", "))
+			fmt.Println("fix them and run: brev setupworkspace --resume")
+		}
 		time.Sleep(time.Millisecond * 100) // wait for buffer to be written
 		logFile, errF := ioutil.ReadFile(logFilePath)
 		if errF != nil {
@@ -137,6 +156,8 @@ type WorkspaceIniter struct {
 	ReposV1            entity.ReposV1
 	ExecsV1            entity.ExecsV1
 	VscodeExtensionIDs []string
+	Checkpoint         *Checkpoint
+	Options            SetupOptions
 }
 
 func NewWorkspaceIniter(workspaceDir string, user *user.User, params *store.SetupParamsV0) *WorkspaceIniter {
@@ -311,6 +332,11 @@ func SendLogToFiles(cmd *exec.Cmd, filePaths ...string) (func(), error) {
 	}, nil
 }
 
+// CheckpointPath is where setup records the steps it completed
+func (w WorkspaceIniter) CheckpointPath() string {
+	return w.BuildHomePath(".brev", "setup-checkpoint.json")
+}
+
 func (w WorkspaceIniter) BuildHomePath(suffix ...string) string {
 	return filepath.Join(append([]string{w.User.HomeDir}, suffix...)...)
 }
@@ -325,35 +351,45 @@ func (w WorkspaceIniter) Setup() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	err = validateForce(w.Options.Force, w.StepNames())
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
 
-	err = w.PrepareWorkspace()
+	err = w.runStep("prepare", w.User.Username, w.PrepareWorkspace)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
 	postPrepare := util.RunEAsync(
 		func() error {
-			err0 := w.SetupVsCodeExtensions()
+			err0 := w.runStep("vscode-extensions", w.VscodeExtensionIDs, w.SetupVsCodeExtensions)
 			if err0 != nil {
 				fmt.Println(err0)
 			}
 			return nil
 		},
 		func() error {
-			err = w.SetupCodeServer(w.Params.WorkspacePassword, fmt.Sprintf("127.0.0.1:%d", w.Params.WorkspacePort), string(w.Params.WorkspaceHost))
-			if err != nil {
-				return breverrors.WrapAndTrace(err)
+			bindAddr := fmt.Sprintf("127.0.0.1:%d", w.Params.WorkspacePort)
+			inputs := []string{w.Params.WorkspacePassword, bindAddr, string(w.Params.WorkspaceHost)}
+			err1 := w.runStep("code-server", inputs, func() error {
+				return w.SetupCodeServer(w.Params.WorkspacePassword, bindAddr, string(w.Params.WorkspaceHost))
+			})
+			if err1 != nil {
+				return breverrors.WrapAndTrace(err1)
 			}
 			return nil
 		},
 	)
 
-	err = w.SetupSSH(w.Params.WorkspaceKeyPair)
+	err = w.runStep("ssh", w.Params.WorkspaceKeyPair, func() error {
+		return w.SetupSSH(w.Params.WorkspaceKeyPair)
+	})
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
 
-	err = w.SetupGit()
+	err = w.runStep("git", []string{w.Params.WorkspaceUsername, w.Params.WorkspaceEmail}, w.SetupGit)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -362,17 +398,17 @@ func (w WorkspaceIniter) Setup() error {
 
 	err = w.SetupRepos()
 	if err != nil {
-		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
+		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
 
 	err = w.RunExecs()
 	if err != nil {
-		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
+		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
 
-	err = w.CreateVerbYamlFile()
+	err = w.runStep("verb-yaml", w.Params.VerbYaml, w.CreateVerbYamlFile)
 	if err != nil {
-		setupErr = multierror.Append(breverrors.WrapAndTrace(err))
+		setupErr = multierror.Append(setupErr, breverrors.WrapAndTrace(err))
 	}
 
 	if setupErr != nil {
@@ -387,24 +423,53 @@ func (w WorkspaceIniter) Setup() error {
 	return nil
 }
 
+// StepNames lists the checkpointed steps of this setup for --force
+func (w WorkspaceIniter) StepNames() []string {
+	steps := []string{"prepare", "vscode-extensions", "code-server", "ssh", "git"}
+	for _, n := range sortedRepoNames(w.ReposV1) {
+		steps = append(steps, "repos/"+string(n))
+	}
+	for _, n := range sortedRepoNames(w.ReposV0) {
+		steps = append(steps, "repos-v0/"+string(n))
+	}
+	for _, n := range sortedExecNames(w.ExecsV1) {
+		steps = append(steps, "execs/"+string(n))
+	}
+	for _, n := range sortedExecNames(w.ExecsV0) {
+		steps = append(steps, "execs-v0/"+string(n))
+	}
+	return append(steps, "verb-yaml")
+}
+
+func sortedRepoNames[V any](m map[entity.RepoName]V) []entity.RepoName {
+	names := make([]entity.RepoName, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
 func (w WorkspaceIniter) SetupRepos() error {
 	var setupErr error
-	for n, r := range w.ReposV1 {
+	for _, n := range sortedRepoNames(w.ReposV1) {
+		r := w.ReposV1[n]
 		fmt.Printf("setting up repo v1 %s\n", n)
-		err := w.setupRepoV1(r)
+		err := w.runStep("repos/"+string(n), r, func() error { return w.setupRepoV1(r) })
 		if err != nil {
 			fmt.Printf("setup failed %s\n", n)
-			setupErr = multierror.Append(breverrors.Wrap(err, fmt.Sprintf("setup failed %s", n)))
+			setupErr = multierror.Append(setupErr, breverrors.Wrap(err, fmt.Sprintf("setup failed %s", n)))
 		} else {
 			fmt.Printf("setup success %s\n", n)
 		}
 	}
-	for n, r := range w.ReposV0 {
+	for _, n := range sortedRepoNames(w.ReposV0) {
+		r := w.ReposV0[n]
 		fmt.Printf("setting up repo v0 %s\n", n)
-		err := w.setupRepoV0(r)
+		err := w.runStep("repos-v0/"+string(n), r, func() error { return w.setupRepoV0(r) })
 		if err != nil {
 			fmt.Printf("setup failed %s\n", n)
-			setupErr = multierror.Append(breverrors.Wrap(err, fmt.Sprintf("setup failed %s", n)))
+			setupErr = multierror.Append(setupErr, breverrors.Wrap(err, fmt.Sprintf("setup failed %s", n)))
 		} else {
 			fmt.Printf("setup success %s\n", n)
 		}
@@ -415,31 +480,47 @@ func (w WorkspaceIniter) SetupRepos() error {
 	return nil
 }
 
+// RunExecs runs v0 then v1 build execs, each set in dependency order with
+// independent execs in parallel, start execs are left to the boot unit
 func (w WorkspaceIniter) RunExecs() error {
 	dotBrev := filepath.Join(w.BuildWorkspacePath(), ".brev")
 	err := w.setupDotBrev(dotBrev)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
+	graphV0, err := NewExecGraphV0(w.ExecsV0)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	graphV1, err := buildExecGraphV1(w.ExecsV1)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+
 	var execErr error
-	for n, e := range w.ExecsV0 {
-		err := w.runExecV0(n, e)
-		if err != nil {
-			fmt.Printf("exec failed %s\n", n)
-			execErr = multierror.Append(breverrors.Wrap(err, fmt.Sprintf("exec failed %s", n)))
-		} else {
-			fmt.Printf("exec success %s\n", n)
-		}
+	reportV0 := graphV0.Run(MaxParallelExecs, func(n entity.ExecName) error {
+		return allowFailure(n, w.ExecsV0[n].ExecPolicy, logExecResult(n, w.runStep("execs-v0/"+string(n), w.ExecsV0[n], func() error {
+			return w.runExecV0(n, w.ExecsV0[n])
+		})))
+	})
+	if len(w.ExecsV0) > 0 {
+		fmt.Println(reportV0)
 	}
-	for n, e := range w.ExecsV1 {
-		_ = e
-		err := w.runExecV1(n, e)
-		if err != nil {
-			fmt.Printf("exec failed %s\n", n)
-			execErr = multierror.Append(breverrors.Wrap(err, fmt.Sprintf("exec failed %s", n)))
-		} else {
-			fmt.Printf("exec success %s\n", n)
-		}
+	err = reportV0.Err()
+	if err != nil {
+		execErr = multierror.Append(execErr, err)
+	}
+	reportV1 := graphV1.Run(MaxParallelExecs, func(n entity.ExecName) error {
+		return allowFailure(n, w.ExecsV1[n].ExecPolicy, logExecResult(n, w.runStep("execs/"+string(n), w.ExecsV1[n], func() error {
+			return w.runBuildExec(n)
+		})))
+	})
+	if len(execsInStage(w.ExecsV1, entity.BuildStage)) > 0 {
+		fmt.Println(reportV1)
+	}
+	err = reportV1.Err()
+	if err != nil {
+		execErr = multierror.Append(execErr, err)
 	}
 	if execErr != nil {
 		return breverrors.WrapAndTrace(execErr)
@@ -447,9 +528,18 @@ func (w WorkspaceIniter) RunExecs() error {
 	return nil
 }
 
+func logExecResult(name entity.ExecName, err error) error {
+	if err != nil {
+		fmt.Printf("exec failed %s\n", name)
+		return err
+	}
+	fmt.Printf("exec success %s\n", name)
+	return nil
+}
+
 func (w WorkspaceIniter) runExecV1(name entity.ExecName, exec entity.ExecV1) error {
 	if exec.IsDisabled {
-		fmt.Printf("exec %s disabled, not running", name)
+		fmt.Printf("exec %s disabled, not running\n", name)
 		return nil
 	}
 	execWorkDir := ""
@@ -481,7 +571,7 @@ func (w WorkspaceIniter) runExecV1(name entity.ExecName, exec entity.ExecV1) err
 		}
 	}
 
-	err = RunSetupScript(logPath, workDirPath, execPath, w.User, logArchPath)
+	err = RunSetupScriptWithPolicy(logPath, workDirPath, execPath, w.User, logArchPath, exec.ExecPolicy)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -608,6 +698,36 @@ func (w WorkspaceIniter) GetLogArchivePath(name entity.ExecName, exec entity.Exe
 	return logArchPath, nil
 }
 
+// ExecLogFile is the log RunSetupScript writes for the script at execPath,
+// runs before the latest are in the archive dir as <name>-<start time>.log
+func ExecLogFile(logsPath string, execPath string) string {
+	return filepath.Join(logsPath, fmt.Sprintf("%s.log", util.RemoveFileExtenstion(filepath.Base(execPath))))
+}
+
+// GetExecLogFiles returns the latest log of a v1 exec and its archive dir
+func (w WorkspaceIniter) GetExecLogFiles(name entity.ExecName, exec entity.ExecV1) (string, string, error) {
+	execPath, err := w.GetExecPath(name, exec)
+	if err != nil {
+		return "", "", breverrors.WrapAndTrace(err)
+	}
+	logPath, err := w.GetLogPath(name, exec)
+	if err != nil {
+		return "", "", breverrors.WrapAndTrace(err)
+	}
+	archivePath, err := w.GetLogArchivePath(name, exec)
+	if err != nil {
+		return "", "", breverrors.WrapAndTrace(err)
+	}
+	return ExecLogFile(logPath, execPath), archivePath, nil
+}
+
+// GetExecV0LogFiles returns the latest log of a v0 exec, including the
+// setup.sh made from the setup script, and its archive dir
+func (w WorkspaceIniter) GetExecV0LogFiles(name entity.ExecName) (string, string) {
+	logPath := filepath.Join(w.BuildWorkspacePath(), ".brev", "logs")
+	return ExecLogFile(logPath, string(name)), filepath.Join(logPath, "archive")
+}
+
 func (w WorkspaceIniter) runExecV0(name entity.ExecName, exec entity.ExecV0) error {
 	workDir := filepath.Join(w.BuildWorkspacePath(), exec.ExecWorkDir)
 	dotBrev := filepath.Join(w.BuildWorkspacePath(), ".brev")
@@ -633,7 +753,7 @@ func (w WorkspaceIniter) runExecV0(name entity.ExecName, exec entity.ExecV0) err
 		fmt.Println(err)
 	}
 
-	err = RunSetupScript(logPath, workDir, setupExecPath, w.User, "")
+	err = RunSetupScriptWithPolicy(logPath, workDir, setupExecPath, w.User, "", exec.ExecPolicy)
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
@@ -1043,20 +1163,24 @@ tar --no-same-owner -xzv --strip-components=1 -C ~/.vscode-server/bin/"${commit_
 }
 
 func allRepoFormats(repo string) []string {
-	repos := []string{
-		repo,
-		parse.GetSSHURLFromOrigin(repo),
-		parse.GetHTTPSURLFromOrigin(repo),
-		parse.GetHTTPURLFromOrigin(repo),
-	}
-	// some of these may be empty strings
-	nonEmptyRepos := []string{}
-	for _, r := range repos {
-		if r != "" {
-			nonEmptyRepos = append(nonEmptyRepos, r)
+	u, err := giturl.Parse(repo)
+	if err != nil {
+		return []string{repo}
+	}
+	formats := []string{u.SSH(), u.HTTPS(), u.HTTP()}
+	if u.Ref == "" {
+		// try it as written first, git can't clone it with a ref on the end
+		formats = append([]string{repo}, formats...)
+	}
+	repos := []string{}
+	seen := map[string]bool{}
+	for _, r := range formats {
+		if !seen[r] {
+			seen[r] = true
+			repos = append(repos, r)
 		}
 	}
-	return nonEmptyRepos
+	return repos
 }
 
 func (w WorkspaceIniter) setupRepoV1(repo entity.RepoV1) error {
@@ -1065,15 +1189,13 @@ func (w WorkspaceIniter) setupRepoV1(repo entity.RepoV1) error {
 		return breverrors.WrapAndTrace(err)
 	}
 	if repo.Type == entity.GitRepoType { //nolint:gocritic // i like if
-		branch := ""
-		if repo.GitRepo.Branch != nil {
-			branch = *repo.GitRepo.Branch
-		}
-		fmt.Println("setuprepov1: ", repoPath, branch)
-		repository := repo.GitRepo.Repository
-		repos := allRepoFormats(repository)
-		for _, repoURL := range repos {
-			err = w.GitCloneIfDNE(repoURL, repoPath, branch)
+		fmt.Println("setuprepov1: ", repoPath, repo.GitRepo.GetBranch())
+		// try each way of reaching the repo until one clones
+		for _, repoURL := range allRepoFormats(repo.GitRepo.Repository) {
+			err = w.GitCloneWithOptions(gitURL(repoURL), repoPath, repo.GitRepo.GitRepoOptions)
+			if err == nil {
+				break
+			}
 		}
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
@@ -1228,43 +1350,30 @@ func (w WorkspaceIniter) setupDotBrev(dotBrevPath string) error {
 }
 
 func (w WorkspaceIniter) GitCloneIfDNE(url string, dirPath string, branch string) error {
-	if !PathExists(dirPath) {
-		// TODO implement multiple retry
-		fmt.Println("pre", url)
-		if !strings.HasPrefix(url, "git@") && !strings.HasPrefix(url, "http") {
-			url = "git@" + url
-		}
-		fmt.Printf("cloning %s to dir '%s'\n", url, dirPath)
-		cmd := CmdBuilder("git", "clone", "--recursive", url, dirPath)
-		err := w.CmdAsUser(cmd)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		err = cmd.Run()
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		if branch != "" {
-			cmd = CmdBuilder("git", "checkout", branch)
-			cmd.Dir = dirPath
-			err = w.CmdAsUser(cmd)
-			if err != nil {
-				return breverrors.WrapAndTrace(err)
-			}
-			err = cmd.Run()
-			if err != nil {
-				return breverrors.WrapAndTrace(err)
-			}
-		}
-	} else {
-		fmt.Printf("path already exists, did not clone %s to %s\n", url, dirPath)
+	opts := entity.GitRepoOptions{}
+	if branch != "" {
+		opts.Branch = &branch
 	}
-	return nil
+	return w.GitCloneWithOptions(gitURL(url), dirPath, opts)
+}
+
+// gitURL adds the git@ that ssh remotes saved without one need
+func gitURL(url string) string {
+	if !strings.HasPrefix(url, "git@") && !strings.HasPrefix(url, "http") && !strings.Contains(url, "://") {
+		return "git@" + url
+	}
+	return url
 }
 
 func RunSetupScript(logsPath string, workingDir string, setupExecPath string, user *user.User, archivePath string) error {
+	return RunSetupScriptWithPolicy(logsPath, workingDir, setupExecPath, user, archivePath, entity.ExecPolicy{})
+}
+
+// RunSetupScriptWithPolicy runs the script with the exec's timeout and
+// retries, every attempt's outcome goes to the exec's log
+func RunSetupScriptWithPolicy(logsPath string, workingDir string, setupExecPath string, user *user.User, archivePath string, policy entity.ExecPolicy) error {
 	namePrefix := util.RemoveFileExtenstion(filepath.Base(setupExecPath))
-	setupLogPath := filepath.Join(logsPath, fmt.Sprintf("%s.log", namePrefix))
+	setupLogPath := ExecLogFile(logsPath, setupExecPath)
 	if archivePath == "" {
 		archivePath = filepath.Join(logsPath, "archive")
 	}
@@ -1272,36 +1381,43 @@ func RunSetupScript(logsPath string, workingDir string, setupExecPath string, us
 	if workingDir == "" {
 		workingDir = filepath.Dir(setupExecPath)
 	}
-	if PathExists(setupExecPath) {
-		err := os.Chmod(setupExecPath, 0o700) //nolint:gosec // occurs in safe area
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
+	if !PathExists(setupExecPath) {
+		fmt.Printf("no setup script found at %s\n", setupExecPath)
+		return nil
+	}
+	err := os.Chmod(setupExecPath, 0o700) //nolint:gosec // occurs in safe area
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	newCmd := func() (*exec.Cmd, error) {
 		cmd := CmdStringBuilder(fmt.Sprintf("echo user: $(whoami) && echo pwd: $(pwd) && export PATH=\"/opt/conda/bin:$PATH\" && %s", setupExecPath))
 		cmd.Dir = workingDir
-		err = CmdAsUser(cmd, user)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		err = os.MkdirAll(logsPath, os.ModePerm)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
+		cmdErr := CmdAsUser(cmd, user)
+		if cmdErr != nil {
+			return nil, breverrors.WrapAndTrace(cmdErr)
 		}
-		err = os.MkdirAll(archivePath, os.ModePerm)
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		done, err := SendLogToFiles(cmd, setupLogPath, archiveLogFile)
-		defer done()
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-		err = cmd.Run()
-		if err != nil {
-			return breverrors.WrapAndTrace(err)
-		}
-	} else {
-		fmt.Printf("no setup script found at %s\n", setupExecPath)
+		return cmd, nil
+	}
+	cmd, err := newCmd()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.MkdirAll(logsPath, os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = os.MkdirAll(archivePath, os.ModePerm)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	done, err := SendLogToFiles(cmd, setupLogPath, archiveLogFile)
+	defer done()
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = runAttempts(cmd, newCmd, policy)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
 	}
 	return nil
 }
@@ -458,7 +458,7 @@ func (s SSHConfigurerV2) EnsureWSLConfigHasInclude() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if !doesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+	if !DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
 		newConf := WSLAddIncludeToUserConfig(conf, brevConfigPath)
 		err := s.store.WriteWSLUserSSHConfig(newConf)
 		if err != nil {
@@ -480,7 +480,7 @@ func (s SSHConfigurerV2) EnsureConfigHasInclude() error {
 	if err != nil {
 		return breverrors.WrapAndTrace(err)
 	}
-	if !doesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
+	if !DoesUserSSHConfigIncludeBrevConfig(conf, brevConfigPath) {
 		newConf, err := AddIncludeToUserConfig(conf, brevConfigPath)
 		if err != nil {
 			return breverrors.WrapAndTrace(err)
@@ -513,7 +513,8 @@ func makeIncludeBrevStr(brevSSHConfigPath string) string {
 	return fmt.Sprintf("Include \"%s\"\n", brevSSHConfigPath)
 }
 
-func doesUserSSHConfigIncludeBrevConfig(conf string, brevConfigPath string) bool {
+// DoesUserSSHConfigIncludeBrevConfig is whether conf has the Include brev adds for its ssh config
+func DoesUserSSHConfigIncludeBrevConfig(conf string, brevConfigPath string) bool {
 	return strings.Contains(conf, makeIncludeBrevStr(brevConfigPath))
 }
 
@@ -220,16 +220,16 @@ func TestDoesUserSSHConfigIncludeBrevConfig(t *testing.T) {
 	}
 
 	userConf := ``
-	assert.False(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.False(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 
 	userConf = `Include "/my/brev/config"
 `
-	assert.True(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.True(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 
 	userConf = `# blahdlkfadlfa
 Include "/my/brev/config"
 # baldfhaldjf`
-	assert.True(t, doesUserSSHConfigIncludeBrevConfig(userConf, bscp))
+	assert.True(t, DoesUserSSHConfigIncludeBrevConfig(userConf, bscp))
 }
 
 func TestAddIncludeToUserConfig(t *testing.T) {
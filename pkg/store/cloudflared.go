@@ -1,16 +1,24 @@
 package store
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
 	"github.com/brevdev/brev-cli/pkg/collections"
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/errors"
 )
 
@@ -21,20 +29,47 @@ type CloudflaredStore interface {
 	Chmod(string, fs.FileMode) error
 	MkdirAll(string, fs.FileMode) error
 	Create(string) (io.WriteCloser, error)
+	Rename(string, string) error
 }
 
 type Cloudflared struct {
 	store CloudflaredStore
+	// Mirror replaces github as the download source, laid out as
+	// <mirror>/<version>/<asset> with a sha256sum style <mirror>/<version>/checksums.txt
+	Mirror string
+	// SHA256 pins the asset checksum instead of reading it from the release notes
+	SHA256 string
+
+	installedVersion func(binaryPath string) (string, error)
+	get              func(ctx context.Context, url string) ([]byte, error)
 }
 
 func NewCloudflare(store CloudflaredStore) Cloudflared {
 	return Cloudflared{
-		store: store,
+		store:  store,
+		Mirror: config.GlobalConfig.GetCloudflaredMirror(),
+		SHA256: config.GlobalConfig.GetCloudflaredSHA256(),
 	}
 }
 
 var CloudflaredVersion = "2024.10.0"
 
+const (
+	cloudflaredDownloadURL = "https://github.com/cloudflare/cloudflared/releases/download"
+	cloudflaredReleaseURL  = "https://api.github.com/repos/cloudflare/cloudflared/releases/tags/%s"
+	// the file a mirror serves next to the assets instead of release notes
+	cloudflaredMirrorChecksums = "checksums.txt"
+)
+
+var (
+	cloudflaredVersionRe = regexp.MustCompile(`version (\S+)`)
+	// the release notes list "cloudflared-linux-amd64: <sha256>"
+	cloudflaredChecksumRe = regexp.MustCompile(`(?m)^\s*(cloudflared[\w.-]*)\s*:\s*([0-9a-fA-F]{64})\s*$`)
+	sha256Re              = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+)
+
+// DownloadCloudflaredBinaryIfItDNE downloads cloudflared when it's missing or
+// isn't the pinned version, an installed binary is kept if the download fails
 func (c Cloudflared) DownloadCloudflaredBinaryIfItDNE() error {
 	binaryPath, err := c.store.GetBrevCloudflaredBinaryPath()
 	if err != nil {
@@ -45,74 +80,271 @@ func (c Cloudflared) DownloadCloudflaredBinaryIfItDNE() error {
 		return errors.WrapAndTrace(err)
 	}
 	if binaryExists {
-		return nil
+		version, versionErr := c.versionOf(binaryPath)
+		if versionErr == nil && version == CloudflaredVersion {
+			return nil
+		}
 	}
-	binaryURL, err := getCloudflaredBinaryDownloadURL()
+	err = c.download(context.TODO(), binaryPath)
 	if err != nil {
+		if binaryExists {
+			fmt.Fprintf(os.Stderr, "warning: could not update cloudflared to %s, using the installed one: %v\n", CloudflaredVersion, errors.Root(err))
+			return nil
+		}
 		return errors.WrapAndTrace(err)
 	}
-	err = c.DownloadBinary(context.TODO(), binaryPath, binaryURL)
+	return nil
+}
+
+// Update downloads and verifies the pinned cloudflared even if it's installed
+func (c Cloudflared) Update() error {
+	binaryPath, err := c.store.GetBrevCloudflaredBinaryPath()
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
-	err = c.store.Chmod(binaryPath, 0o755)
+	err = c.download(context.TODO(), binaryPath)
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func (c Cloudflared) DownloadBinary(ctx context.Context, binaryPath, binaryURL string) error {
-	resp, err := collections.GetRequestWithContext(ctx, binaryURL)
+type CloudflaredStatus struct {
+	BinaryPath       string
+	Installed        bool
+	InstalledVersion string
+	PinnedVersion    string
+	URL              string
+	ChecksumPinned   bool
+	// ChecksumURL is the mirror's checksums file, empty when they come from the release notes
+	ChecksumURL string
+}
+
+func (s CloudflaredStatus) UpToDate() bool {
+	return s.Installed && s.InstalledVersion == s.PinnedVersion
+}
+
+func (c Cloudflared) Status() (*CloudflaredStatus, error) {
+	binaryPath, err := c.store.GetBrevCloudflaredBinaryPath()
+	if err != nil {
+		return nil, errors.WrapAndTrace(err)
+	}
+	binaryURL, err := c.DownloadURL()
+	if err != nil {
+		return nil, errors.WrapAndTrace(err)
+	}
+	status := &CloudflaredStatus{
+		BinaryPath:     binaryPath,
+		PinnedVersion:  CloudflaredVersion,
+		URL:            binaryURL,
+		ChecksumPinned: c.SHA256 != "",
+		ChecksumURL:    c.mirrorChecksumsURL(),
+	}
+	status.Installed, err = c.store.FileExists(binaryPath)
+	if err != nil {
+		return nil, errors.WrapAndTrace(err)
+	}
+	if status.Installed {
+		status.InstalledVersion, _ = c.versionOf(binaryPath)
+	}
+	return status, nil
+}
+
+func (c Cloudflared) DownloadURL() (string, error) {
+	asset, err := cloudflaredAsset(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", errors.WrapAndTrace(err)
+	}
+	base := cloudflaredDownloadURL
+	if c.Mirror != "" {
+		base = strings.TrimSuffix(c.Mirror, "/")
+	}
+	return fmt.Sprintf("%s/%s/%s", base, CloudflaredVersion, asset), nil
+}
+
+func (c Cloudflared) mirrorChecksumsURL() string {
+	if c.Mirror == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(c.Mirror, "/"), CloudflaredVersion, cloudflaredMirrorChecksums)
+}
+
+// download fetches the asset, checks its sha256 and only then writes the binary
+func (c Cloudflared) download(ctx context.Context, binaryPath string) error {
+	binaryURL, err := c.DownloadURL()
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
-	defer resp.Body.Close() //nolint:errcheck // defer
+	asset := binaryURL[strings.LastIndex(binaryURL, "/")+1:]
+	want, err := c.expectedChecksum(ctx, asset)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	data, err := c.fetch(ctx, binaryURL)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return errors.NewValidationError(fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", binaryURL, want, got))
+	}
+	err = c.writeBinary(binaryPath, binaryURL, bytes.NewReader(data))
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+func (c Cloudflared) expectedChecksum(ctx context.Context, asset string) (string, error) {
+	if c.SHA256 != "" {
+		return strings.ToLower(c.SHA256), nil
+	}
+	if sumsURL := c.mirrorChecksumsURL(); sumsURL != "" {
+		body, err := c.fetch(ctx, sumsURL)
+		if err != nil {
+			return "", errors.WrapAndTrace(err)
+		}
+		sum, ok := ParseSHA256Sums(string(body))[asset]
+		if !ok {
+			return "", errors.NewValidationError(fmt.Sprintf("%s has no checksum for %s, pin one with: brev config set cloudflared-sha256 <sha256>", sumsURL, asset))
+		}
+		return sum, nil
 	}
+	body, err := c.fetch(ctx, fmt.Sprintf(cloudflaredReleaseURL, CloudflaredVersion))
+	if err != nil {
+		return "", errors.WrapAndTrace(err)
+	}
+	var release GithubReleaseMetadata
+	err = json.Unmarshal(body, &release)
+	if err != nil {
+		return "", errors.WrapAndTrace(err)
+	}
+	sum, ok := ParseCloudflaredChecksums(release.Body)[asset]
+	if !ok {
+		return "", errors.NewValidationError(fmt.Sprintf("no published checksum for %s in cloudflared %s, pin one with: brev config set cloudflared-sha256 <sha256>", asset, CloudflaredVersion))
+	}
+	return sum, nil
+}
 
-	err = c.store.MkdirAll(filepath.Dir(binaryPath), 0o755)
+// ParseCloudflaredChecksums reads the checksums cloudflare lists in its release notes
+func ParseCloudflaredChecksums(notes string) map[string]string {
+	sums := map[string]string{}
+	for _, m := range cloudflaredChecksumRe.FindAllStringSubmatch(notes, -1) {
+		sums[m[1]] = strings.ToLower(m[2])
+	}
+	return sums
+}
+
+// ParseSHA256Sums reads sha256sum output, "<sha256>  <file>" per line
+func ParseSHA256Sums(sums string) map[string]string {
+	parsed := map[string]string{}
+	for _, line := range strings.Split(sums, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !sha256Re.MatchString(fields[0]) {
+			continue
+		}
+		parsed[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return parsed
+}
+
+// writeBinary writes next to binaryPath and renames over it, a running
+// cloudflared can't be written to in place
+func (c Cloudflared) writeBinary(binaryPath, binaryURL string, data io.Reader) error {
+	err := c.store.MkdirAll(filepath.Dir(binaryPath), 0o755)
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
 
-	out, err := c.store.Create(binaryPath)
+	tmpPath := binaryPath + ".tmp"
+	out, err := c.store.Create(tmpPath)
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
-	defer out.Close() //nolint:errcheck // defer
 
-	var src io.Reader
+	src := data
 	if strings.HasSuffix(binaryURL, ".tgz") {
-		src = trytoUnTarGZ(resp.Body)
-	} else {
-		src = resp.Body
+		src = trytoUnTarGZ(data)
 	}
 
 	_, err = io.Copy(out, src)
+	_ = out.Close()
 	if err != nil {
 		return fmt.Errorf("error saving downloaded file: %v", err)
 	}
 
-	err = c.store.Chmod(binaryPath, 0o755)
+	err = c.store.Chmod(tmpPath, 0o755)
+	if err != nil {
+		return errors.WrapAndTrace(err)
+	}
+	err = c.store.Rename(tmpPath, binaryPath)
 	if err != nil {
 		return errors.WrapAndTrace(err)
 	}
 	return nil
 }
 
-func getCloudflaredBinaryDownloadURL() (string, error) {
-	switch runtime.GOOS {
+func (c Cloudflared) fetch(ctx context.Context, url string) ([]byte, error) {
+	if c.get != nil {
+		return c.get(ctx, url)
+	}
+	resp, err := collections.GetRequestWithContext(ctx, url)
+	if err != nil {
+		return nil, errors.WrapAndTrace(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // defer
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WrapAndTrace(err)
+	}
+	return data, nil
+}
+
+func (c Cloudflared) versionOf(binaryPath string) (string, error) {
+	if c.installedVersion != nil {
+		return c.installedVersion(binaryPath)
+	}
+	return InstalledCloudflaredVersion(binaryPath)
+}
+
+// InstalledCloudflaredVersion asks the binary at binaryPath for its version
+func InstalledCloudflaredVersion(binaryPath string) (string, error) {
+	out, err := exec.Command(binaryPath, "--version").CombinedOutput() //nolint:gosec // our own binary
+	if err != nil {
+		return "", errors.WrapAndTrace(err)
+	}
+	version := ParseCloudflaredVersion(string(out))
+	if version == "" {
+		return "", errors.Errorf("unexpected cloudflared --version output: %s", strings.TrimSpace(string(out)))
+	}
+	return version, nil
+}
+
+func ParseCloudflaredVersion(out string) string {
+	m := cloudflaredVersionRe.FindStringSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func cloudflaredAsset(goos, goarch string) (string, error) {
+	switch goos {
 	case "linux":
-		return fmt.Sprintf("https://github.com/cloudflare/cloudflared/releases/download/%s/cloudflared-linux-amd64", CloudflaredVersion), nil
+		if goarch == "arm64" {
+			return "cloudflared-linux-arm64", nil
+		}
+		return "cloudflared-linux-amd64", nil
 	case "darwin":
-		if runtime.GOARCH == "arm64" {
-			return fmt.Sprintf("https://github.com/cloudflare/cloudflared/releases/download/%s/cloudflared-darwin-arm64.tgz", CloudflaredVersion), nil
+		if goarch == "arm64" {
+			return "cloudflared-darwin-arm64.tgz", nil
 		}
-		return fmt.Sprintf("https://github.com/cloudflare/cloudflared/releases/download/%s/cloudflared-darwin-amd64.tgz", CloudflaredVersion), nil
+		return "cloudflared-darwin-amd64.tgz", nil
 	default:
-		return "", fmt.Errorf("unsupported OS %s for downloading Cloudflared binary", runtime.GOOS)
+		return "", fmt.Errorf("unsupported OS %s for downloading Cloudflared binary", goos)
 	}
 }
@@ -1,6 +1,20 @@
 package store
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/brevdev/brev-cli/pkg/auth"
@@ -41,3 +55,146 @@ func TestTask_DownloadCloudflaredBinary(t *testing.T) {
 	err := client.DownloadCloudflaredBinaryIfItDNE()
 	assert.NoError(t, err)
 }
+
+type fakeCloudflaredStore struct {
+	dir string
+}
+
+func (f fakeCloudflaredStore) GetBrevCloudflaredBinaryPath() (string, error) {
+	return filepath.Join(f.dir, "cloudflared"), nil
+}
+
+func (f fakeCloudflaredStore) FileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	return err == nil, nil
+}
+
+func (f fakeCloudflaredStore) DownloadBinary(string, string) error { return nil }
+
+func (f fakeCloudflaredStore) Chmod(path string, mode fs.FileMode) error {
+	return os.Chmod(path, mode) //nolint:wrapcheck // test
+}
+
+func (f fakeCloudflaredStore) MkdirAll(path string, mode fs.FileMode) error {
+	return os.MkdirAll(path, mode) //nolint:wrapcheck // test
+}
+
+func (f fakeCloudflaredStore) Create(path string) (io.WriteCloser, error) {
+	return os.Create(path) //nolint:wrapcheck,gosec // test
+}
+
+func (f fakeCloudflaredStore) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath) //nolint:wrapcheck // test
+}
+
+func TestCloudflaredVerifiedDownload(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("no cloudflared for this os")
+	}
+	asset, err := cloudflaredAsset(runtime.GOOS, runtime.GOARCH)
+	assert.NoError(t, err)
+	binary := []byte("cloudflared " + CloudflaredVersion)
+	served := binary
+	if strings.HasSuffix(asset, ".tgz") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "cloudflared", Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg}))
+		_, err = tw.Write(binary)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gz.Close())
+		served = buf.Bytes()
+	}
+	sum := sha256.Sum256(served)
+	notes := fmt.Sprintf("### SHA256 Checksums:\n```\ncloudflared-amd64.pkg: %064d\n%s: %s\n```\n", 0, asset, hex.EncodeToString(sum[:]))
+	release, err := json.Marshal(GithubReleaseMetadata{TagName: CloudflaredVersion, Body: notes})
+	assert.NoError(t, err)
+
+	mirrorSums := fmt.Sprintf("%064d  cloudflared-amd64.pkg\n%s *%s\n", 0, hex.EncodeToString(sum[:]), asset)
+
+	fetched := []string{}
+	installed := ""
+	cl := Cloudflared{
+		store: fakeCloudflaredStore{dir: t.TempDir()},
+		get: func(_ context.Context, url string) ([]byte, error) {
+			fetched = append(fetched, url)
+			switch {
+			case strings.HasPrefix(url, "https://api.github.com/"):
+				return release, nil
+			case strings.HasSuffix(url, "/checksums.txt"):
+				return []byte(mirrorSums), nil
+			}
+			return served, nil
+		},
+		installedVersion: func(string) (string, error) { return installed, nil },
+	}
+
+	assert.NoError(t, cl.DownloadCloudflaredBinaryIfItDNE())
+	assert.Equal(t, []string{
+		fmt.Sprintf(cloudflaredReleaseURL, CloudflaredVersion),
+		fmt.Sprintf("%s/%s/%s", cloudflaredDownloadURL, CloudflaredVersion, asset),
+	}, fetched)
+	path, _ := cl.store.GetBrevCloudflaredBinaryPath()
+	data, err := os.ReadFile(path) //nolint:gosec // test
+	assert.NoError(t, err)
+	assert.Equal(t, "cloudflared "+CloudflaredVersion, string(data))
+	assert.NoFileExists(t, path+".tmp")
+
+	// a mirror serves its own checksums, github isn't asked
+	cl.Mirror = "https://mirror.internal/cloudflared/"
+	fetched = nil
+	assert.NoError(t, cl.Update())
+	assert.Equal(t, []string{
+		fmt.Sprintf("https://mirror.internal/cloudflared/%s/checksums.txt", CloudflaredVersion),
+		fmt.Sprintf("https://mirror.internal/cloudflared/%s/%s", CloudflaredVersion, asset),
+	}, fetched)
+
+	// the pinned version is installed, nothing to do
+	installed = CloudflaredVersion
+	fetched = nil
+	assert.NoError(t, cl.DownloadCloudflaredBinaryIfItDNE())
+	assert.Empty(t, fetched)
+
+	// drifted, download again
+	installed = "2023.1.0"
+	assert.NoError(t, cl.DownloadCloudflaredBinaryIfItDNE())
+	assert.Len(t, fetched, 2)
+
+	// a pinned checksum is used instead of the release notes and must match
+	cl.SHA256 = strings.Repeat("ab", 32)
+	fetched = nil
+	err = cl.Update()
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.Len(t, fetched, 1)
+	data, _ = os.ReadFile(path) //nolint:gosec // test
+	assert.Equal(t, "cloudflared "+CloudflaredVersion, string(data), "a bad download isn't written")
+
+	// drifted but the download fails, the installed binary is kept
+	offline := func(context.Context, string) ([]byte, error) { return nil, fmt.Errorf("offline") }
+	cl.get = offline
+	assert.NoError(t, cl.DownloadCloudflaredBinaryIfItDNE())
+	data, _ = os.ReadFile(path) //nolint:gosec // test
+	assert.Equal(t, "cloudflared "+CloudflaredVersion, string(data))
+
+	// nothing installed to fall back on
+	cl.store = fakeCloudflaredStore{dir: t.TempDir()}
+	assert.Error(t, cl.DownloadCloudflaredBinaryIfItDNE())
+}
+
+func TestParseCloudflared(t *testing.T) {
+	assert.Equal(t, "2024.10.0", ParseCloudflaredVersion("cloudflared version 2024.10.0 (built 2024-10-07-1234 UTC)\n"))
+	assert.Equal(t, "", ParseCloudflaredVersion("segmentation fault"))
+
+	sha := strings.Repeat("A1", 32)
+	sums := ParseCloudflaredChecksums("notes\ncloudflared-linux-amd64: " + sha + "\ncloudflared-darwin-arm64.tgz:  short\n")
+	assert.Equal(t, map[string]string{"cloudflared-linux-amd64": strings.ToLower(sha)}, sums)
+	sums = ParseSHA256Sums(sha + "  cloudflared-linux-amd64\n" + sha + " *cloudflared-darwin-arm64.tgz\nshort  cloudflared-linux-arm64\n")
+	assert.Equal(t, map[string]string{"cloudflared-linux-amd64": strings.ToLower(sha), "cloudflared-darwin-arm64.tgz": strings.ToLower(sha)}, sums)
+
+	asset, err := cloudflaredAsset("linux", "arm64")
+	assert.NoError(t, err)
+	assert.Equal(t, "cloudflared-linux-arm64", asset)
+	_, err = cloudflaredAsset("windows", "amd64")
+	assert.Error(t, err)
+}
@@ -368,6 +368,14 @@ func (f FileStore) Create(target string) (io.WriteCloser, error) {
 	return file, nil
 }
 
+func (f FileStore) Rename(oldpath, newpath string) error {
+	err := f.fs.Rename(oldpath, newpath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
 func (f FileStore) MkdirAll(path string, mode os.FileMode) error {
 	err := f.fs.MkdirAll(path, mode)
 	if err != nil {
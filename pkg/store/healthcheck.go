@@ -1,6 +1,11 @@
 package store
 
-import breverrors "github.com/brevdev/brev-cli/pkg/errors"
+import (
+	"net/http"
+	"time"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
 
 const healthcheckPath = "api/health"
 
@@ -15,3 +20,16 @@ func (n NoAuthHTTPStore) Healthcheck() error {
 
 	return nil
 }
+
+// ServerTime is the api's clock, read from the Date header of a healthcheck
+func (n NoAuthHTTPStore) ServerTime() (time.Time, error) {
+	res, err := n.noAuthHTTPClient.restyClient.R().Get(healthcheckPath)
+	if err != nil {
+		return time.Time{}, breverrors.WrapAndTrace(err)
+	}
+	serverTime, err := http.ParseTime(res.Header().Get("Date"))
+	if err != nil {
+		return time.Time{}, breverrors.WrapAndTrace(err)
+	}
+	return serverTime, nil
+}
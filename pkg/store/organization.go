@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/brevdev/brev-cli/pkg/files"
@@ -58,6 +59,12 @@ func (s AuthHTTPStore) GetActiveOrganizationOrNil() (*entity.Organization, error
 		return org, nil
 	}
 
+	// BREV_ORG is picked for this command so it beats brev set, the config
+	// files only give a default for when brev set hasn't picked an org
+	if envOrg := config.GlobalConfig.GetOrgOverride(); envOrg != "" {
+		return s.getConfiguredOrganization(envOrg)
+	}
+
 	home, err := s.UserHomeDir()
 	if err != nil {
 		return nil, breverrors.WrapAndTrace(err)
@@ -69,6 +76,9 @@ func (s AuthHTTPStore) GetActiveOrganizationOrNil() (*entity.Organization, error
 		return nil, breverrors.WrapAndTrace(err)
 	}
 	if !exists {
+		if configOrg := config.GlobalConfig.GetDefaultOrg(); configOrg != "" {
+			return s.getConfiguredOrganization(configOrg)
+		}
 		return nil, nil
 	}
 
@@ -87,6 +97,20 @@ func (s AuthHTTPStore) GetActiveOrganizationOrNil() (*entity.Organization, error
 	return freshOrg, nil
 }
 
+// getConfiguredOrganization finds the org BREV_ORG or brev config sets, by name or id
+func (s AuthHTTPStore) getConfiguredOrganization(nameOrID string) (*entity.Organization, error) {
+	orgs, err := s.GetOrganizations(nil)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	for i, o := range orgs {
+		if o.ID == nameOrID || o.Name == nameOrID {
+			return &orgs[i], nil
+		}
+	}
+	return nil, breverrors.NewValidationError(fmt.Sprintf("org %s from BREV_ORG or brev config isn't one of your orgs", nameOrID))
+}
+
 // returns the 'set'/active organization or the default one or nil if no orgs exist
 func (s AuthHTTPStore) GetActiveOrganizationOrDefault() (*entity.Organization, error) {
 	org, err := s.GetActiveOrganizationOrNil()
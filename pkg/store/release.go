@@ -1,20 +1,29 @@
 package store
 
 import (
+	"fmt"
+
 	breverrors "github.com/brevdev/brev-cli/pkg/errors"
 	"github.com/go-resty/resty/v2"
 )
 
 type GithubReleaseMetadata struct {
-	TagName      string `json:"tag_name"`
-	IsDraft      bool   `json:"draft"`
-	IsPrerelease bool   `json:"prerelease"`
-	Name         string `json:"name"`
-	Body         string `json:"body"`
+	TagName      string               `json:"tag_name"`
+	IsDraft      bool                 `json:"draft"`
+	IsPrerelease bool                 `json:"prerelease"`
+	Name         string               `json:"name"`
+	Body         string               `json:"body"`
+	Assets       []GithubReleaseAsset `json:"assets"`
+}
+
+type GithubReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
 }
 
 const (
-	cliReleaseURL = "https://api.github.com/repos/brevdev/brev-cli/releases/latest"
+	cliReleaseURL      = "https://api.github.com/repos/brevdev/brev-cli/releases/latest"
+	cliReleaseByTagURL = "https://api.github.com/repos/brevdev/brev-cli/releases/tags/%s"
 )
 
 func (n NoAuthHTTPStore) GetLatestReleaseMetadata() (*GithubReleaseMetadata, error) {
@@ -32,3 +41,34 @@ func (n NoAuthHTTPStore) GetLatestReleaseMetadata() (*GithubReleaseMetadata, err
 
 	return &result, nil
 }
+
+func (n NoAuthHTTPStore) GetReleaseMetadata(tag string) (*GithubReleaseMetadata, error) {
+	var result GithubReleaseMetadata
+
+	client := resty.New()
+
+	res, err := client.R().SetResult(&result).Get(fmt.Sprintf(cliReleaseByTagURL, tag))
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+
+	return &result, nil
+}
+
+// DownloadReleaseAsset fetches a release asset into memory, they are small enough
+func (n NoAuthHTTPStore) DownloadReleaseAsset(url string) ([]byte, error) {
+	client := resty.New()
+
+	res, err := client.R().Get(url)
+	if err != nil {
+		return nil, breverrors.WrapAndTrace(err)
+	}
+	if res.IsError() {
+		return nil, NewHTTPResponseError(res)
+	}
+
+	return res.Body(), nil
+}
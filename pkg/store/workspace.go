@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/brevdev/brev-cli/pkg/config"
 	"github.com/brevdev/brev-cli/pkg/entity"
@@ -21,16 +24,18 @@ var (
 )
 
 type ModifyWorkspaceRequest struct {
-	WorkspaceClassID  string            `json:"workspaceClassId,omitempty"`
-	IsStoppable       *bool             `json:"isStoppable,omitempty"`
-	StartupScriptPath string            `json:"startupScriptPath,omitempty"`
-	Name              string            `json:"name,omitempty"`
-	IDEConfig         *entity.IDEConfig `json:"ideConfig,omitempty"`
-	Repos             entity.ReposV0    `json:"repos,omitempty"`
-	Execs             entity.ExecsV0    `json:"execs,omitempty"`
-	ReposV1           *entity.ReposV1   `json:"reposV1,omitempty"`
-	ExecsV1           *entity.ExecsV1   `json:"execsV1,omitempty"`
-	InstanceType      string            `json:"instanceType,omitempty"`
+	WorkspaceClassID  string             `json:"workspaceClassId,omitempty"`
+	IsStoppable       *bool              `json:"isStoppable,omitempty"`
+	StartupScriptPath string             `json:"startupScriptPath,omitempty"`
+	Name              string             `json:"name,omitempty"`
+	IDEConfig         *entity.IDEConfig  `json:"ideConfig,omitempty"`
+	Repos             entity.ReposV0     `json:"repos,omitempty"`
+	Execs             entity.ExecsV0     `json:"execs,omitempty"`
+	ReposV1           *entity.ReposV1    `json:"reposV1,omitempty"`
+	ExecsV1           *entity.ExecsV1    `json:"execsV1,omitempty"`
+	InstanceType      string             `json:"instanceType,omitempty"`
+	StopTimeout       *time.Duration     `json:"stopTimeout,omitempty"`
+	Labels            *map[string]string `json:"labels,omitempty"` // replaces all labels, an empty map clears them
 }
 
 type CreateWorkspacesOptions struct {
@@ -58,9 +63,10 @@ type CreateWorkspacesOptions struct {
 	VMOnlyMode           bool                 `json:"vmOnlyMode"`
 	PortMappings         map[string]string    `json:"portMappings"`
 	Files                interface{}          `json:"files"`
-	Labels               interface{}          `json:"labels"`
+	Labels               map[string]string    `json:"labels,omitempty"`
 	WorkspaceVersion     string               `json:"workspaceVersion"`
 	LaunchJupyterOnStart bool                 `json:"launchJupyterOnStart"`
+	StopTimeout          *time.Duration       `json:"stopTimeout,omitempty"`
 }
 
 var (
@@ -122,6 +128,20 @@ func (c *CreateWorkspacesOptions) WithGitRepo(gitRepo string) *CreateWorkspacesO
 	return c
 }
 
+// WithGitRepoOptions also sets up the git repo as a v1 repo so it can be
+// cloned shallow, sparse or pinned. It lands where the project repo would, so
+// the plain clone of GitRepo finds it already there.
+func (c *CreateWorkspacesOptions) WithGitRepoOptions(opts entity.GitRepoOptions) *CreateWorkspacesOptions {
+	if c.ReposV1 == nil {
+		c.ReposV1 = &entity.ReposV1{}
+	}
+	(*c.ReposV1)["project"] = entity.RepoV1{
+		Type:    entity.GitRepoType,
+		GitRepo: entity.GitRepo{Repository: c.GitRepo, GitRepoOptions: opts},
+	}
+	return c
+}
+
 func (c *CreateWorkspacesOptions) WithInstanceType(instanceType string) *CreateWorkspacesOptions {
 	c.InstanceType = instanceType
 	return c
@@ -575,6 +595,48 @@ func (s AuthHTTPStore) BuildVerbContainer(workspaceID string, verbYaml string) (
 	return &result, nil
 }
 
+func (f FileStore) getVerbLogPathFile(workspaceID string) (string, error) {
+	brevHome, err := f.GetBrevHomePath()
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return filepath.Join(brevHome, "verb_logs", workspaceID), nil
+}
+
+// SaveVerbLogPath records where the instance writes its last verb build log
+func (f FileStore) SaveVerbLogPath(workspaceID string, logFilePath string) error {
+	path, err := f.getVerbLogPathFile(workspaceID)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	err = f.OverWriteString(path, logFilePath)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+// GetVerbLogPath is the log of the last verb build started from this machine,
+// empty if there wasn't one
+func (f FileStore) GetVerbLogPath(workspaceID string) (string, error) {
+	path, err := f.getVerbLogPathFile(workspaceID)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	exists, err := f.FileExists(path)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	if !exists {
+		return "", nil
+	}
+	logFilePath, err := f.GetFileAsString(path)
+	if err != nil {
+		return "", breverrors.WrapAndTrace(err)
+	}
+	return strings.TrimSpace(logFilePath), nil
+}
+
 func (f FileStore) GetSetupParams() (*SetupParamsV0, error) {
 	file, err := f.fs.Open("/etc/meta/setup_v0.json")
 	if err != nil {
@@ -0,0 +1,201 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"sigs.k8s.io/yaml"
+
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+type OutputFormat string
+
+const (
+	TableOutput         OutputFormat = "table"
+	WideOutput          OutputFormat = "wide"
+	JSONOutput          OutputFormat = "json"
+	YAMLOutput          OutputFormat = "yaml"
+	CustomColumnsOutput OutputFormat = "custom-columns"
+)
+
+const customColumnsPrefix = "custom-columns="
+
+// OutputFlagUsage is the shared help text for --output flags. -o is --org on
+// commands that have an --org flag, their --output has no shorthand.
+const OutputFlagUsage = "output format: table|wide|json|yaml|custom-columns=HEADER:.path,..."
+
+type CustomColumn struct {
+	Header string
+	Path   string
+}
+
+type Output struct {
+	Format  OutputFormat
+	Columns []CustomColumn
+}
+
+// ParseOutput parses the value of an --output flag, an empty value is the default table
+func ParseOutput(value string) (*Output, error) {
+	if strings.HasPrefix(value, customColumnsPrefix) {
+		columns, err := parseCustomColumns(strings.TrimPrefix(value, customColumnsPrefix))
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		return &Output{Format: CustomColumnsOutput, Columns: columns}, nil
+	}
+	switch OutputFormat(value) {
+	case "", TableOutput:
+		return &Output{Format: TableOutput}, nil
+	case WideOutput, JSONOutput, YAMLOutput:
+		return &Output{Format: OutputFormat(value)}, nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unsupported output format %q, %s", value, OutputFlagUsage))
+	}
+}
+
+func parseCustomColumns(spec string) ([]CustomColumn, error) {
+	if spec == "" {
+		return nil, breverrors.NewValidationError("custom-columns requires at least one HEADER:.path column")
+	}
+	columns := []CustomColumn{}
+	for _, col := range strings.Split(spec, ",") {
+		parts := strings.SplitN(col, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || !strings.HasPrefix(parts[1], ".") {
+			return nil, breverrors.NewValidationError(fmt.Sprintf("invalid custom column %q, expected HEADER:.path", col))
+		}
+		columns = append(columns, CustomColumn{Header: parts[0], Path: parts[1]})
+	}
+	return columns, nil
+}
+
+// IsStructured is true when the output is meant for machines, so callers
+// should skip colors, hints and breadcrumbs
+func (o Output) IsStructured() bool {
+	return o.Format == JSONOutput || o.Format == YAMLOutput || o.Format == CustomColumnsOutput
+}
+
+func (o Output) IsWide() bool {
+	return o.Format == WideOutput
+}
+
+// PrintStructured writes v as json, yaml or custom columns. v should be a
+// struct or slice of structs with json tags.
+func (t *Terminal) PrintStructured(o Output, v interface{}) error {
+	err := WriteStructured(t.out, o, v)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	return nil
+}
+
+func WriteStructured(w io.Writer, o Output, v interface{}) error {
+	switch o.Format {
+	case JSONOutput:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	case YAMLOutput:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+		_, err = fmt.Fprint(w, string(b))
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	case CustomColumnsOutput:
+		err := writeCustomColumns(w, o.Columns, v)
+		if err != nil {
+			return breverrors.WrapAndTrace(err)
+		}
+	default:
+		return breverrors.NewValidationError(fmt.Sprintf("output format %q is not structured", o.Format))
+	}
+	return nil
+}
+
+func writeCustomColumns(w io.Writer, columns []CustomColumn, v interface{}) error {
+	// round trip through json so paths match the json field names
+	b, err := json.Marshal(v)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return breverrors.WrapAndTrace(err)
+	}
+	items, ok := generic.([]interface{})
+	if !ok {
+		items = []interface{}{generic}
+	}
+
+	ta := table.NewWriter()
+	ta.SetOutputMirror(w)
+	ta.Style().Options = getBrevTableOptions()
+	header := table.Row{}
+	for _, c := range columns {
+		header = append(header, c.Header)
+	}
+	ta.AppendHeader(header)
+	for _, item := range items {
+		row := table.Row{}
+		for _, c := range columns {
+			row = append(row, lookupPath(item, c.Path))
+		}
+		ta.AppendRow(row)
+	}
+	ta.Render()
+	return nil
+}
+
+// lookupPath resolves a dotted path like .tunnel.tunnelID, missing values render as <none>
+func lookupPath(item interface{}, path string) string {
+	current := item
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if key == "" {
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return "<none>"
+		}
+		current, ok = m[key]
+		if !ok || current == nil {
+			return "<none>"
+		}
+	}
+	switch val := current.(type) {
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return "<none>"
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func getBrevTableOptions() table.Options {
+	options := table.OptionsDefault
+	options.DrawBorder = false
+	options.SeparateColumns = false
+	options.SeparateRows = false
+	options.SeparateHeader = false
+	return options
+}
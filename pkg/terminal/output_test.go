@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type outputTestItem struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Port   int    `json:"port"`
+	Nested struct {
+		ID string `json:"id"`
+	} `json:"nested"`
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		value      string
+		want       Output
+		structured bool
+		wide       bool
+	}{
+		{"", Output{Format: TableOutput}, false, false},
+		{"table", Output{Format: TableOutput}, false, false},
+		{"wide", Output{Format: WideOutput}, false, true},
+		{"json", Output{Format: JSONOutput}, true, false},
+		{"yaml", Output{Format: YAMLOutput}, true, false},
+		{
+			"custom-columns=NAME:.name,ID:.nested.id",
+			Output{Format: CustomColumnsOutput, Columns: []CustomColumn{{Header: "NAME", Path: ".name"}, {Header: "ID", Path: ".nested.id"}}},
+			true, false,
+		},
+		{
+			"custom-columns=ALL:.",
+			Output{Format: CustomColumnsOutput, Columns: []CustomColumn{{Header: "ALL", Path: "."}}},
+			true, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			o, err := ParseOutput(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *o)
+			assert.Equal(t, tt.structured, o.IsStructured())
+			assert.Equal(t, tt.wide, o.IsWide())
+		})
+	}
+}
+
+func TestParseOutputErrors(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{"xml", `unsupported output format "xml"`},
+		{"JSON", `unsupported output format "JSON"`},
+		{"custom-columns", `unsupported output format "custom-columns"`},
+		{"custom-columns=", "requires at least one HEADER:.path column"},
+		{"custom-columns=NAME", `invalid custom column "NAME"`},
+		{"custom-columns=:.name", `invalid custom column ":.name"`},
+		{"custom-columns=NAME:name", `invalid custom column "NAME:name"`},
+		{"custom-columns=NAME:.name,", `invalid custom column ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseOutput(tt.value)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	item := map[string]interface{}{
+		"name":   "ws",
+		"port":   json.Number("22"),
+		"nested": map[string]interface{}{"id": "abc", "empty": nil},
+		"labels": []interface{}{"a", "b"},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{".name", "ws"},
+		{".port", "22"},
+		{".nested.id", "abc"},
+		{".nested", `{"empty":null,"id":"abc"}`},
+		{".labels", `["a","b"]`},
+		{".nested.empty", "<none>"},
+		{".missing", "<none>"},
+		{".name.deeper", "<none>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, lookupPath(item, tt.path))
+		})
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	item := outputTestItem{Name: "ws", Status: "RUNNING", Port: 22}
+	item.Nested.ID = "abc"
+
+	buf := &bytes.Buffer{}
+	err := WriteStructured(buf, Output{Format: JSONOutput}, []outputTestItem{item})
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"name": "ws"`)
+
+	buf = &bytes.Buffer{}
+	err = WriteStructured(buf, Output{Format: YAMLOutput}, item)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "status: RUNNING")
+
+	buf = &bytes.Buffer{}
+	o, err := ParseOutput("custom-columns=NAME:.name,PORT:.port,ID:.nested.id,MISSING:.nope")
+	assert.Nil(t, err)
+	err = WriteStructured(buf, *o, []outputTestItem{item})
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "NAME")
+	assert.Contains(t, buf.String(), "ws")
+	assert.Contains(t, buf.String(), "22")
+	assert.Contains(t, buf.String(), "abc")
+	assert.Contains(t, buf.String(), "<none>")
+
+	err = WriteStructured(buf, Output{Format: TableOutput}, item)
+	assert.NotNil(t, err)
+}
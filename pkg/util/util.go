@@ -76,10 +76,6 @@ func RunEAsync(calls ...func() error) RunEResult {
 	return res
 }
 
-func IsGitURL(u string) bool {
-	return strings.Contains(u, "https://") || strings.Contains(u, "git@")
-}
-
 func DoesPathExist(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {
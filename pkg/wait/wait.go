@@ -0,0 +1,275 @@
+// Package wait polls an instance until a readiness condition holds
+package wait
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+// exit codes for brev wait, 1 is any other error
+const (
+	ExitCodeTimeout     = 2
+	ExitCodeUnreachable = 3
+)
+
+type WorkspaceGetter interface {
+	GetWorkspace(workspaceID string) (*entity.Workspace, error)
+}
+
+type Condition interface {
+	// Check reports whether the condition holds for the latest fetch of the
+	// instance and a short state string for progress output
+	Check(workspace *entity.Workspace) (bool, string, error)
+	String() string
+}
+
+type Options struct {
+	Timeout         time.Duration // 0 waits forever
+	InitialDelay    time.Duration // wait before the first check, for callers that just changed the instance
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	OnPoll          func(state string)
+
+	sleep func(time.Duration)
+}
+
+func DefaultOptions() Options {
+	return Options{
+		InitialInterval: 2 * time.Second,
+		MaxInterval:     15 * time.Second,
+		Multiplier:      1.5,
+	}
+}
+
+type TimeoutError struct {
+	Condition string
+	LastState string
+	Timeout   time.Duration
+}
+
+func (e TimeoutError) ExitCode() int {
+	return ExitCodeTimeout
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for %s, last state: %s", e.Timeout, e.Condition, e.LastState)
+}
+
+// UnreachableError means the instance got into a state the condition can't recover from
+type UnreachableError struct {
+	Condition string
+	State     string
+}
+
+func (e UnreachableError) ExitCode() int {
+	return ExitCodeUnreachable
+}
+
+func (e UnreachableError) Error() string {
+	return fmt.Sprintf("%s can not be reached, instance is %s", e.Condition, e.State)
+}
+
+// Until polls the instance with exponential backoff until cond holds
+func Until(getter WorkspaceGetter, workspaceID string, cond Condition, opts Options) (*entity.Workspace, error) {
+	sleep := opts.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+
+	sleep(opts.InitialDelay)
+	lastState := "unknown"
+	for {
+		workspace, err := getter.GetWorkspace(workspaceID)
+		if err != nil {
+			return nil, breverrors.WrapAndTrace(err)
+		}
+		done, state, err := cond.Check(workspace)
+		if err != nil {
+			return workspace, breverrors.WrapAndTrace(err)
+		}
+		lastState = state
+		if opts.OnPoll != nil {
+			opts.OnPoll(state)
+		}
+		if done {
+			return workspace, nil
+		}
+
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return workspace, TimeoutError{Condition: cond.String(), LastState: lastState, Timeout: opts.Timeout}
+			}
+			if interval > remaining {
+				interval = remaining
+			}
+		}
+		sleep(interval)
+		interval = nextInterval(interval, opts)
+	}
+}
+
+func nextInterval(interval time.Duration, opts Options) time.Duration {
+	if opts.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+	}
+	if opts.MaxInterval > 0 && interval > opts.MaxInterval {
+		interval = opts.MaxInterval
+	}
+	return interval
+}
+
+// ParseCondition parses the value of --for, ex: status=RUNNING, health=HEALTHY, ssh, verb=COMPLETED
+func ParseCondition(value string) (Condition, error) {
+	if value == "ssh" {
+		return SSHCondition{}, nil
+	}
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, breverrors.NewValidationError(fmt.Sprintf("invalid condition %q, expected status=STATUS, health=STATUS, verb=STATUS or ssh", value))
+	}
+	want := strings.ToUpper(parts[1])
+	switch parts[0] {
+	case "status":
+		return StatusCondition{Status: want}, nil
+	case "health":
+		return HealthCondition{Health: want}, nil
+	case "verb":
+		return VerbCondition{Status: entity.VerbBuildStatus(want)}, nil
+	default:
+		return nil, breverrors.NewValidationError(fmt.Sprintf("unknown condition %q, expected status, health, verb or ssh", parts[0]))
+	}
+}
+
+// states an instance doesn't come back from on its own
+var terminalStatuses = map[string]bool{
+	entity.Failure:  true,
+	entity.Deleting: true,
+}
+
+type StatusCondition struct {
+	Status string
+}
+
+func (c StatusCondition) Check(workspace *entity.Workspace) (bool, string, error) {
+	if workspace.Status == c.Status {
+		return true, workspace.Status, nil
+	}
+	if terminalStatuses[workspace.Status] {
+		return false, workspace.Status, UnreachableError{Condition: c.String(), State: workspace.Status}
+	}
+	return false, workspace.Status, nil
+}
+
+func (c StatusCondition) String() string {
+	return "status=" + c.Status
+}
+
+type HealthCondition struct {
+	Health string
+}
+
+func (c HealthCondition) Check(workspace *entity.Workspace) (bool, string, error) {
+	if workspace.HealthStatus == c.Health {
+		return true, workspace.HealthStatus, nil
+	}
+	if terminalStatuses[workspace.Status] {
+		return false, workspace.Status, UnreachableError{Condition: c.String(), State: workspace.Status}
+	}
+	state := workspace.HealthStatus
+	if state == "" {
+		state = workspace.Status
+	}
+	return false, state, nil
+}
+
+func (c HealthCondition) String() string {
+	return "health=" + c.Health
+}
+
+type VerbCondition struct {
+	Status entity.VerbBuildStatus
+}
+
+func (c VerbCondition) Check(workspace *entity.Workspace) (bool, string, error) {
+	state := string(workspace.VerbBuildStatus)
+	if workspace.VerbBuildStatus == c.Status {
+		return true, state, nil
+	}
+	if workspace.VerbBuildStatus == entity.CreateFailed {
+		return false, state, UnreachableError{Condition: c.String(), State: state}
+	}
+	if terminalStatuses[workspace.Status] {
+		return false, workspace.Status, UnreachableError{Condition: c.String(), State: workspace.Status}
+	}
+	if state == "" {
+		state = workspace.Status
+	}
+	return false, state, nil
+}
+
+func (c VerbCondition) String() string {
+	return "verb=" + string(c.Status)
+}
+
+// SSHCondition holds once the instance is running and its ssh alias accepts a connection
+type SSHCondition struct {
+	Host  bool                     // probe the host instead of the container
+	Probe func(alias string) error // defaults to running ssh
+}
+
+func (c SSHCondition) Check(workspace *entity.Workspace) (bool, string, error) {
+	if workspace.Status != entity.Running {
+		if terminalStatuses[workspace.Status] {
+			return false, workspace.Status, UnreachableError{Condition: c.String(), State: workspace.Status}
+		}
+		return false, workspace.Status, nil
+	}
+	alias := string(workspace.GetLocalIdentifier())
+	if c.Host {
+		alias = string(workspace.GetHostIdentifier())
+	}
+	probe := c.Probe
+	if probe == nil {
+		probe = ProbeSSH
+	}
+	err := probe(alias)
+	if err != nil {
+		// a failed probe just means sshd isn't up yet
+		return false, "waiting for ssh", nil
+	}
+	return true, "ssh ready", nil
+}
+
+func (c SSHCondition) String() string {
+	return "ssh"
+}
+
+// ProbeSSH runs a no-op command over the alias from the brev ssh config
+func ProbeSSH(alias string) error {
+	cmd := exec.Command("ssh", "-o", "ConnectTimeout=3", "-o", "BatchMode=yes", alias, "true") //nolint:gosec // alias comes from the instance name
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return breverrors.Wrap(err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var (
+	_ breverrors.ExitCoder = TimeoutError{}
+	_ breverrors.ExitCoder = UnreachableError{}
+)
@@ -0,0 +1,112 @@
+package wait
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brevdev/brev-cli/pkg/entity"
+	breverrors "github.com/brevdev/brev-cli/pkg/errors"
+)
+
+type fakeGetter struct {
+	workspaces []entity.Workspace
+	calls      int
+}
+
+func (f *fakeGetter) GetWorkspace(_ string) (*entity.Workspace, error) {
+	i := f.calls
+	if i >= len(f.workspaces) {
+		i = len(f.workspaces) - 1
+	}
+	f.calls++
+	return &f.workspaces[i], nil
+}
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.sleep = func(time.Duration) {}
+	return opts
+}
+
+func TestParseCondition(t *testing.T) {
+	c, err := ParseCondition("status=running")
+	assert.Nil(t, err)
+	assert.Equal(t, StatusCondition{Status: "RUNNING"}, c)
+
+	c, err = ParseCondition("ssh")
+	assert.Nil(t, err)
+	assert.Equal(t, SSHCondition{}, c)
+
+	c, err = ParseCondition("verb=COMPLETED")
+	assert.Nil(t, err)
+	assert.Equal(t, VerbCondition{Status: entity.Completed}, c)
+
+	_, err = ParseCondition("status=")
+	assert.NotNil(t, err)
+	_, err = ParseCondition("color=blue")
+	assert.NotNil(t, err)
+}
+
+func TestUntil(t *testing.T) {
+	getter := &fakeGetter{workspaces: []entity.Workspace{
+		{Status: entity.Deploying},
+		{Status: entity.Starting},
+		{Status: entity.Running},
+	}}
+	states := []string{}
+	opts := testOptions()
+	opts.OnPoll = func(state string) { states = append(states, state) }
+
+	ws, err := Until(getter, "ws1", StatusCondition{Status: entity.Running}, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Running, ws.Status)
+	assert.Equal(t, []string{entity.Deploying, entity.Starting, entity.Running}, states)
+}
+
+func TestUntilUnreachable(t *testing.T) {
+	getter := &fakeGetter{workspaces: []entity.Workspace{{Status: entity.Failure}}}
+
+	_, err := Until(getter, "ws1", StatusCondition{Status: entity.Running}, testOptions())
+	assert.True(t, errors.As(err, &UnreachableError{}))
+	assert.Equal(t, ExitCodeUnreachable, breverrors.GetExitCode(err))
+}
+
+func TestUntilTimeout(t *testing.T) {
+	getter := &fakeGetter{workspaces: []entity.Workspace{{Status: entity.Starting}}}
+	opts := testOptions()
+	opts.Timeout = time.Nanosecond
+	opts.sleep = func(time.Duration) { time.Sleep(time.Millisecond) }
+
+	_, err := Until(getter, "ws1", StatusCondition{Status: entity.Running}, opts)
+	assert.True(t, errors.As(err, &TimeoutError{}))
+	assert.Equal(t, ExitCodeTimeout, breverrors.GetExitCode(err))
+}
+
+func TestSSHCondition(t *testing.T) {
+	probed := []string{}
+	probeErr := errors.New("connection refused")
+	cond := SSHCondition{Probe: func(alias string) error {
+		probed = append(probed, alias)
+		return probeErr
+	}}
+	ws := &entity.Workspace{Name: "my-instance", Status: entity.Starting}
+
+	done, _, err := cond.Check(ws)
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Empty(t, probed)
+
+	ws.Status = entity.Running
+	done, _, err = cond.Check(ws)
+	assert.Nil(t, err)
+	assert.False(t, done)
+
+	probeErr = nil
+	done, _, err = cond.Check(ws)
+	assert.Nil(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []string{"my-instance", "my-instance"}, probed)
+}